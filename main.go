@@ -1,17 +1,136 @@
 package main
 
 import (
+	"api/src/armazenamento"
+	"api/src/banco"
 	"api/src/config"
+	"api/src/controllers"
+	"api/src/logs"
+	"api/src/metricas"
+	"api/src/rastreamento"
+	"api/src/repositorios"
 	"api/src/router"
+	"api/src/tarefas"
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
 func main() {
+	reconstruirLinhasDoTempo := flag.Bool(
+		"reconstruir-linhas-do-tempo", false,
+		"reconstrói as linhas do tempo de todos os usuários e encerra, sem subir a API",
+	)
+	flag.Parse()
+
 	config.Carregar()
-	r := router.Gerar()
+	if erro := logs.Configurar(config.NivelLog, config.FormatoLog); erro != nil {
+		log.Fatal(erro)
+	}
+
+	ctx, cancelar := context.WithCancel(context.Background())
+	defer cancelar()
+
+	encerrarRastreamento, erro := rastreamento.Configurar(
+		ctx, config.RastreamentoEndpoint, config.RastreamentoInseguro, config.RastreamentoAmostragem,
+	)
+	if erro != nil {
+		log.Fatal(erro)
+	}
+	defer encerrarRastreamento(context.Background())
+
+	if erro = armazenamento.Carregar(); erro != nil {
+		log.Fatal(erro)
+	}
+
+	db, erro := banco.Conectar()
+	if erro != nil {
+		log.Fatal(erro)
+	}
+	defer db.Close()
+
+	if erro = metricas.RegistrarBanco(db); erro != nil {
+		log.Fatal(erro)
+	}
+
+	r := router.Gerar(db)
+	// As tarefas param quando o ctx é cancelado, mas o que já começaram, como devolver uma exportação
+	// interrompida para a fila, ainda precisa chegar ao banco
+	repos := repositorios.NovoRepositories(context.WithoutCancel(ctx), db)
+
+	if *reconstruirLinhasDoTempo {
+		if erro = tarefas.ReconstruirLinhasDoTempo(ctx, repos); erro != nil {
+			log.Fatal(erro)
+		}
+		return
+	}
+
+	tarefasEmAndamento := tarefas.Iniciar(ctx, repos)
+
+	servidor := &http.Server{
+		Addr:         fmt.Sprintf(":%d", config.Porta),
+		Handler:      r,
+		ReadTimeout:  config.TempoLimiteLeitura,
+		WriteTimeout: config.TempoLimiteEscrita,
+		IdleTimeout:  config.TempoLimiteOcioso,
+	}
+
+	sinal, pararSinais := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer pararSinais()
+
+	falhaServidor := make(chan error, 1)
+	go func() {
+		slog.Info("API rodando", "porta", config.Porta)
+		falhaServidor <- servidor.ListenAndServe()
+	}()
+
+	select {
+	case erro = <-falhaServidor:
+		log.Fatal(erro)
+	case <-sinal.Done():
+	}
+
+	encerrar(servidor, cancelar, tarefasEmAndamento)
+}
+
+// encerrar para a API sem derrubar o que está em andamento: o /readyz passa a responder 503 e, passado o
+// config.AtrasoEncerramento para o balanceador tirar a API de rotação, o servidor deixa de aceitar conexões e
+// espera as requisições abertas, e as tarefas em segundo plano são canceladas. Essa última parte tem o prazo de
+// config.EsperaEncerramento
+func encerrar(servidor *http.Server, cancelarTarefas context.CancelFunc, tarefasEmAndamento *sync.WaitGroup) {
+	slog.Info("encerrando a API",
+		"atraso", config.AtrasoEncerramento.String(), "espera", config.EsperaEncerramento.String(),
+	)
+	controllers.IniciarEncerramento()
+	time.Sleep(config.AtrasoEncerramento)
+
+	prazo, cancelarPrazo := context.WithTimeout(context.Background(), config.EsperaEncerramento)
+	defer cancelarPrazo()
+
+	cancelarTarefas()
+
+	if erro := servidor.Shutdown(prazo); erro != nil {
+		slog.Error("requisições ainda em andamento ao fim da espera", "erro", erro)
+	}
+
+	tarefasConcluidas := make(chan struct{})
+	go func() {
+		tarefasEmAndamento.Wait()
+		close(tarefasConcluidas)
+	}()
 
-	fmt.Printf("API rodando na porta %d\n", config.Porta)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.Porta), r))
+	select {
+	case <-tarefasConcluidas:
+		slog.Info("API encerrada")
+	case <-prazo.Done():
+		slog.Error("tarefas ainda em andamento ao fim da espera")
+	}
 }
@@ -1,6 +1,29 @@
 CREATE DATABASE IF NOT EXISTS devbook;
 USE devbook;
 
+DROP TABLE IF EXISTS versao_esquema;
+DROP TABLE IF EXISTS auditoria;
+DROP TABLE IF EXISTS palavras_silenciadas;
+DROP TABLE IF EXISTS regras_filtro;
+DROP TABLE IF EXISTS advertencias;
+DROP TABLE IF EXISTS decisoes_moderacao;
+DROP TABLE IF EXISTS denuncias;
+DROP TABLE IF EXISTS exportacoes;
+DROP TABLE IF EXISTS nicks_anteriores;
+DROP TABLE IF EXISTS tendencias_calculadas;
+DROP TABLE IF EXISTS tendencias_hashtags;
+DROP TABLE IF EXISTS tendencias_publicacoes;
+DROP TABLE IF EXISTS sugestoes_calculadas;
+DROP TABLE IF EXISTS sugestoes;
+DROP TABLE IF EXISTS linha_do_tempo;
+DROP TABLE IF EXISTS reacoes;
+DROP TABLE IF EXISTS salvos;
+DROP TABLE IF EXISTS colecoes;
+DROP TABLE IF EXISTS enquete_votos;
+DROP TABLE IF EXISTS enquete_opcoes;
+DROP TABLE IF EXISTS enquetes;
+DROP TABLE IF EXISTS trechos;
+DROP TABLE IF EXISTS anexos;
 DROP TABLE IF EXISTS publicacoes;
 DROP TABLE IF EXISTS seguidores;
 DROP TABLE IF EXISTS usuarios;
@@ -11,7 +34,25 @@ CREATE TABLE usuarios(
     nick varchar(50) not null unique,
     email varchar(50) not null unique,
     senha varchar(100) not null,
-    criadoEm timestamp default current_timestamp()
+    criadoEm timestamp default current_timestamp(),
+    -- deletadoEm marca a conta como desativada; exclusaoAgendadaEm, quando preenchida, é a data em que ela
+    -- vai ser removida definitivamente
+    deletadoEm timestamp null default null,
+    exclusaoAgendadaEm timestamp null default null,
+    nickAlteradoEm timestamp null default null,
+
+    -- Não há cadastro de moderadores pela API; a marcação é feita direto no banco
+    moderador boolean not null default false,
+    administrador boolean not null default false,
+    suspensoAte timestamp null default null,
+    -- Preenchida pelo antispam; enquanto estiver preenchida a conta não publica nem segue ninguém
+    quarentenaEm timestamp null default null,
+
+    -- Contadores mantidos junto com as operações que os alteram, para o perfil não precisar contar as linhas.
+    -- Os de seguidores só contam relações com contas que não estão na lixeira
+    totalSeguidores int not null default 0,
+    totalSeguindo int not null default 0,
+    totalPublicacoes int not null default 0
 ) ENGINE=INNODB;
 
 CREATE TABLE seguidores(
@@ -25,7 +66,10 @@ CREATE TABLE seguidores(
     REFERENCES usuarios(id)
     ON DELETE CASCADE,
 
-    primary key(usuario_id, seguidor_id)
+    seguidoEm timestamp default current_timestamp,
+
+    primary key(usuario_id, seguidor_id),
+    INDEX (seguidor_id, seguidoEm)
 ) ENGINE=INNODB;
 
 CREATE TABLE publicacoes(
@@ -39,5 +83,404 @@ CREATE TABLE publicacoes(
     ON DELETE CASCADE,
 
     curtidas int default 0,
+    criadaEm timestamp default current_timestamp,
+    deletadoEm timestamp null default null,
+    status varchar(20) not null default 'publicada',
+    publicarEm timestamp null default null,
+    fixadaEm timestamp null default null,
+    -- Preenchida quando a moderação esconde a publicação; o autor não consegue desfazer
+    ocultadaEm timestamp null default null,
+    -- Impressão digital do título e do conteúdo normalizados, usada pelo antispam para achar conteúdo repetido
+    hashConteudo char(64) not null default '',
+    INDEX (status, publicarEm),
+    INDEX (autor_id, criadaEm),
+    INDEX (hashConteudo, criadaEm)
+) ENGINE=INNODB;
+
+CREATE TABLE anexos(
+    id int auto_increment primary key,
+
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id)
+    REFERENCES publicacoes(id)
+    ON DELETE CASCADE,
+
+    chave varchar(255) not null,
+    chaveMiniatura varchar(255) not null,
+    tipo varchar(50) not null,
+    tamanho int not null,
+    largura int not null,
+    altura int not null,
+    criadoEm timestamp default current_timestamp,
+
+    INDEX (chave),
+    INDEX (chaveMiniatura)
+) ENGINE=INNODB;
+
+CREATE TABLE trechos(
+    id int auto_increment primary key,
+
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id)
+    REFERENCES publicacoes(id)
+    ON DELETE CASCADE,
+
+    linguagem varchar(50) not null,
+    nomeArquivo varchar(100) not null default '',
+    codigo mediumtext not null
+) ENGINE=INNODB;
+
+CREATE TABLE enquetes(
+    id int auto_increment primary key,
+
+    publicacao_id int not null unique,
+    FOREIGN KEY (publicacao_id)
+    REFERENCES publicacoes(id)
+    ON DELETE CASCADE,
+
+    multiplaEscolha boolean not null default false,
+    anonima boolean not null default false,
+    expiraEm timestamp not null
+) ENGINE=INNODB;
+
+CREATE TABLE enquete_opcoes(
+    id int auto_increment primary key,
+
+    enquete_id int not null,
+    FOREIGN KEY (enquete_id)
+    REFERENCES enquetes(id)
+    ON DELETE CASCADE,
+
+    texto varchar(100) not null,
+    posicao int not null
+) ENGINE=INNODB;
+
+CREATE TABLE enquete_votos(
+    enquete_id int not null,
+    FOREIGN KEY (enquete_id)
+    REFERENCES enquetes(id)
+    ON DELETE CASCADE,
+
+    opcao_id int not null,
+    FOREIGN KEY (opcao_id)
+    REFERENCES enquete_opcoes(id)
+    ON DELETE CASCADE,
+
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    -- Em enquetes de escolha única a vaga é sempre 0, então a chave única garante um voto por usuário.
+    -- Em enquetes de múltipla escolha a vaga é o id da opção, permitindo um voto por opção
+    vaga int not null,
+    votadoEm timestamp default current_timestamp,
+
+    primary key(enquete_id, usuario_id, vaga)
+) ENGINE=INNODB;
+
+CREATE TABLE colecoes(
+    id int auto_increment primary key,
+
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    nome varchar(50) not null,
+    criadaEm timestamp default current_timestamp,
+
+    unique(usuario_id, nome)
+) ENGINE=INNODB;
+
+CREATE TABLE salvos(
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id)
+    REFERENCES publicacoes(id)
+    ON DELETE CASCADE,
+
+    colecao_id int null,
+    FOREIGN KEY (colecao_id)
+    REFERENCES colecoes(id)
+    ON DELETE SET NULL,
+
+    salvoEm timestamp default current_timestamp,
+
+    primary key(usuario_id, publicacao_id)
+) ENGINE=INNODB;
+
+CREATE TABLE reacoes(
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id)
+    REFERENCES publicacoes(id)
+    ON DELETE CASCADE,
+
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    -- Os tipos são emojis, que precisam do utf8mb4. A comparação é binária porque a collation padrão
+    -- considera vários emojis iguais e juntaria reações diferentes na contagem e no filtro por tipo
+    tipo varchar(16) character set utf8mb4 collate utf8mb4_bin not null,
+    reagidoEm timestamp default current_timestamp,
+
+    primary key(publicacao_id, usuario_id),
+    INDEX(publicacao_id, tipo)
+) ENGINE=INNODB;
+
+CREATE TABLE linha_do_tempo(
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id)
+    REFERENCES publicacoes(id)
+    ON DELETE CASCADE,
+
+    autor_id int not null,
+    publicadaEm timestamp not null,
+
+    primary key(usuario_id, publicacao_id),
+    INDEX(usuario_id, publicadaEm),
+    INDEX(usuario_id, autor_id),
+    INDEX(publicacao_id)
+) ENGINE=INNODB;
+
+CREATE TABLE sugestoes(
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    sugerido_id int not null,
+    FOREIGN KEY (sugerido_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    conexoesEmComum int not null,
+    posicao int not null,
+
+    primary key(usuario_id, sugerido_id)
+) ENGINE=INNODB;
+
+CREATE TABLE sugestoes_calculadas(
+    usuario_id int primary key,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    calculadasEm timestamp not null,
+    INDEX(calculadasEm)
+) ENGINE=INNODB;
+
+CREATE TABLE tendencias_publicacoes(
+    janela varchar(3) not null,
+    posicao int not null,
+
+    publicacao_id int not null,
+    FOREIGN KEY (publicacao_id)
+    REFERENCES publicacoes(id)
+    ON DELETE CASCADE,
+
+    pontuacao double not null,
+
+    primary key(janela, posicao)
+) ENGINE=INNODB;
+
+CREATE TABLE tendencias_hashtags(
+    janela varchar(3) not null,
+    posicao int not null,
+    hashtag varchar(100) character set utf8mb4 not null,
+    publicacoes int not null,
+    pontuacao double not null,
+
+    primary key(janela, posicao)
+) ENGINE=INNODB;
+
+CREATE TABLE tendencias_calculadas(
+    janela varchar(3) primary key,
+    calculadasEm timestamp not null
+) ENGINE=INNODB;
+
+CREATE TABLE nicks_anteriores(
+    id int auto_increment primary key,
+
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    nick varchar(50) not null,
+    trocadoEm timestamp default current_timestamp,
+
+    INDEX(nick, trocadoEm)
+) ENGINE=INNODB;
+
+CREATE TABLE exportacoes(
+    id int auto_increment primary key,
+
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    status varchar(20) not null default 'pendente',
+    chave varchar(255) null,
+    solicitadaEm timestamp default current_timestamp,
+    iniciadaEm timestamp null,
+    concluidaEm timestamp null,
+    expiraEm timestamp null,
+
+    INDEX(status, solicitadaEm)
+) ENGINE=INNODB;
+
+-- As denúncias e o histórico de decisões continuam existindo quando as contas ou publicações envolvidas
+-- são excluídas, para que a auditoria da moderação não se perca
+CREATE TABLE denuncias(
+    id int auto_increment primary key,
+
+    denunciante_id int null,
+    FOREIGN KEY (denunciante_id)
+    REFERENCES usuarios(id)
+    ON DELETE SET NULL,
+
+    usuario_id int null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE SET NULL,
+
+    publicacao_id int null,
+    FOREIGN KEY (publicacao_id)
+    REFERENCES publicacoes(id)
+    ON DELETE SET NULL,
+
+    motivo varchar(30) not null,
+    descricao varchar(500) not null default '',
+    status varchar(20) not null default 'aberta',
+
+    moderador_id int null,
+    FOREIGN KEY (moderador_id)
+    REFERENCES usuarios(id)
+    ON DELETE SET NULL,
+
+    acao varchar(20) null,
+    criadaEm timestamp default current_timestamp,
+    resolvidaEm timestamp null,
+
+    INDEX(status, criadaEm)
+) ENGINE=INNODB;
+
+CREATE TABLE decisoes_moderacao(
+    id int auto_increment primary key,
+
+    denuncia_id int not null,
+    FOREIGN KEY (denuncia_id)
+    REFERENCES denuncias(id),
+
+    moderador_id int null,
+    FOREIGN KEY (moderador_id)
+    REFERENCES usuarios(id)
+    ON DELETE SET NULL,
+
+    acao varchar(20) not null,
+    observacao varchar(500) not null default '',
+    criadaEm timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE advertencias(
+    id int auto_increment primary key,
+
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    denuncia_id int null,
+    FOREIGN KEY (denuncia_id)
+    REFERENCES denuncias(id)
+    ON DELETE SET NULL,
+
+    motivo varchar(30) not null,
+    observacao varchar(500) not null default '',
     criadaEm timestamp default current_timestamp
 ) ENGINE=INNODB;
+
+CREATE TABLE regras_filtro(
+    id int auto_increment primary key,
+    tipo varchar(20) not null,
+    padrao varchar(255) character set utf8mb4 not null,
+    acao varchar(20) not null,
+
+    moderador_id int null,
+    FOREIGN KEY (moderador_id)
+    REFERENCES usuarios(id)
+    ON DELETE SET NULL,
+
+    criadaEm timestamp default current_timestamp
+) ENGINE=INNODB;
+
+CREATE TABLE palavras_silenciadas(
+    usuario_id int not null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE CASCADE,
+
+    palavra varchar(50) character set utf8mb4 not null,
+    -- Expressão regular que encontra a palavra só quando ela está inteira, usada no regexp_like do feed
+    padrao varchar(200) character set utf8mb4 not null,
+    criadaEm timestamp default current_timestamp,
+
+    primary key(usuario_id, palavra)
+) ENGINE=INNODB;
+
+-- Registro só de inclusão: os gatilhos abaixo recusam qualquer update ou delete feito por comando. Quando uma
+-- conta é excluída, as linhas dela ficam e só as chaves estrangeiras viram nulas; os IDs continuam guardados
+-- no estado final (depois) de cada registro
+CREATE TABLE auditoria(
+    id bigint auto_increment primary key,
+    acao varchar(30) not null,
+
+    -- Quem fez a ação; nulo nas ações feitas pelo sistema ou por alguém que não se identificou
+    ator_id int null,
+    FOREIGN KEY (ator_id)
+    REFERENCES usuarios(id)
+    ON DELETE SET NULL,
+
+    -- Conta afetada pela ação
+    usuario_id int null,
+    FOREIGN KEY (usuario_id)
+    REFERENCES usuarios(id)
+    ON DELETE SET NULL,
+
+    ip varchar(45) not null default '',
+    userAgent varchar(255) not null default '',
+    antes json null,
+    depois json null,
+    criadoEm timestamp default current_timestamp,
+    INDEX (usuario_id, criadoEm),
+    INDEX (ator_id, criadoEm),
+    INDEX (acao, criadoEm)
+) ENGINE=INNODB;
+
+CREATE TRIGGER auditoria_sem_update BEFORE UPDATE ON auditoria
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'a auditoria não pode ser alterada';
+
+CREATE TRIGGER auditoria_sem_delete BEFORE DELETE ON auditoria
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'a auditoria não pode ser apagada';
+
+-- Versão do esquema aplicada ao banco. Ela sobe a cada mudança neste arquivo, junto com a constante
+-- banco.VersaoEsquema, e o /readyz só responde que a API está pronta quando o banco já está nela
+CREATE TABLE versao_esquema(
+    versao int not null primary key,
+    aplicadaEm timestamp default current_timestamp
+) ENGINE=INNODB;
+
+INSERT INTO versao_esquema (versao) VALUES (1);
@@ -0,0 +1,151 @@
+// Package antispam pontua as ações de um usuário para identificar contas que se comportam como spam. A pontuação
+// combina a idade da conta, a velocidade com que ela publica e segue outros usuários, a repetição de conteúdo
+// e a quantidade de links. Acima de um limite a ação é recusada por um tempo e, acima de outro, a conta vai
+// para a quarentena até que um moderador a libere
+package antispam
+
+import (
+	"api/src/config"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// AcaoPermitir indica que a ação pode seguir normalmente
+	AcaoPermitir = ""
+
+	// AcaoLimitar indica que a ação deve ser recusada e o usuário precisa esperar para tentar de novo
+	AcaoLimitar = "limitar"
+
+	// AcaoQuarentena indica que a conta deve ser colocada em quarentena e enviada para a moderação
+	AcaoQuarentena = "quarentena"
+)
+
+const (
+	// JanelaVelocidade é o período em que as publicações e os usuários seguidos são contados
+	JanelaVelocidade = time.Hour
+
+	// JanelaDuplicadas é o período em que as publicações com o mesmo conteúdo são contadas
+	JanelaDuplicadas = 24 * time.Hour
+)
+
+// link encontra endereços no texto, com ou sem o protocolo
+var link = regexp.MustCompile(`(?i)\bhttps?://|\bwww\.`)
+
+// Limites define a partir de quando cada sinal pesa na pontuação e quanto ele pesa
+type Limites struct {
+	// ContaNova é a idade abaixo da qual a conta é considerada nova e tem a pontuação multiplicada
+	ContaNova           time.Duration
+	MultiplicadorNova   float64
+	PublicacoesPorHora  int
+	SeguidasPorHora     int
+	Duplicadas          int
+	MaximoLinks         int
+	DensidadeLinks      float64
+	PesoPublicacao      float64
+	PesoSeguida         float64
+	PesoDuplicada       float64
+	PesoLink            float64
+	PontuacaoLimitar    float64
+	PontuacaoQuarentena float64
+}
+
+// Sinais são os dados de uma ação usados no cálculo da pontuação. As contagens já incluem a ação avaliada
+type Sinais struct {
+	ContaCriadaEm time.Time
+	EmQuarentena  bool
+	// PublicacoesRecentes é quantas publicações a conta fez dentro da JanelaVelocidade
+	PublicacoesRecentes int
+	// SeguidasRecentes é quantos usuários a conta passou a seguir dentro da JanelaVelocidade
+	SeguidasRecentes int
+	// Duplicadas é quantas publicações com o mesmo conteúdo foram feitas na JanelaDuplicadas, por qualquer conta
+	Duplicadas int
+	Links      int
+	Palavras   int
+}
+
+// Avaliacao é o resultado da pontuação de uma ação
+type Avaliacao struct {
+	Pontuacao float64
+	Acao      string
+	Motivos   []string
+}
+
+// LimitesDaConfiguracao retorna os limites definidos nas variáveis de ambiente
+func LimitesDaConfiguracao() Limites {
+	return Limites{
+		ContaNova:           config.IdadeContaNovaAntispam,
+		MultiplicadorNova:   config.MultiplicadorContaNovaAntispam,
+		PublicacoesPorHora:  config.PublicacoesPorHoraAntispam,
+		SeguidasPorHora:     config.SeguidasPorHoraAntispam,
+		Duplicadas:          config.DuplicadasAntispam,
+		MaximoLinks:         config.MaximoLinksAntispam,
+		DensidadeLinks:      config.DensidadeLinksAntispam,
+		PesoPublicacao:      config.PesoPublicacaoAntispam,
+		PesoSeguida:         config.PesoSeguidaAntispam,
+		PesoDuplicada:       config.PesoDuplicadaAntispam,
+		PesoLink:            config.PesoLinkAntispam,
+		PontuacaoLimitar:    config.PontuacaoLimitarAntispam,
+		PontuacaoQuarentena: config.PontuacaoQuarentenaAntispam,
+	}
+}
+
+// Avaliar pontua uma ação e decide se ela pode seguir, se deve ser limitada ou se a conta vai para a quarentena.
+// Cada sinal só pontua o que passar do seu limite, então o uso normal fica sempre com pontuação zero
+func Avaliar(sinais Sinais, limites Limites, agora time.Time) Avaliacao {
+	var avaliacao Avaliacao
+
+	if excesso := sinais.PublicacoesRecentes - limites.PublicacoesPorHora; excesso > 0 {
+		avaliacao.Pontuacao += float64(excesso) * limites.PesoPublicacao
+		avaliacao.Motivos = append(avaliacao.Motivos, fmt.Sprintf("%d publicações na última hora", sinais.PublicacoesRecentes))
+	}
+
+	if excesso := sinais.SeguidasRecentes - limites.SeguidasPorHora; excesso > 0 {
+		avaliacao.Pontuacao += float64(excesso) * limites.PesoSeguida
+		avaliacao.Motivos = append(avaliacao.Motivos, fmt.Sprintf("%d usuários seguidos na última hora", sinais.SeguidasRecentes))
+	}
+
+	if excesso := sinais.Duplicadas - limites.Duplicadas; excesso >= 0 && sinais.Duplicadas > 1 {
+		avaliacao.Pontuacao += float64(excesso+1) * limites.PesoDuplicada
+		avaliacao.Motivos = append(avaliacao.Motivos, fmt.Sprintf("conteúdo repetido %d vezes no último dia", sinais.Duplicadas))
+	}
+
+	if excesso := sinais.Links - limites.MaximoLinks; excesso > 0 {
+		avaliacao.Pontuacao += float64(excesso) * limites.PesoLink
+		avaliacao.Motivos = append(avaliacao.Motivos, fmt.Sprintf("%d links em uma publicação", sinais.Links))
+	} else if sinais.Links > 1 && float64(sinais.Links)/float64(max(sinais.Palavras, 1)) > limites.DensidadeLinks {
+		avaliacao.Pontuacao += limites.PesoLink
+		avaliacao.Motivos = append(avaliacao.Motivos, "publicação feita quase só de links")
+	}
+
+	if avaliacao.Pontuacao > 0 && agora.Sub(sinais.ContaCriadaEm) < limites.ContaNova {
+		avaliacao.Pontuacao *= limites.MultiplicadorNova
+		avaliacao.Motivos = append(avaliacao.Motivos, "conta criada recentemente")
+	}
+
+	switch {
+	case avaliacao.Pontuacao >= limites.PontuacaoQuarentena:
+		avaliacao.Acao = AcaoQuarentena
+	case avaliacao.Pontuacao >= limites.PontuacaoLimitar:
+		avaliacao.Acao = AcaoLimitar
+	}
+
+	return avaliacao
+}
+
+// Hash retorna a impressão digital do conteúdo de uma publicação, usada para encontrar publicações repetidas.
+// O texto é normalizado antes, então diferenças só de maiúsculas ou espaços não geram hashes diferentes
+func Hash(titulo, conteudo string) string {
+	normalizado := strings.Join(strings.Fields(strings.ToLower(titulo+"\n"+conteudo)), " ")
+	soma := sha256.Sum256([]byte(normalizado))
+	return hex.EncodeToString(soma[:])
+}
+
+// ContarLinks retorna quantos links e quantas palavras o texto tem
+func ContarLinks(texto string) (links, palavras int) {
+	return len(link.FindAllStringIndex(texto, -1)), len(strings.Fields(texto))
+}
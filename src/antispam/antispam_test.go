@@ -0,0 +1,233 @@
+package antispam
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// agora é o instante fixo usado nos testes, para que a idade das contas não dependa do relógio
+var agora = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+// limitesDeTeste são os valores padrão da configuração, para que os testes mostrem onde cada sinal cruza
+// os limites com a API configurada de fábrica
+var limitesDeTeste = Limites{
+	ContaNova:           72 * time.Hour,
+	MultiplicadorNova:   2,
+	PublicacoesPorHora:  10,
+	SeguidasPorHora:     50,
+	Duplicadas:          3,
+	MaximoLinks:         3,
+	DensidadeLinks:      0.3,
+	PesoPublicacao:      20,
+	PesoSeguida:         5,
+	PesoDuplicada:       40,
+	PesoLink:            15,
+	PontuacaoLimitar:    40,
+	PontuacaoQuarentena: 100,
+}
+
+// usoNormal monta os sinais de uma conta antiga no limite do uso normal, com as alterações informadas
+func usoNormal(alterar func(sinais *Sinais)) Sinais {
+	sinais := Sinais{
+		ContaCriadaEm:       agora.Add(-30 * 24 * time.Hour),
+		PublicacoesRecentes: 10,
+		SeguidasRecentes:    50,
+		Duplicadas:          1,
+		Links:               3,
+		Palavras:            100,
+	}
+	if alterar != nil {
+		alterar(&sinais)
+	}
+
+	return sinais
+}
+
+func TestAvaliar(t *testing.T) {
+	testes := []struct {
+		nome      string
+		sinais    Sinais
+		limites   Limites
+		pontuacao float64
+		acao      string
+		motivos   int
+	}{
+		{
+			nome:   "uso normal não pontua",
+			sinais: usoNormal(nil),
+		},
+		{
+			nome:   "conta nova em uso normal não pontua",
+			sinais: usoNormal(func(s *Sinais) { s.ContaCriadaEm = agora.Add(-time.Hour) }),
+		},
+		{
+			nome:      "uma publicação acima do limite por hora só pontua",
+			sinais:    usoNormal(func(s *Sinais) { s.PublicacoesRecentes = 11 }),
+			pontuacao: 20,
+			motivos:   1,
+		},
+		{
+			nome:      "publicações por hora chegam a limitar",
+			sinais:    usoNormal(func(s *Sinais) { s.PublicacoesRecentes = 12 }),
+			pontuacao: 40,
+			acao:      AcaoLimitar,
+			motivos:   1,
+		},
+		{
+			nome:      "publicações por hora chegam à quarentena",
+			sinais:    usoNormal(func(s *Sinais) { s.PublicacoesRecentes = 15 }),
+			pontuacao: 100,
+			acao:      AcaoQuarentena,
+			motivos:   1,
+		},
+		{
+			nome:      "usuários seguidos por hora abaixo de limitar",
+			sinais:    usoNormal(func(s *Sinais) { s.SeguidasRecentes = 57 }),
+			pontuacao: 35,
+			motivos:   1,
+		},
+		{
+			nome:      "usuários seguidos por hora chegam a limitar",
+			sinais:    usoNormal(func(s *Sinais) { s.SeguidasRecentes = 58 }),
+			pontuacao: 40,
+			acao:      AcaoLimitar,
+			motivos:   1,
+		},
+		{
+			nome:      "usuários seguidos por hora chegam à quarentena",
+			sinais:    usoNormal(func(s *Sinais) { s.SeguidasRecentes = 70 }),
+			pontuacao: 100,
+			acao:      AcaoQuarentena,
+			motivos:   1,
+		},
+		{
+			nome:   "cópias abaixo do limite não pontuam",
+			sinais: usoNormal(func(s *Sinais) { s.Duplicadas = 2 }),
+		},
+		{
+			nome:      "cópias no limite já limitam",
+			sinais:    usoNormal(func(s *Sinais) { s.Duplicadas = 3 }),
+			pontuacao: 40,
+			acao:      AcaoLimitar,
+			motivos:   1,
+		},
+		{
+			nome:      "cópias acima do limite chegam à quarentena",
+			sinais:    usoNormal(func(s *Sinais) { s.Duplicadas = 5 }),
+			pontuacao: 120,
+			acao:      AcaoQuarentena,
+			motivos:   1,
+		},
+		{
+			nome:    "a própria publicação não conta como cópia, mesmo com limite um",
+			sinais:  usoNormal(nil),
+			limites: func() Limites { limites := limitesDeTeste; limites.Duplicadas = 1; return limites }(),
+		},
+		{
+			nome:      "a segunda cópia pontua com limite um",
+			sinais:    usoNormal(func(s *Sinais) { s.Duplicadas = 2 }),
+			limites:   func() Limites { limites := limitesDeTeste; limites.Duplicadas = 1; return limites }(),
+			pontuacao: 80,
+			acao:      AcaoLimitar,
+			motivos:   1,
+		},
+		{
+			nome:      "um link acima do máximo só pontua",
+			sinais:    usoNormal(func(s *Sinais) { s.Links = 4 }),
+			pontuacao: 15,
+			motivos:   1,
+		},
+		{
+			nome:      "links acima do máximo chegam a limitar",
+			sinais:    usoNormal(func(s *Sinais) { s.Links = 6 }),
+			pontuacao: 45,
+			acao:      AcaoLimitar,
+			motivos:   1,
+		},
+		{
+			nome:      "links acima do máximo chegam à quarentena",
+			sinais:    usoNormal(func(s *Sinais) { s.Links = 10 }),
+			pontuacao: 105,
+			acao:      AcaoQuarentena,
+			motivos:   1,
+		},
+		{
+			nome:      "publicação feita quase só de links pontua uma vez",
+			sinais:    usoNormal(func(s *Sinais) { s.Links, s.Palavras = 2, 4 }),
+			pontuacao: 15,
+			motivos:   1,
+		},
+		{
+			nome:      "densidade de links no máximo de links ainda pontua",
+			sinais:    usoNormal(func(s *Sinais) { s.Links, s.Palavras = 3, 3 }),
+			pontuacao: 15,
+			motivos:   1,
+		},
+		{
+			nome:   "um único link em texto curto não pontua",
+			sinais: usoNormal(func(s *Sinais) { s.Links, s.Palavras = 1, 1 }),
+		},
+		{
+			nome:   "links espalhados em texto longo não pontuam",
+			sinais: usoNormal(func(s *Sinais) { s.Links, s.Palavras = 2, 10 }),
+		},
+		{
+			nome:      "densidade de links somada a outro sinal chega a limitar",
+			sinais:    usoNormal(func(s *Sinais) { s.Links, s.Palavras, s.SeguidasRecentes = 2, 4, 55 }),
+			pontuacao: 40,
+			acao:      AcaoLimitar,
+			motivos:   2,
+		},
+		{
+			nome: "conta nova dobra a pontuação e chega a limitar",
+			sinais: usoNormal(func(s *Sinais) {
+				s.PublicacoesRecentes = 11
+				s.ContaCriadaEm = agora.Add(-time.Hour)
+			}),
+			pontuacao: 40,
+			acao:      AcaoLimitar,
+			motivos:   2,
+		},
+		{
+			nome: "conta nova dobra a pontuação e chega à quarentena",
+			sinais: usoNormal(func(s *Sinais) {
+				s.PublicacoesRecentes = 13
+				s.ContaCriadaEm = agora.Add(-time.Hour)
+			}),
+			pontuacao: 120,
+			acao:      AcaoQuarentena,
+			motivos:   2,
+		},
+		{
+			nome: "conta com a idade exata do limite já não é nova",
+			sinais: usoNormal(func(s *Sinais) {
+				s.PublicacoesRecentes = 11
+				s.ContaCriadaEm = agora.Add(-72 * time.Hour)
+			}),
+			pontuacao: 20,
+			motivos:   1,
+		},
+	}
+
+	for _, teste := range testes {
+		t.Run(teste.nome, func(t *testing.T) {
+			limites := teste.limites
+			if limites == (Limites{}) {
+				limites = limitesDeTeste
+			}
+
+			avaliacao := Avaliar(teste.sinais, limites, agora)
+
+			if math.Abs(avaliacao.Pontuacao-teste.pontuacao) > 1e-9 {
+				t.Errorf("esperava a pontuação %v, recebeu %v", teste.pontuacao, avaliacao.Pontuacao)
+			}
+			if avaliacao.Acao != teste.acao {
+				t.Errorf("esperava a ação %q, recebeu %q", teste.acao, avaliacao.Acao)
+			}
+			if len(avaliacao.Motivos) != teste.motivos {
+				t.Errorf("esperava %d motivos, recebeu %v", teste.motivos, avaliacao.Motivos)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package armazenamento
+
+import (
+	"api/src/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrArquivoNaoEncontrado é retornado quando a chave pedida não existe no armazenamento
+	ErrArquivoNaoEncontrado = errors.New("arquivo não encontrado")
+
+	// ErrChaveInvalida é retornado quando a chave tenta sair do espaço reservado para os arquivos
+	ErrChaveInvalida = errors.New("chave de arquivo inválida")
+)
+
+// Armazenamento define as operações de um local onde os arquivos enviados pelos usuários são guardados
+type Armazenamento interface {
+	Salvar(ctx context.Context, chave, tipo string, conteudo []byte) error
+	Copiar(ctx context.Context, chave, tipo string, origem io.Reader, tamanho int64) error
+	Abrir(ctx context.Context, chave string) (io.ReadCloser, error)
+	Remover(ctx context.Context, chave string) error
+	URL(chave string) string
+}
+
+// Padrao é o armazenamento usado pela API, definido em Carregar
+var Padrao Armazenamento
+
+// Carregar cria o armazenamento configurado nas variáveis de ambiente
+func Carregar() error {
+	switch config.ArmazenamentoTipo {
+	case "", "local":
+		local, erro := NovoLocal(config.ArmazenamentoDiretorio, config.ArmazenamentoURLPublica)
+		if erro != nil {
+			return erro
+		}
+		Padrao = local
+	case "s3":
+		s3, erro := NovoS3(
+			config.S3Endpoint,
+			config.S3ChaveDeAcesso,
+			config.S3ChaveSecreta,
+			config.S3Bucket,
+			config.S3Regiao,
+			config.S3UsarSSL,
+			config.ArmazenamentoURLPublica,
+		)
+		if erro != nil {
+			return erro
+		}
+		Padrao = s3
+	default:
+		return fmt.Errorf("tipo de armazenamento desconhecido: %s", config.ArmazenamentoTipo)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package armazenamento_test
+
+import (
+	"api/src/armazenamento"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verificarIdaEVolta grava um arquivo, lê de volta o mesmo conteúdo, remove e confere que ele sumiu
+func verificarIdaEVolta(t *testing.T, destino armazenamento.Armazenamento, chave string) {
+	t.Helper()
+	ctx := context.Background()
+	conteudo := "conteúdo de teste"
+
+	if erro := destino.Copiar(ctx, chave, "text/plain", strings.NewReader(conteudo), int64(len(conteudo))); erro != nil {
+		t.Fatalf("não foi possível copiar o arquivo: %v", erro)
+	}
+	t.Cleanup(func() { destino.Remover(context.Background(), chave) })
+
+	arquivo, erro := destino.Abrir(ctx, chave)
+	if erro != nil {
+		t.Fatalf("não foi possível abrir o arquivo: %v", erro)
+	}
+	lido, erro := io.ReadAll(arquivo)
+	arquivo.Close()
+	if erro != nil {
+		t.Fatalf("não foi possível ler o arquivo: %v", erro)
+	}
+	if string(lido) != conteudo {
+		t.Errorf("esperava %q, recebeu %q", conteudo, lido)
+	}
+
+	if erro = destino.Remover(ctx, chave); erro != nil {
+		t.Fatalf("não foi possível remover o arquivo: %v", erro)
+	}
+
+	if _, erro = destino.Abrir(ctx, chave); !errors.Is(erro, armazenamento.ErrArquivoNaoEncontrado) {
+		t.Errorf("esperava %v depois de remover, recebeu %v", armazenamento.ErrArquivoNaoEncontrado, erro)
+	}
+}
+
+func TestLocalIdaEVolta(t *testing.T) {
+	local, erro := armazenamento.NovoLocal(t.TempDir(), "http://localhost/midias")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	verificarIdaEVolta(t, local, "publicacoes/1/imagem.txt")
+}
+
+func TestLocalArquivoNaoEncontrado(t *testing.T) {
+	local, erro := armazenamento.NovoLocal(t.TempDir(), "http://localhost/midias")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	if _, erro = local.Abrir(context.Background(), "publicacoes/1/inexistente.txt"); !errors.Is(erro, armazenamento.ErrArquivoNaoEncontrado) {
+		t.Errorf("esperava %v, recebeu %v", armazenamento.ErrArquivoNaoEncontrado, erro)
+	}
+
+	if erro = local.Remover(context.Background(), "publicacoes/1/inexistente.txt"); erro != nil {
+		t.Errorf("remover um arquivo que não existe não deveria falhar, recebeu %v", erro)
+	}
+}
+
+func TestLocalChaveInvalida(t *testing.T) {
+	diretorio := t.TempDir()
+	local, erro := armazenamento.NovoLocal(diretorio+"/arquivos", "http://localhost/midias")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	// Um arquivo fora do diretório do armazenamento, que nenhuma chave pode alcançar
+	if erro = os.WriteFile(diretorio+"/segredo.txt", []byte("segredo"), 0o644); erro != nil {
+		t.Fatal(erro)
+	}
+
+	testes := []struct {
+		nome  string
+		chave string
+	}{
+		{"vazia", ""},
+		{"raiz", "/"},
+		{"pai", "../segredo.txt"},
+		{"pai no meio", "publicacoes/../../segredo.txt"},
+		{"pai com barra inicial", "/../segredo.txt"},
+		{"só o pai", ".."},
+	}
+
+	for _, teste := range testes {
+		t.Run(teste.nome, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, erro := local.Abrir(ctx, teste.chave); !errors.Is(erro, armazenamento.ErrChaveInvalida) {
+				t.Errorf("Abrir: esperava %v, recebeu %v", armazenamento.ErrChaveInvalida, erro)
+			}
+			if erro := local.Copiar(ctx, teste.chave, "text/plain", strings.NewReader("x"), 1); !errors.Is(erro, armazenamento.ErrChaveInvalida) {
+				t.Errorf("Copiar: esperava %v, recebeu %v", armazenamento.ErrChaveInvalida, erro)
+			}
+			if erro := local.Remover(ctx, teste.chave); !errors.Is(erro, armazenamento.ErrChaveInvalida) {
+				t.Errorf("Remover: esperava %v, recebeu %v", armazenamento.ErrChaveInvalida, erro)
+			}
+		})
+	}
+
+	if _, erro = os.Stat(diretorio + "/segredo.txt"); erro != nil {
+		t.Errorf("o arquivo fora do armazenamento não deveria ter sido alterado: %v", erro)
+	}
+}
+
+// TestS3IdaEVolta roda contra um serviço compatível com S3, como um MinIO local, configurado pelas mesmas
+// variáveis de ambiente da API. Sem S3_ENDPOINT e S3_BUCKET, o teste é pulado
+func TestS3IdaEVolta(t *testing.T) {
+	endpoint, bucket := os.Getenv("S3_ENDPOINT"), os.Getenv("S3_BUCKET")
+	if endpoint == "" || bucket == "" {
+		t.Skip("S3_ENDPOINT e S3_BUCKET não configurados")
+	}
+
+	s3, erro := armazenamento.NovoS3(
+		endpoint,
+		os.Getenv("S3_CHAVE_DE_ACESSO"),
+		os.Getenv("S3_CHAVE_SECRETA"),
+		bucket,
+		os.Getenv("S3_REGIAO"),
+		os.Getenv("S3_USAR_SSL") != "false",
+		"http://localhost/midias",
+	)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	verificarIdaEVolta(t, s3, fmt.Sprintf("testes/%d.txt", time.Now().UnixNano()))
+}
@@ -0,0 +1,116 @@
+package armazenamento
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local guarda os arquivos em um diretório do próprio servidor
+type Local struct {
+	diretorio  string
+	urlPublica string
+}
+
+// NovoLocal cria um armazenamento no diretório informado, criando-o se necessário
+func NovoLocal(diretorio, urlPublica string) (*Local, error) {
+	if erro := os.MkdirAll(diretorio, 0o755); erro != nil {
+		return nil, erro
+	}
+
+	return &Local{diretorio, strings.TrimSuffix(urlPublica, "/")}, nil
+}
+
+// Salvar escreve o conteúdo no arquivo correspondente à chave
+func (local Local) Salvar(ctx context.Context, chave, tipo string, conteudo []byte) error {
+	caminho, erro := local.caminho(chave)
+	if erro != nil {
+		return erro
+	}
+
+	if erro = os.MkdirAll(filepath.Dir(caminho), 0o755); erro != nil {
+		return erro
+	}
+
+	return os.WriteFile(caminho, conteudo, 0o644)
+}
+
+// Copiar escreve no arquivo correspondente à chave o conteúdo lido da origem, sem carregá-lo inteiro na
+// memória. Se a cópia falhar no meio, o arquivo incompleto é apagado
+func (local Local) Copiar(ctx context.Context, chave, tipo string, origem io.Reader, tamanho int64) error {
+	caminho, erro := local.caminho(chave)
+	if erro != nil {
+		return erro
+	}
+
+	if erro = os.MkdirAll(filepath.Dir(caminho), 0o755); erro != nil {
+		return erro
+	}
+
+	arquivo, erro := os.OpenFile(caminho, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if erro != nil {
+		return erro
+	}
+
+	if _, erro = io.Copy(arquivo, origem); erro != nil {
+		arquivo.Close()
+		os.Remove(caminho)
+		return erro
+	}
+
+	return arquivo.Close()
+}
+
+// Abrir abre o arquivo correspondente à chave para leitura
+func (local Local) Abrir(ctx context.Context, chave string) (io.ReadCloser, error) {
+	caminho, erro := local.caminho(chave)
+	if erro != nil {
+		return nil, erro
+	}
+
+	arquivo, erro := os.Open(caminho)
+	if errors.Is(erro, fs.ErrNotExist) {
+		return nil, ErrArquivoNaoEncontrado
+	}
+
+	return arquivo, erro
+}
+
+// Remover apaga o arquivo correspondente à chave, se ele existir
+func (local Local) Remover(ctx context.Context, chave string) error {
+	caminho, erro := local.caminho(chave)
+	if erro != nil {
+		return erro
+	}
+
+	if erro = os.Remove(caminho); erro != nil && !errors.Is(erro, fs.ErrNotExist) {
+		return erro
+	}
+
+	return nil
+}
+
+// URL retorna o endereço público de um arquivo
+func (local Local) URL(chave string) string {
+	return local.urlPublica + "/" + chave
+}
+
+// caminho converte uma chave em um caminho dentro do diretório, recusando chaves que tentem sair dele
+func (local Local) caminho(chave string) (string, error) {
+	for _, parte := range strings.Split(filepath.ToSlash(chave), "/") {
+		if parte == ".." {
+			return "", ErrChaveInvalida
+		}
+	}
+
+	limpa := filepath.Clean("/" + chave)
+	if limpa == "/" {
+		return "", ErrChaveInvalida
+	}
+
+	return filepath.Join(local.diretorio, filepath.FromSlash(limpa)), nil
+}
@@ -0,0 +1,75 @@
+package armazenamento
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3 guarda os arquivos em um bucket de qualquer serviço compatível com a API do S3 (AWS, MinIO, etc.)
+type S3 struct {
+	cliente    *minio.Client
+	bucket     string
+	urlPublica string
+}
+
+// NovoS3 cria um armazenamento apontando para o bucket informado
+func NovoS3(endpoint, chaveDeAcesso, chaveSecreta, bucket, regiao string, usarSSL bool, urlPublica string) (*S3, error) {
+	cliente, erro := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(chaveDeAcesso, chaveSecreta, ""),
+		Secure: usarSSL,
+		Region: regiao,
+	})
+	if erro != nil {
+		return nil, erro
+	}
+
+	return &S3{cliente, bucket, strings.TrimSuffix(urlPublica, "/")}, nil
+}
+
+// Salvar envia o conteúdo para o bucket
+func (s3 S3) Salvar(ctx context.Context, chave, tipo string, conteudo []byte) error {
+	_, erro := s3.cliente.PutObject(ctx, s3.bucket, chave,
+		bytes.NewReader(conteudo), int64(len(conteudo)),
+		minio.PutObjectOptions{ContentType: tipo},
+	)
+	return erro
+}
+
+// Copiar envia para o bucket o conteúdo lido da origem, em partes, sem carregá-lo inteiro na memória
+func (s3 S3) Copiar(ctx context.Context, chave, tipo string, origem io.Reader, tamanho int64) error {
+	_, erro := s3.cliente.PutObject(ctx, s3.bucket, chave, origem, tamanho, minio.PutObjectOptions{ContentType: tipo})
+	return erro
+}
+
+// Abrir baixa o objeto correspondente à chave
+func (s3 S3) Abrir(ctx context.Context, chave string) (io.ReadCloser, error) {
+	objeto, erro := s3.cliente.GetObject(ctx, s3.bucket, chave, minio.GetObjectOptions{})
+	if erro != nil {
+		return nil, erro
+	}
+
+	if _, erro = objeto.Stat(); erro != nil {
+		objeto.Close()
+		if minio.ToErrorResponse(erro).Code == "NoSuchKey" {
+			return nil, ErrArquivoNaoEncontrado
+		}
+		return nil, erro
+	}
+
+	return objeto, nil
+}
+
+// Remover apaga o objeto correspondente à chave
+func (s3 S3) Remover(ctx context.Context, chave string) error {
+	return s3.cliente.RemoveObject(ctx, s3.bucket, chave, minio.RemoveObjectOptions{})
+}
+
+// URL retorna o endereço público de um objeto
+func (s3 S3) URL(chave string) string {
+	return s3.urlPublica + "/" + chave
+}
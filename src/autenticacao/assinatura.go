@@ -0,0 +1,57 @@
+package autenticacao
+
+import (
+	"api/src/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ErrAssinaturaInvalida é retornado quando um endereço assinado foi alterado ou já expirou
+var ErrAssinaturaInvalida = errors.New("link inválido ou expirado")
+
+// AssinarURL acrescenta ao caminho a data de expiração e uma assinatura, para que ele possa ser acessado
+// sem token até essa data
+func AssinarURL(caminho string, expiraEm time.Time) string {
+	expira := strconv.FormatInt(expiraEm.Unix(), 10)
+
+	parametros := url.Values{}
+	parametros.Set("expira", expira)
+	parametros.Set("assinatura", assinar(caminho, expira))
+
+	return caminho + "?" + parametros.Encode()
+}
+
+// VerificarURL confere se a assinatura recebida corresponde ao caminho e se a data de expiração não passou
+func VerificarURL(caminho string, parametros url.Values) error {
+	expira := parametros.Get("expira")
+
+	segundos, erro := strconv.ParseInt(expira, 10, 64)
+	if erro != nil || time.Now().Unix() > segundos {
+		return ErrAssinaturaInvalida
+	}
+
+	assinatura, erro := hex.DecodeString(parametros.Get("assinatura"))
+	if erro != nil {
+		return ErrAssinaturaInvalida
+	}
+
+	esperada, _ := hex.DecodeString(assinar(caminho, expira))
+	if !hmac.Equal(assinatura, esperada) {
+		return ErrAssinaturaInvalida
+	}
+
+	return nil
+}
+
+// assinar calcula o HMAC do caminho e da expiração com a chave secreta da API
+func assinar(caminho, expira string) string {
+	mac := hmac.New(sha256.New, config.SecretKey)
+	fmt.Fprintf(mac, "%s\n%s", caminho, expira)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -2,14 +2,32 @@ package banco
 
 import (
 	"api/src/config"
+	"api/src/rastreamento"
+	"context"
 	"database/sql"
+	"database/sql/driver"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/go-sql-driver/mysql" // Driver
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
-// Conectar abre a conexão com o banco de dados e a retorna
+// VersaoEsquema é a versão do esquema do banco que esta versão da API espera. Ela deve subir junto com o
+// insert em versao_esquema no sql/sql.sql sempre que o esquema mudar
+const VersaoEsquema = 1
+
+// Conectar abre a conexão com o banco de dados e a retorna. Os comandos executados com o contexto de uma
+// requisição rastreada viram spans no trace dela
 func Conectar() (*sql.DB, error) {
-	db, erro := sql.Open("mysql", config.StringConexaoBanco)
+	db, erro := otelsql.Open("mysql", config.StringConexaoBanco,
+		otelsql.WithAttributes(semconv.DBSystemMySQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return rastreamento.EmTrace(ctx)
+			},
+		}),
+	)
 	if erro != nil {
 		return nil, erro
 	}
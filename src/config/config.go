@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,8 +18,228 @@ var (
 	// Porta onde a API vai estar rodando
 	Porta = 0
 
+	// TempoLimiteLeitura é quanto tempo o servidor espera para receber uma requisição inteira, com o corpo
+	TempoLimiteLeitura time.Duration
+
+	// TempoLimiteEscrita é quanto tempo o servidor tem para escrever a resposta, contado do fim da leitura
+	TempoLimiteEscrita time.Duration
+
+	// TempoLimiteOcioso é por quanto tempo uma conexão keep-alive fica aberta esperando a próxima requisição
+	TempoLimiteOcioso time.Duration
+
+	// AtrasoEncerramento é quanto tempo a API continua atendendo depois de passar a responder 503 no /readyz,
+	// para que o balanceador perceba e pare de mandar requisições antes de o servidor deixar de aceitá-las
+	AtrasoEncerramento time.Duration
+
+	// EsperaEncerramento é quanto tempo a API espera as requisições e as tarefas em andamento terminarem
+	// depois de receber o sinal para parar
+	EsperaEncerramento time.Duration
+
+	// NivelLog é o nível mínimo das mensagens de log: debug, info, warn ou error
+	NivelLog = ""
+
+	// FormatoLog é o formato das linhas de log: json ou texto
+	FormatoLog = ""
+
+	// TokenMetricas, quando definido, é exigido como Bearer para acessar o /metrics
+	TokenMetricas = ""
+
+	// RastreamentoEndpoint é o host:porta do coletor OTLP/HTTP que recebe os traces. Vazio desliga a exportação
+	RastreamentoEndpoint = ""
+
+	// RastreamentoInseguro faz os traces serem enviados ao coletor por HTTP, sem TLS
+	RastreamentoInseguro = false
+
+	// RastreamentoAmostragem é a fração das requisições sem trace de origem que são rastreadas, de 0 a 1
+	RastreamentoAmostragem = 0.0
+
+	// ProxyConfiavel indica se a API roda atrás de um proxy que preenche o X-Forwarded-For com o IP do cliente
+	ProxyConfiavel = false
+
 	// SecretKey é a chave que vai ser usada para assinar o token
 	SecretKey []byte
+
+	// RetencaoLixeira é por quanto tempo publicações deletadas ficam na lixeira antes de serem removidas definitivamente
+	RetencaoLixeira time.Duration
+
+	// IntervaloLimpezaLixeira é o intervalo entre as execuções da tarefa que esvazia a lixeira
+	IntervaloLimpezaLixeira time.Duration
+
+	// IntervaloAgendador é de quanto em quanto tempo as publicações agendadas são verificadas
+	IntervaloAgendador time.Duration
+
+	// ArmazenamentoTipo define onde os arquivos enviados são guardados: "local" ou "s3"
+	ArmazenamentoTipo = ""
+
+	// ArmazenamentoDiretorio é o diretório usado pelo armazenamento local
+	ArmazenamentoDiretorio = ""
+
+	// ArmazenamentoURLPublica é o prefixo dos endereços pelos quais os arquivos são servidos
+	ArmazenamentoURLPublica = ""
+
+	// S3Endpoint é o endereço do serviço compatível com S3
+	S3Endpoint = ""
+
+	// S3Bucket é o bucket onde os arquivos são guardados
+	S3Bucket = ""
+
+	// S3Regiao é a região do bucket
+	S3Regiao = ""
+
+	// S3ChaveDeAcesso é a chave de acesso ao serviço compatível com S3
+	S3ChaveDeAcesso = ""
+
+	// S3ChaveSecreta é a chave secreta do serviço compatível com S3
+	S3ChaveSecreta = ""
+
+	// S3UsarSSL indica se a conexão com o serviço compatível com S3 usa HTTPS
+	S3UsarSSL = false
+
+	// TamanhoMaximoImagem é o tamanho máximo, em bytes, de cada imagem enviada
+	TamanhoMaximoImagem int64
+
+	// MaximoAnexosPorPublicacao é a quantidade máxima de imagens em uma publicação
+	MaximoAnexosPorPublicacao = 0
+
+	// LarguraMiniatura é a largura máxima, em pixels, das miniaturas geradas para as imagens
+	LarguraMiniatura = 0
+
+	// TamanhoMaximoTrecho é a quantidade máxima de caracteres de cada trecho de código de uma publicação
+	TamanhoMaximoTrecho = 0
+
+	// MaximoTrechosPorPublicacao é a quantidade máxima de trechos de código em uma publicação
+	MaximoTrechosPorPublicacao = 0
+
+	// EstiloDestaque é o tema usado no destaque de sintaxe dos trechos de código
+	EstiloDestaque = ""
+
+	// RecursosMarkdown são os recursos de Markdown aceitos nas publicações (links, listas, codigo, enfase)
+	RecursosMarkdown []string
+
+	// TiposDeReacao são os emojis que os usuários podem usar para reagir às publicações
+	TiposDeReacao []string
+
+	// JanelaFeedRelevante é até quanto tempo atrás as publicações são consideradas no feed relevante
+	JanelaFeedRelevante time.Duration
+
+	// MaximoCandidatosFeedRelevante é quantas publicações, no máximo, são pontuadas para montar o feed relevante
+	MaximoCandidatosFeedRelevante = 0
+
+	// MeiaVidaRelevancia é o tempo depois do qual a pontuação de uma publicação cai pela metade
+	MeiaVidaRelevancia time.Duration
+
+	// PesoCurtidaRelevancia é quanto cada curtida soma ao engajamento de uma publicação
+	PesoCurtidaRelevancia = 0.0
+
+	// PesoReacaoRelevancia é quanto cada reação soma ao engajamento de uma publicação
+	PesoReacaoRelevancia = 0.0
+
+	// PesoSalvoRelevancia é quanto cada vez que a publicação foi salva soma ao seu engajamento
+	PesoSalvoRelevancia = 0.0
+
+	// PesoVotoRelevancia é quanto cada voto na enquete soma ao engajamento de uma publicação
+	PesoVotoRelevancia = 0.0
+
+	// PesoAfinidadeRelevancia é quanto as interações anteriores com o autor aumentam a pontuação
+	PesoAfinidadeRelevancia = 0.0
+
+	// LimiteSeguidoresLinhaDoTempo é a quantidade de seguidores a partir da qual as publicações de um autor
+	// deixam de ser distribuídas nas linhas do tempo e passam a ser buscadas na leitura do feed
+	LimiteSeguidoresLinhaDoTempo = 0
+
+	// PreenchimentoLinhaDoTempo é quantas publicações recentes de um autor entram na linha do tempo de quem passa a segui-lo
+	PreenchimentoLinhaDoTempo = 0
+
+	// TamanhoLinhaDoTempo é quantas publicações cada linha do tempo guarda quando é reconstruída
+	TamanhoLinhaDoTempo = 0
+
+	// QuantidadeSugestoes é quantos usuários são sugeridos para cada pessoa seguir
+	QuantidadeSugestoes = 0
+
+	// ValidadeSugestoes é por quanto tempo as sugestões calculadas para um usuário continuam sendo usadas
+	ValidadeSugestoes time.Duration
+
+	// IntervaloSugestoes é o intervalo entre as execuções da tarefa que recalcula as sugestões vencidas
+	IntervaloSugestoes time.Duration
+
+	// LoteSugestoes é quantos usuários têm as sugestões recalculadas em cada execução da tarefa
+	LoteSugestoes = 0
+
+	// IntervaloTendencias é o intervalo entre as execuções da tarefa que calcula as tendências
+	IntervaloTendencias time.Duration
+
+	// QuantidadeTendencias é quantas publicações e quantas hashtags aparecem em cada janela das tendências
+	QuantidadeTendencias = 0
+
+	// MaximoCandidatosTendencias é quantas publicações, no máximo, são avaliadas em cada janela das tendências
+	MaximoCandidatosTendencias = 0
+
+	// IntervaloTrocaNick é o tempo mínimo entre duas trocas de nick do mesmo usuário
+	IntervaloTrocaNick time.Duration
+
+	// ReservaNick é por quanto tempo um nick abandonado fica reservado antes de poder ser usado por outro usuário
+	ReservaNick time.Duration
+
+	// ValidadeExportacao é por quanto tempo o arquivo de uma exportação de dados fica disponível para download
+	ValidadeExportacao time.Duration
+
+	// IntervaloExportacoes é de quanto em quanto tempo as exportações de dados pendentes são verificadas
+	IntervaloExportacoes time.Duration
+
+	// PrazoExclusaoConta é quanto tempo depois do pedido de exclusão a conta é removida definitivamente,
+	// período em que o usuário ainda pode desistir
+	PrazoExclusaoConta time.Duration
+
+	// IntervaloExclusaoContas é o intervalo entre as execuções da tarefa que exclui as contas com prazo vencido
+	IntervaloExclusaoContas time.Duration
+
+	// IntervaloFiltros é de quanto em quanto tempo as regras de filtro de conteúdo são relidas do banco
+	IntervaloFiltros time.Duration
+
+	// MaximoPalavrasSilenciadas é quantas palavras cada usuário pode silenciar
+	MaximoPalavrasSilenciadas = 0
+
+	// IdadeContaNovaAntispam é a idade abaixo da qual uma conta é tratada com mais rigor pelo antispam
+	IdadeContaNovaAntispam time.Duration
+
+	// MultiplicadorContaNovaAntispam é por quanto a pontuação de spam de uma conta nova é multiplicada
+	MultiplicadorContaNovaAntispam = 0.0
+
+	// PublicacoesPorHoraAntispam é quantas publicações por hora uma conta pode fazer sem pontuar como spam
+	PublicacoesPorHoraAntispam = 0
+
+	// SeguidasPorHoraAntispam é quantos usuários por hora uma conta pode seguir sem pontuar como spam
+	SeguidasPorHoraAntispam = 0
+
+	// DuplicadasAntispam é a partir de quantas cópias do mesmo conteúdo no último dia a publicação pontua como spam
+	DuplicadasAntispam = 0
+
+	// MaximoLinksAntispam é quantos links uma publicação pode ter sem pontuar como spam
+	MaximoLinksAntispam = 0
+
+	// DensidadeLinksAntispam é a proporção máxima de links por palavra antes de a publicação pontuar como spam
+	DensidadeLinksAntispam = 0.0
+
+	// PesoPublicacaoAntispam é quanto cada publicação acima do limite por hora soma à pontuação de spam
+	PesoPublicacaoAntispam = 0.0
+
+	// PesoSeguidaAntispam é quanto cada usuário seguido acima do limite por hora soma à pontuação de spam
+	PesoSeguidaAntispam = 0.0
+
+	// PesoDuplicadaAntispam é quanto cada cópia do mesmo conteúdo a partir do limite soma à pontuação de spam
+	PesoDuplicadaAntispam = 0.0
+
+	// PesoLinkAntispam é quanto cada link acima do máximo, ou o excesso de densidade, soma à pontuação de spam
+	PesoLinkAntispam = 0.0
+
+	// PontuacaoLimitarAntispam é a pontuação de spam a partir da qual a ação é recusada temporariamente
+	PontuacaoLimitarAntispam = 0.0
+
+	// PontuacaoQuarentenaAntispam é a pontuação de spam a partir da qual a conta vai para a quarentena
+	PontuacaoQuarentenaAntispam = 0.0
+
+	// EsperaAntispam é quanto tempo o usuário é orientado a esperar depois de ter uma ação limitada
+	EsperaAntispam time.Duration
 )
 
 // Carregar vai inicializar as variáveis de ambiente
@@ -33,11 +255,148 @@ func Carregar() {
 		Porta = 9000
 	}
 
-	StringConexaoBanco = fmt.Sprintf("%s:%s@/%s?charset=utf8&parseTime=True&loc=Local",
+	TempoLimiteLeitura = time.Duration(lerInteiro("API_TEMPO_LIMITE_LEITURA_SEGUNDOS", 30)) * time.Second
+	TempoLimiteEscrita = time.Duration(lerInteiro("API_TEMPO_LIMITE_ESCRITA_SEGUNDOS", 60)) * time.Second
+	TempoLimiteOcioso = time.Duration(lerInteiro("API_TEMPO_LIMITE_OCIOSO_SEGUNDOS", 120)) * time.Second
+	AtrasoEncerramento = time.Duration(lerInteiro("API_ATRASO_ENCERRAMENTO_SEGUNDOS", 5)) * time.Second
+	EsperaEncerramento = time.Duration(lerInteiro("API_ESPERA_ENCERRAMENTO_SEGUNDOS", 30)) * time.Second
+
+	NivelLog = lerTexto("LOG_NIVEL", "info")
+	FormatoLog = lerTexto("LOG_FORMATO", "json")
+	ProxyConfiavel = lerBooleano("PROXY_CONFIAVEL", false)
+	TokenMetricas = os.Getenv("METRICAS_TOKEN")
+	RastreamentoEndpoint = os.Getenv("RASTREAMENTO_ENDPOINT")
+	RastreamentoInseguro = lerBooleano("RASTREAMENTO_INSEGURO", false)
+	RastreamentoAmostragem = lerDecimal("RASTREAMENTO_AMOSTRAGEM", 1)
+
+	StringConexaoBanco = fmt.Sprintf("%s:%s@/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		os.Getenv("DB_USUARIO"),
 		os.Getenv("DB_SENHA"),
 		os.Getenv("DB_NOME"),
 	)
 
 	SecretKey = []byte(os.Getenv("SECRET_KEY"))
+
+	RetencaoLixeira = time.Duration(lerInteiro("LIXEIRA_RETENCAO_DIAS", 30)) * 24 * time.Hour
+	IntervaloLimpezaLixeira = time.Duration(lerPositivo("LIXEIRA_INTERVALO_MINUTOS", 60)) * time.Minute
+	IntervaloAgendador = time.Duration(lerPositivo("AGENDADOR_INTERVALO_SEGUNDOS", 30)) * time.Second
+
+	ArmazenamentoTipo = os.Getenv("ARMAZENAMENTO_TIPO")
+	ArmazenamentoDiretorio = lerTexto("ARMAZENAMENTO_DIRETORIO", "midias")
+	ArmazenamentoURLPublica = lerTexto("ARMAZENAMENTO_URL_PUBLICA", "/midias")
+	S3Endpoint = os.Getenv("S3_ENDPOINT")
+	S3Bucket = os.Getenv("S3_BUCKET")
+	S3Regiao = os.Getenv("S3_REGIAO")
+	S3ChaveDeAcesso = os.Getenv("S3_CHAVE_DE_ACESSO")
+	S3ChaveSecreta = os.Getenv("S3_CHAVE_SECRETA")
+	S3UsarSSL = lerBooleano("S3_USAR_SSL", true)
+	TamanhoMaximoImagem = int64(lerInteiro("IMAGEM_TAMANHO_MAXIMO_KB", 5*1024)) * 1024
+	MaximoAnexosPorPublicacao = lerInteiro("PUBLICACAO_MAXIMO_ANEXOS", 4)
+	LarguraMiniatura = lerInteiro("MINIATURA_LARGURA", 320)
+
+	TamanhoMaximoTrecho = lerInteiro("TRECHO_TAMANHO_MAXIMO", 10000)
+	MaximoTrechosPorPublicacao = lerInteiro("PUBLICACAO_MAXIMO_TRECHOS", 5)
+	EstiloDestaque = lerTexto("DESTAQUE_ESTILO", "github")
+
+	RecursosMarkdown = strings.Split(lerTexto("MARKDOWN_RECURSOS", "links,listas,codigo,enfase"), ",")
+
+	TiposDeReacao = strings.Split(lerTexto("REACOES_PERMITIDAS", "👍,❤️,😂,😮,😢,💡"), ",")
+
+	JanelaFeedRelevante = time.Duration(lerInteiro("FEED_RELEVANTE_JANELA_HORAS", 72)) * time.Hour
+	MaximoCandidatosFeedRelevante = lerInteiro("FEED_RELEVANTE_MAXIMO_CANDIDATOS", 500)
+	MeiaVidaRelevancia = time.Duration(lerInteiro("RELEVANCIA_MEIA_VIDA_HORAS", 12)) * time.Hour
+	PesoCurtidaRelevancia = lerDecimal("RELEVANCIA_PESO_CURTIDA", 1)
+	PesoReacaoRelevancia = lerDecimal("RELEVANCIA_PESO_REACAO", 1.5)
+	PesoSalvoRelevancia = lerDecimal("RELEVANCIA_PESO_SALVO", 3)
+	PesoVotoRelevancia = lerDecimal("RELEVANCIA_PESO_VOTO", 0.5)
+	PesoAfinidadeRelevancia = lerDecimal("RELEVANCIA_PESO_AFINIDADE", 0.5)
+
+	LimiteSeguidoresLinhaDoTempo = lerInteiro("LINHA_DO_TEMPO_LIMITE_SEGUIDORES", 10000)
+	PreenchimentoLinhaDoTempo = lerInteiro("LINHA_DO_TEMPO_PREENCHIMENTO", 20)
+	TamanhoLinhaDoTempo = lerInteiro("LINHA_DO_TEMPO_TAMANHO", 800)
+
+	QuantidadeSugestoes = lerInteiro("SUGESTOES_QUANTIDADE", 20)
+	ValidadeSugestoes = time.Duration(lerInteiro("SUGESTOES_VALIDADE_MINUTOS", 360)) * time.Minute
+	IntervaloSugestoes = time.Duration(lerPositivo("SUGESTOES_INTERVALO_MINUTOS", 15)) * time.Minute
+	LoteSugestoes = lerInteiro("SUGESTOES_LOTE", 100)
+
+	IntervaloTendencias = time.Duration(lerPositivo("TENDENCIAS_INTERVALO_MINUTOS", 5)) * time.Minute
+	QuantidadeTendencias = lerInteiro("TENDENCIAS_QUANTIDADE", 20)
+	MaximoCandidatosTendencias = lerInteiro("TENDENCIAS_MAXIMO_CANDIDATOS", 5000)
+
+	IntervaloTrocaNick = time.Duration(lerInteiro("NICK_INTERVALO_TROCA_DIAS", 30)) * 24 * time.Hour
+	ReservaNick = time.Duration(lerInteiro("NICK_RESERVA_DIAS", 90)) * 24 * time.Hour
+
+	ValidadeExportacao = time.Duration(lerInteiro("EXPORTACAO_VALIDADE_HORAS", 48)) * time.Hour
+	IntervaloExportacoes = time.Duration(lerPositivo("EXPORTACAO_INTERVALO_SEGUNDOS", 30)) * time.Second
+
+	PrazoExclusaoConta = time.Duration(lerInteiro("CONTA_EXCLUSAO_PRAZO_DIAS", 30)) * 24 * time.Hour
+	IntervaloExclusaoContas = time.Duration(lerPositivo("CONTA_EXCLUSAO_INTERVALO_MINUTOS", 60)) * time.Minute
+
+	IntervaloFiltros = time.Duration(lerPositivo("FILTROS_INTERVALO_SEGUNDOS", 60)) * time.Second
+	MaximoPalavrasSilenciadas = lerInteiro("PALAVRAS_SILENCIADAS_MAXIMO", 100)
+
+	IdadeContaNovaAntispam = time.Duration(lerInteiro("ANTISPAM_CONTA_NOVA_HORAS", 72)) * time.Hour
+	MultiplicadorContaNovaAntispam = lerDecimal("ANTISPAM_MULTIPLICADOR_CONTA_NOVA", 2)
+	PublicacoesPorHoraAntispam = lerInteiro("ANTISPAM_PUBLICACOES_POR_HORA", 10)
+	SeguidasPorHoraAntispam = lerInteiro("ANTISPAM_SEGUIDAS_POR_HORA", 50)
+	DuplicadasAntispam = lerInteiro("ANTISPAM_DUPLICADAS", 3)
+	MaximoLinksAntispam = lerInteiro("ANTISPAM_MAXIMO_LINKS", 3)
+	DensidadeLinksAntispam = lerDecimal("ANTISPAM_DENSIDADE_LINKS", 0.3)
+	PesoPublicacaoAntispam = lerDecimal("ANTISPAM_PESO_PUBLICACAO", 20)
+	PesoSeguidaAntispam = lerDecimal("ANTISPAM_PESO_SEGUIDA", 5)
+	PesoDuplicadaAntispam = lerDecimal("ANTISPAM_PESO_DUPLICADA", 40)
+	PesoLinkAntispam = lerDecimal("ANTISPAM_PESO_LINK", 15)
+	PontuacaoLimitarAntispam = lerDecimal("ANTISPAM_PONTUACAO_LIMITAR", 40)
+	PontuacaoQuarentenaAntispam = lerDecimal("ANTISPAM_PONTUACAO_QUARENTENA", 100)
+	EsperaAntispam = time.Duration(lerInteiro("ANTISPAM_ESPERA_MINUTOS", 15)) * time.Minute
+}
+
+// lerInteiro lê uma variável de ambiente numérica, usando o valor padrão quando ela não está definida ou é inválida
+func lerInteiro(nome string, padrao int) int {
+	valor, erro := strconv.Atoi(os.Getenv(nome))
+	if erro != nil {
+		return padrao
+	}
+
+	return valor
+}
+
+// lerPositivo lê uma variável de ambiente numérica que precisa ser maior que zero, como os intervalos das
+// tarefas, que não podem ser zero nem negativos. Usa o valor padrão quando ela não está definida ou é inválida
+func lerPositivo(nome string, padrao int) int {
+	if valor := lerInteiro(nome, padrao); valor > 0 {
+		return valor
+	}
+
+	return padrao
+}
+
+// lerDecimal lê uma variável de ambiente com casas decimais, usando o valor padrão quando ela não está definida ou é inválida
+func lerDecimal(nome string, padrao float64) float64 {
+	valor, erro := strconv.ParseFloat(os.Getenv(nome), 64)
+	if erro != nil {
+		return padrao
+	}
+
+	return valor
+}
+
+// lerTexto lê uma variável de ambiente, usando o valor padrão quando ela não está definida
+func lerTexto(nome, padrao string) string {
+	if valor := os.Getenv(nome); valor != "" {
+		return valor
+	}
+
+	return padrao
+}
+
+// lerBooleano lê uma variável de ambiente booleana, usando o valor padrão quando ela não está definida ou é inválida
+func lerBooleano(nome string, padrao bool) bool {
+	valor, erro := strconv.ParseBool(os.Getenv(nome))
+	if erro != nil {
+		return padrao
+	}
+
+	return valor
 }
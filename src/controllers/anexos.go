@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"api/src/armazenamento"
+	"api/src/autenticacao"
+	"api/src/config"
+	"api/src/midias"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// tamanhoMaximoCamposMultipart é o espaço reservado para os campos de texto de uma requisição multipart
+const tamanhoMaximoCamposMultipart = 1 << 20
+
+// BuscarMidia devolve um arquivo enviado pelos usuários
+// @Summary Buscar uma mídia
+// @Description Retorna uma imagem ou miniatura anexada a uma publicação visível. O autor, enviando o token,
+// @Description também recebe as imagens das suas publicações ainda não publicadas
+// @Security ApiKeyAuth
+// @Tags publicacoes
+// @Produce  image/jpeg,image/png
+// @Param   chave path string true "Chave do arquivo"
+// @Success 200 {file} file
+// @Failure 404 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Router /midias/{chave} [get]
+func BuscarMidia(w http.ResponseWriter, r *http.Request) {
+	chave := mux.Vars(r)["chave"]
+
+	// Só as imagens das publicações são públicas; os arquivos de exportação são baixados por links assinados
+	if !strings.HasPrefix(chave, "publicacoes/") {
+		respostas.Erro(w, http.StatusNotFound, armazenamento.ErrArquivoNaoEncontrado)
+		return
+	}
+
+	// O token é opcional: sem ele, só as imagens das publicações visíveis para todos são servidas, e o autor
+	// que o envia também vê as dos rascunhos, agendadas e ocultadas. Qualquer outro caso recebe 404, para não
+	// revelar que o arquivo existe
+	usuarioID, _ := autenticacao.ExtrairUsuarioID(r)
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	visivel, erro := repos.Anexo.Visivel(chave, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	if !visivel {
+		respostas.Erro(w, http.StatusNotFound, armazenamento.ErrArquivoNaoEncontrado)
+		return
+	}
+
+	arquivo, erro := armazenamento.Padrao.Abrir(r.Context(), chave)
+	if erro != nil {
+		if errors.Is(erro, armazenamento.ErrArquivoNaoEncontrado) || errors.Is(erro, armazenamento.ErrChaveInvalida) {
+			respostas.Erro(w, http.StatusNotFound, armazenamento.ErrArquivoNaoEncontrado)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer arquivo.Close()
+
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(chave)))
+	// O cache é curto e privado porque a publicação pode deixar de ser visível, ao ir para a lixeira ou ser
+	// ocultada pela moderação, e a resposta pode depender do token de quem pediu
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, arquivo)
+}
+
+// ehMultipart indica se o corpo da requisição foi enviado como multipart/form-data
+func ehMultipart(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+}
+
+// lerPublicacaoComImagens lê uma publicação enviada como multipart/form-data, com os dados da
+// publicação em JSON no campo "publicacao" e as imagens no campo "imagens"
+func lerPublicacaoComImagens(w http.ResponseWriter, r *http.Request) (modelos.Publicacao, []midias.Imagem, int, error) {
+	limite := config.TamanhoMaximoImagem*int64(config.MaximoAnexosPorPublicacao) + tamanhoMaximoCamposMultipart
+	r.Body = http.MaxBytesReader(w, r.Body, limite)
+
+	if erro := r.ParseMultipartForm(tamanhoMaximoCamposMultipart); erro != nil {
+		var erroTamanho *http.MaxBytesError
+		if errors.As(erro, &erroTamanho) {
+			return modelos.Publicacao{}, nil, http.StatusRequestEntityTooLarge, midias.ErrImagemMuitoGrande
+		}
+		return modelos.Publicacao{}, nil, http.StatusBadRequest, erro
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var publicacao modelos.Publicacao
+	if erro := json.Unmarshal([]byte(r.FormValue("publicacao")), &publicacao); erro != nil {
+		return modelos.Publicacao{}, nil, http.StatusBadRequest, erro
+	}
+
+	arquivos := r.MultipartForm.File["imagens"]
+	if len(arquivos) > config.MaximoAnexosPorPublicacao {
+		return modelos.Publicacao{}, nil, http.StatusBadRequest,
+			fmt.Errorf("Uma publicação pode ter no máximo %d imagens", config.MaximoAnexosPorPublicacao)
+	}
+
+	var imagens []midias.Imagem
+	for _, cabecalho := range arquivos {
+		if cabecalho.Size > config.TamanhoMaximoImagem {
+			return modelos.Publicacao{}, nil, http.StatusRequestEntityTooLarge, midias.ErrImagemMuitoGrande
+		}
+
+		arquivo, erro := cabecalho.Open()
+		if erro != nil {
+			return modelos.Publicacao{}, nil, http.StatusUnprocessableEntity, erro
+		}
+
+		dados, erro := io.ReadAll(arquivo)
+		arquivo.Close()
+		if erro != nil {
+			return modelos.Publicacao{}, nil, http.StatusUnprocessableEntity, erro
+		}
+
+		imagem, erro := midias.Processar(dados)
+		if erro != nil {
+			if errors.Is(erro, midias.ErrImagemMuitoGrande) {
+				return modelos.Publicacao{}, nil, http.StatusRequestEntityTooLarge, erro
+			}
+			if errors.Is(erro, midias.ErrTipoNaoSuportado) {
+				return modelos.Publicacao{}, nil, http.StatusUnsupportedMediaType, erro
+			}
+			return modelos.Publicacao{}, nil, http.StatusBadRequest, erro
+		}
+
+		imagens = append(imagens, imagem)
+	}
+
+	return publicacao, imagens, http.StatusOK, nil
+}
+
+// salvarAnexos guarda as imagens no armazenamento e registra os anexos da publicação
+func salvarAnexos(ctx context.Context, repos *repositorios.Repositories, publicacaoID uint64, imagens []midias.Imagem) ([]modelos.Anexo, error) {
+	var anexos []modelos.Anexo
+
+	for _, imagem := range imagens {
+		nome, erro := nomeAleatorio()
+		if erro != nil {
+			return nil, erro
+		}
+
+		anexo := modelos.Anexo{
+			PublicacaoID:   publicacaoID,
+			Chave:          fmt.Sprintf("publicacoes/%d/%s%s", publicacaoID, nome, imagem.Extensao),
+			ChaveMiniatura: fmt.Sprintf("publicacoes/%d/%s_miniatura%s", publicacaoID, nome, imagem.Extensao),
+			Tipo:           imagem.Tipo,
+			Tamanho:        uint64(len(imagem.Conteudo)),
+			Largura:        imagem.Largura,
+			Altura:         imagem.Altura,
+		}
+
+		if erro = armazenamento.Padrao.Salvar(ctx, anexo.Chave, imagem.Tipo, imagem.Conteudo); erro != nil {
+			return nil, erro
+		}
+
+		if erro = armazenamento.Padrao.Salvar(ctx, anexo.ChaveMiniatura, imagem.Tipo, imagem.Miniatura); erro != nil {
+			return nil, erro
+		}
+
+		if anexo.ID, erro = repos.Anexo.Criar(anexo); erro != nil {
+			return nil, erro
+		}
+
+		preencherURLs(&anexo)
+		anexos = append(anexos, anexo)
+	}
+
+	return anexos, nil
+}
+
+// preencherURLs monta os endereços públicos da imagem e da miniatura de um anexo
+func preencherURLs(anexo *modelos.Anexo) {
+	anexo.URL = armazenamento.Padrao.URL(anexo.Chave)
+	anexo.URLMiniatura = armazenamento.Padrao.URL(anexo.ChaveMiniatura)
+}
+
+// nomeAleatorio gera um nome imprevisível para os arquivos enviados
+func nomeAleatorio() (string, error) {
+	bytes := make([]byte, 16)
+	if _, erro := rand.Read(bytes); erro != nil {
+		return "", erro
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
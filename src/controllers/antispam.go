@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"api/src/antispam"
+	"api/src/config"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrContaEmQuarentena é retornado quando uma conta em quarentena tenta publicar, editar ou seguir alguém
+	ErrContaEmQuarentena = errors.New("Sua conta foi colocada em quarentena por atividade suspeita e aguarda a análise de um moderador")
+
+	// ErrAtividadeLimitada é retornado quando o antispam recusa a ação por ela ter vindo rápido demais
+	ErrAtividadeLimitada = errors.New("Muitas ações em pouco tempo. Aguarde um pouco antes de tentar de novo")
+)
+
+// verificarSpam pontua a ação do usuário antes de ela ser gravada: uma publicação ou, com a publicação nil,
+// seguir alguém. Se a ação for barrada a resposta já é escrita e o retorno é false. Contas que passam do limite
+// de quarentena só voltam a publicar e seguir depois que um moderador libera a denúncia aberta aqui
+func verificarSpam(w http.ResponseWriter, r *http.Request, repos *repositorios.Repositories, usuarioID uint64, publicacao *modelos.Publicacao) bool {
+	agora := time.Now()
+
+	var hashConteudo string
+	if publicacao != nil {
+		hashConteudo = antispam.Hash(publicacao.Titulo, publicacao.Conteudo)
+	}
+
+	sinais, erro := repos.Antispam.BuscarSinais(usuarioID, hashConteudo, agora)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return false
+	}
+
+	if sinais.EmQuarentena {
+		respostas.Erro(w, http.StatusForbidden, ErrContaEmQuarentena)
+		return false
+	}
+
+	// As contagens vêm do banco sem a ação que está sendo avaliada
+	if publicacao != nil {
+		sinais.PublicacoesRecentes++
+		sinais.Duplicadas++
+		sinais.Links, sinais.Palavras = antispam.ContarLinks(publicacao.Titulo + " " + publicacao.Conteudo)
+	} else {
+		sinais.SeguidasRecentes++
+	}
+
+	avaliacao := antispam.Avaliar(sinais, antispam.LimitesDaConfiguracao(), agora)
+
+	switch avaliacao.Acao {
+	case antispam.AcaoQuarentena:
+		quarentenada, erro := repos.Antispam.Quarentenar(usuarioID, resumirMotivos(avaliacao.Motivos))
+		if erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return false
+		}
+
+		if quarentenada {
+			repos.Logger.Warn("antispam: conta colocada em quarentena", "usuarioId", usuarioID, "pontuacao", avaliacao.Pontuacao)
+			auditar(r, repos, modelos.AuditoriaQuarentena, 0, usuarioID, nil,
+				map[string]interface{}{"pontuacao": avaliacao.Pontuacao, "motivos": avaliacao.Motivos},
+			)
+		}
+		respostas.Erro(w, http.StatusForbidden, ErrContaEmQuarentena)
+		return false
+
+	case antispam.AcaoLimitar:
+		w.Header().Set("Retry-After", strconv.Itoa(int(config.EsperaAntispam.Seconds())))
+		respostas.Erro(w, http.StatusTooManyRequests, ErrAtividadeLimitada)
+		return false
+	}
+
+	return true
+}
+
+// verificarQuarentena barra as edições de publicações e rascunhos de contas em quarentena, que de outro modo
+// poderiam publicar um rascunho ou trocar o texto de uma publicação por spam. Se a conta estiver em
+// quarentena a resposta já é escrita e o retorno é false
+func verificarQuarentena(w http.ResponseWriter, repos *repositorios.Repositories, usuarioID uint64) bool {
+	emQuarentena, erro := repos.Antispam.EmQuarentena(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return false
+	}
+
+	if emQuarentena {
+		respostas.Erro(w, http.StatusForbidden, ErrContaEmQuarentena)
+		return false
+	}
+
+	return true
+}
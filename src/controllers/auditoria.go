@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/utils"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// tamanhoMaximoUserAgent é quantos caracteres do user agent são guardados na auditoria
+const tamanhoMaximoUserAgent = 255
+
+// ErrSomenteAdministradores é retornado quando um usuário comum tenta acessar uma rota de administração
+var ErrSomenteAdministradores = errors.New("somente administradores podem acessar a administração")
+
+// BuscarAuditoria retorna os registros da auditoria de todas as contas
+// @Summary Consultar a auditoria
+// @Description Retorna as ações relevantes para a segurança, das mais novas para as mais antigas. Pode ser filtrada pela
+// @Description ação, por quem a fez (atorId), pela conta afetada (usuarioId) e por período (desde e ate, no formato RFC 3339)
+// @Tags administracao
+// @Accept  json
+// @Produce  json
+// @Param   acao query string false "Ação registrada"
+// @Param   atorId query int false "ID de quem fez a ação"
+// @Param   usuarioId query int false "ID da conta afetada"
+// @Param   desde query string false "Início do período"
+// @Param   ate query string false "Fim do período"
+// @Param   pagina query int false "Página"
+// @Param   limite query int false "Itens por página"
+// @Success 200 {array} modelos.RegistroAuditoria
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /auditoria [get]
+func BuscarAuditoria(w http.ResponseWriter, r *http.Request) {
+	administradorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	paginacao, erro := utils.ExtrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	filtro, erro := lerFiltroAuditoria(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	parametros := r.URL.Query()
+	for nome, destino := range map[string]*uint64{"atorId": &filtro.AtorID, "usuarioId": &filtro.UsuarioID} {
+		if valor := parametros.Get(nome); valor != "" {
+			if *destino, erro = strconv.ParseUint(valor, 10, 64); erro != nil {
+				respostas.Erro(w, http.StatusBadRequest, erro)
+				return
+			}
+		}
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if !verificarAdministrador(w, repos, administradorID) {
+		return
+	}
+
+	registros, erro := repos.Auditoria.Buscar(filtro, paginacao)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, registros)
+}
+
+// BuscarAuditoriaDoUsuario retorna os registros da auditoria que afetaram uma conta
+// @Summary Consultar a auditoria de uma conta
+// @Description Retorna as ações relevantes para a segurança que afetaram a conta, como entradas, trocas de senha e de e-mail.
+// @Description O dono da conta não vê quem fez as ações de outras pessoas, como as da moderação, nem de onde elas vieram
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param   usuarioId path int true "ID do Usuário"
+// @Param   acao query string false "Ação registrada"
+// @Param   desde query string false "Início do período"
+// @Param   ate query string false "Fim do período"
+// @Param   pagina query int false "Página"
+// @Param   limite query int false "Itens por página"
+// @Success 200 {array} modelos.RegistroAuditoria
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /usuarios/{usuarioId}/auditoria [get]
+func BuscarAuditoriaDoUsuario(w http.ResponseWriter, r *http.Request) {
+	usuarioIDNoToken, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	paginacao, erro := utils.ExtrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	filtro, erro := lerFiltroAuditoria(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+	filtro.UsuarioID = usuarioID
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if usuarioID != usuarioIDNoToken && !verificarAdministrador(w, repos, usuarioIDNoToken) {
+		return
+	}
+
+	registros, erro := repos.Auditoria.Buscar(filtro, paginacao)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if usuarioID == usuarioIDNoToken {
+		for i, registro := range registros {
+			if registro.AtorID == nil || *registro.AtorID != usuarioID {
+				registros[i].AtorID, registros[i].AtorNick, registros[i].IP, registros[i].UserAgent = nil, "", "", ""
+			}
+		}
+	}
+
+	respostas.JSON(w, http.StatusOK, registros)
+}
+
+// DefinirModerador concede ou retira o papel de moderador de um usuário
+// @Summary Definir moderador
+// @Description Concede ou retira o papel de moderador de um usuário. A mudança fica registrada na auditoria
+// @Tags administracao
+// @Accept  json
+// @Produce  json
+// @Param   usuarioId path int true "ID do Usuário"
+// @Param   papel body modelos.Papel true "Papel do usuário"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 422 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /usuarios/{usuarioId}/moderador [put]
+func DefinirModerador(w http.ResponseWriter, r *http.Request) {
+	administradorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var papel modelos.Papel
+	if erro = json.Unmarshal(corpoRequisicao, &papel); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if !verificarAdministrador(w, repos, administradorID) {
+		return
+	}
+
+	anterior, erro := repos.Usuario.DefinirModerador(usuarioID, papel.Moderador)
+	if erro != nil {
+		if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+			respostas.Erro(w, http.StatusNotFound, erro)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if anterior != papel.Moderador {
+		auditar(r, repos, modelos.AuditoriaPapelAlterado, administradorID, usuarioID, modelos.Papel{Moderador: anterior}, papel)
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// auditar grava uma ação na auditoria com o IP e o user agent da requisição. Os IDs zerados ficam nulos e os
+// estados de antes e depois são guardados como JSON. Uma falha aqui só é registrada no log, já que a ação
+// auditada já foi feita
+func auditar(r *http.Request, repos *repositorios.Repositories, acao string, atorID, usuarioID uint64, antes, depois interface{}) {
+	userAgent := []rune(r.UserAgent())
+	if len(userAgent) > tamanhoMaximoUserAgent {
+		userAgent = userAgent[:tamanhoMaximoUserAgent]
+	}
+
+	registro := modelos.RegistroAuditoria{
+		Acao:      acao,
+		IP:        utils.ExtrairIP(r),
+		UserAgent: string(userAgent),
+	}
+
+	var erro error
+	if registro.Antes, erro = instantaneo(antes); erro == nil {
+		registro.Depois, erro = instantaneo(depois)
+	}
+	if erro != nil {
+		repos.Logger.Error("auditoria: não foi possível converter o estado em JSON", "acao", acao, "erro", erro)
+	}
+
+	if atorID != 0 {
+		registro.AtorID = &atorID
+	}
+
+	if usuarioID != 0 {
+		registro.UsuarioID = &usuarioID
+	}
+
+	if erro = repos.Auditoria.Registrar(registro); erro != nil {
+		repos.Logger.Error("auditoria: não foi possível registrar a ação", "acao", acao, "usuarioId", usuarioID, "erro", erro)
+	}
+}
+
+// instantaneo converte o estado de algo auditado em JSON, deixando vazio quando não há estado
+func instantaneo(estado interface{}) (json.RawMessage, error) {
+	if estado == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(estado)
+}
+
+// dadosDaConta são os dados da conta guardados na auditoria quando ela é alterada
+func dadosDaConta(usuario modelos.Usuario) map[string]string {
+	return map[string]string{
+		"nome":  usuario.Nome,
+		"nick":  usuario.Nick,
+		"email": usuario.Email,
+	}
+}
+
+// dadosDaPublicacao são os dados da publicação guardados na auditoria quando ela é apagada
+func dadosDaPublicacao(publicacao modelos.Publicacao) map[string]interface{} {
+	return map[string]interface{}{
+		"publicacaoId": publicacao.ID,
+		"titulo":       publicacao.Titulo,
+		"status":       publicacao.Status,
+	}
+}
+
+// lerFiltroAuditoria lê da query string os filtros de ação e de período da auditoria
+func lerFiltroAuditoria(r *http.Request) (modelos.FiltroAuditoria, error) {
+	parametros := r.URL.Query()
+	filtro := modelos.FiltroAuditoria{Acao: parametros.Get("acao")}
+
+	for nome, destino := range map[string]**time.Time{"desde": &filtro.Desde, "ate": &filtro.Ate} {
+		valor := parametros.Get(nome)
+		if valor == "" {
+			continue
+		}
+
+		data, erro := time.Parse(time.RFC3339, valor)
+		if erro != nil {
+			return modelos.FiltroAuditoria{}, errors.New("Os parâmetros desde e ate devem estar no formato RFC 3339")
+		}
+		*destino = &data
+	}
+
+	return filtro, nil
+}
+
+// verificarAdministrador confere se o usuário é administrador, escrevendo a resposta de erro quando não for
+func verificarAdministrador(w http.ResponseWriter, repos *repositorios.Repositories, usuarioID uint64) bool {
+	administrador, erro := repos.Usuario.EhAdministrador(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return false
+	}
+
+	if !administrador {
+		respostas.Erro(w, http.StatusForbidden, ErrSomenteAdministradores)
+		return false
+	}
+
+	return true
+}
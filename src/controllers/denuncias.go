@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/utils"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// DenunciarPublicacao registra uma denúncia contra uma publicação
+// @Summary Denunciar uma publicação
+// @Description Envia uma publicação para a fila de moderação. O motivo deve ser uma das categorias aceitas:
+// @Description spam, assedio, discurso_de_odio, conteudo_improprio, informacao_falsa ou outro
+// @Tags moderacao
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Param   denuncia body modelos.Denuncia true "Motivo e descrição da denúncia"
+// @Success 201 {object} modelos.Denuncia
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 409 {object} respostas.Erro
+// @Failure 422 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/denunciar [post]
+func DenunciarPublicacao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	denuncia, status, erro := lerDenuncia(r)
+	if erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	publicacao, erro := repos.Publicacao.BuscarPorID(publicacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if publicacao.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	if publicacao.AutorID == usuarioID {
+		respostas.Erro(w, http.StatusForbidden, errors.New("Não é possível denunciar uma publicação sua"))
+		return
+	}
+
+	denuncia.DenuncianteID = &usuarioID
+	denuncia.UsuarioID = &publicacao.AutorID
+	denuncia.PublicacaoID = &publicacao.ID
+	registrarDenuncia(w, repos, denuncia)
+}
+
+// DenunciarUsuario registra uma denúncia contra um usuário
+// @Summary Denunciar um usuário
+// @Description Envia um usuário para a fila de moderação. O motivo deve ser uma das categorias aceitas:
+// @Description spam, assedio, discurso_de_odio, conteudo_improprio, informacao_falsa ou outro
+// @Tags moderacao
+// @Accept  json
+// @Produce  json
+// @Param   usuarioId path int true "ID do Usuário"
+// @Param   denuncia body modelos.Denuncia true "Motivo e descrição da denúncia"
+// @Success 201 {object} modelos.Denuncia
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 409 {object} respostas.Erro
+// @Failure 422 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /usuarios/{usuarioId}/denunciar [post]
+func DenunciarUsuario(w http.ResponseWriter, r *http.Request) {
+	denuncianteID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if usuarioID == denuncianteID {
+		respostas.Erro(w, http.StatusForbidden, errors.New("Não é possível denunciar você mesmo"))
+		return
+	}
+
+	denuncia, status, erro := lerDenuncia(r)
+	if erro != nil {
+		respostas.Erro(w, status, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	usuario, erro := repos.Usuario.BuscarPorID(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if usuario.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, errors.New("Usuário não encontrado"))
+		return
+	}
+
+	denuncia.DenuncianteID = &denuncianteID
+	denuncia.UsuarioID = &usuario.ID
+	registrarDenuncia(w, repos, denuncia)
+}
+
+// lerDenuncia lê e valida a denúncia enviada no corpo da requisição, retornando o status adequado em caso de erro
+func lerDenuncia(r *http.Request) (modelos.Denuncia, int, error) {
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		return modelos.Denuncia{}, http.StatusUnprocessableEntity, erro
+	}
+
+	var denuncia modelos.Denuncia
+	if erro = json.Unmarshal(corpoRequisicao, &denuncia); erro != nil {
+		return modelos.Denuncia{}, http.StatusBadRequest, erro
+	}
+
+	if erro = denuncia.Preparar(); erro != nil {
+		return modelos.Denuncia{}, http.StatusBadRequest, erro
+	}
+
+	return denuncia, 0, nil
+}
+
+// registrarDenuncia grava a denúncia e escreve a resposta com ela
+func registrarDenuncia(w http.ResponseWriter, repos *repositorios.Repositories, denuncia modelos.Denuncia) {
+	denunciaID, erro := repos.Denuncia.Criar(denuncia)
+	if erro != nil {
+		if errors.Is(erro, repositorios.ErrDenunciaDuplicada) {
+			respostas.Erro(w, http.StatusConflict, erro)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	denuncia, erro = repos.Denuncia.BuscarPorID(denunciaID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusCreated, denuncia)
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"api/src/armazenamento"
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/utils"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ErrExportacaoIndisponivel é retornado quando a exportação pedida não existe, não terminou ou já expirou
+var ErrExportacaoIndisponivel = errors.New("exportação não encontrada ou expirada")
+
+// SolicitarExportacao pede a geração de uma cópia dos dados pessoais do usuário
+// @Summary Solicitar exportação de dados
+// @Description Pede a geração de um arquivo ZIP com o perfil, as publicações (inclusive rascunhos, agendadas e
+// @Description as que estão na lixeira), imagens, reações, coleções, salvos, votos, seguidores e histórico de nicks
+// @Description do usuário. O arquivo é gerado em segundo plano; acompanhe o andamento em GET /usuarios/{usuarioId}/exportacao
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param   usuarioId path int true "ID do usuário"
+// @Success 202 {object} modelos.Exportacao
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 409 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /usuarios/{usuarioId}/exportacao [post]
+func SolicitarExportacao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	usuarioIDNoToken, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	if usuarioID != usuarioIDNoToken {
+		respostas.Erro(w, http.StatusForbidden, errors.New("Não é possível exportar os dados de um usuário que não seja o seu"))
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	exportacaoID, erro := repos.Exportacao.Criar(usuarioID)
+	if erro != nil {
+		if errors.Is(erro, repositorios.ErrExportacaoEmAndamento) {
+			respostas.Erro(w, http.StatusConflict, erro)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	exportacao, erro := repos.Exportacao.BuscarPorID(exportacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusAccepted, exportacao)
+}
+
+// BuscarExportacoes lista as exportações de dados do usuário
+// @Summary Listar exportações de dados
+// @Description Retorna as exportações de dados do usuário, das mais novas para as mais antigas. As que estão prontas
+// @Description trazem um link assinado para download, válido até a data de expiração e que não exige token
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param   usuarioId path int true "ID do usuário"
+// @Success 200 {array} modelos.Exportacao
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /usuarios/{usuarioId}/exportacao [get]
+func BuscarExportacoes(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	usuarioIDNoToken, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	if usuarioID != usuarioIDNoToken {
+		respostas.Erro(w, http.StatusForbidden, errors.New("Não é possível ver as exportações de um usuário que não seja o seu"))
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	exportacoes, erro := repos.Exportacao.BuscarPorUsuario(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	for i, exportacao := range exportacoes {
+		if exportacao.Status == modelos.StatusExportacaoPronta && exportacao.ExpiraEm != nil {
+			caminho := fmt.Sprintf("/exportacoes/%d/download", exportacao.ID)
+			exportacoes[i].URL = autenticacao.AssinarURL(caminho, *exportacao.ExpiraEm)
+		}
+	}
+
+	respostas.JSON(w, http.StatusOK, exportacoes)
+}
+
+// BaixarExportacao entrega o arquivo de uma exportação de dados
+// @Summary Baixar exportação de dados
+// @Description Entrega o arquivo ZIP de uma exportação pronta. O acesso é feito pelo link assinado retornado na
+// @Description listagem de exportações, sem token
+// @Tags usuarios
+// @Produce  application/zip
+// @Param   exportacaoId path int true "ID da exportação"
+// @Param   expira query int true "Expiração do link, em segundos desde 1970"
+// @Param   assinatura query string true "Assinatura do link"
+// @Success 200 {file} file
+// @Failure 400 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Router /exportacoes/{exportacaoId}/download [get]
+func BaixarExportacao(w http.ResponseWriter, r *http.Request) {
+	exportacaoID, erro := strconv.ParseUint(mux.Vars(r)["exportacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = autenticacao.VerificarURL(r.URL.Path, r.URL.Query()); erro != nil {
+		respostas.Erro(w, http.StatusForbidden, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	exportacao, erro := repos.Exportacao.BuscarPorID(exportacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if exportacao.ID == 0 || exportacao.Status != modelos.StatusExportacaoPronta ||
+		exportacao.ExpiraEm == nil || time.Now().After(*exportacao.ExpiraEm) {
+		respostas.Erro(w, http.StatusNotFound, ErrExportacaoIndisponivel)
+		return
+	}
+
+	arquivo, erro := armazenamento.Padrao.Abrir(r.Context(), exportacao.Chave)
+	if erro != nil {
+		if errors.Is(erro, armazenamento.ErrArquivoNaoEncontrado) {
+			respostas.Erro(w, http.StatusNotFound, ErrExportacaoIndisponivel)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+	defer arquivo.Close()
+
+	nome := fmt.Sprintf("devbook-dados-%d.zip", exportacao.UsuarioID)
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nome))
+	w.Header().Set("Cache-Control", "private, no-store")
+	io.Copy(w, arquivo)
+}
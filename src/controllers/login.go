@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"api/src/autenticacao"
+	"api/src/metricas"
 	"api/src/modelos"
+	"api/src/repositorios"
 	"api/src/respostas"
 	"api/src/seguranca"
 	"api/src/utils"
@@ -11,16 +13,15 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 )
 
-var (
-	// ErrCredenciaisInvalidas é retornado quando as credenciais do usuário estão incorretas
-	ErrCredenciaisInvalidas = errors.New("credenciais inválidas")
-)
+// ErrCredenciaisInvalidas é retornado quando as credenciais do usuário estão incorretas
+var ErrCredenciaisInvalidas = errors.New("credenciais inválidas")
 
 // Login é responsável por autenticar um usuário na API
 // @Summary Autenticar usuário
-// @Description Autentica um usuário na API e retorna um token JWT
+// @Description Autentica um usuário na API e retorna um token JWT. Entrar em uma conta desativada a reativa
 // @Tags autenticacao
 // @Accept  json
 // @Produce  json
@@ -28,6 +29,7 @@ var (
 // @Success 200 {object} modelos.DadosAutenticacao
 // @Failure 400 {object} respostas.Erro
 // @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
 // @Failure 422 {object} respostas.Erro
 // @Failure 500 {object} respostas.Erro
 // @Router /login [post]
@@ -62,20 +64,52 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if erro = seguranca.VerificarSenha(usuarioSalvoNoBanco.Senha, usuario.Senha); erro != nil {
+		registrarFalhaDeLogin(r, repos, usuarioSalvoNoBanco.ID, usuario.Email, "credenciais_invalidas")
 		respostas.Erro(w, http.StatusUnauthorized, ErrCredenciaisInvalidas)
 		return
 	}
 
+	if erro = usuarioSalvoNoBanco.Bloqueio(time.Now()); erro != nil {
+		motivo := "conta_suspensa"
+		if errors.Is(erro, modelos.ErrExclusaoAgendada) {
+			motivo = "exclusao_agendada"
+		}
+
+		registrarFalhaDeLogin(r, repos, usuarioSalvoNoBanco.ID, usuario.Email, motivo)
+		respostas.Erro(w, http.StatusForbidden, erro)
+		return
+	}
+
+	// Entrar em uma conta desativada a reativa
+	if usuarioSalvoNoBanco.DeletadoEm != nil {
+		if erro = repos.Usuario.Reativar(usuarioSalvoNoBanco.ID); erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+	}
+
 	token, erro := autenticacao.CriarToken(usuarioSalvoNoBanco.ID)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
+	metricas.RegistrarLogin(true)
+	auditar(r, repos, modelos.AuditoriaLoginSucesso, usuarioSalvoNoBanco.ID, usuarioSalvoNoBanco.ID, nil,
+		map[string]bool{"reativada": usuarioSalvoNoBanco.DeletadoEm != nil},
+	)
+
 	usuarioID := strconv.FormatUint(usuarioSalvoNoBanco.ID, 10)
 	respostas.JSON(w, http.StatusOK, modelos.DadosAutenticacao{ID: usuarioID, Token: token})
 }
 
+// registrarFalhaDeLogin registra uma tentativa de entrada recusada na auditoria e nas métricas. Quem tentou não
+// está autenticado, então o registro não tem ator; a conta afetada fica vazia quando o e-mail não pertence a ninguém
+func registrarFalhaDeLogin(r *http.Request, repos *repositorios.Repositories, usuarioID uint64, email, motivo string) {
+	metricas.RegistrarLogin(false)
+	auditar(r, repos, modelos.AuditoriaLoginFalha, 0, usuarioID, nil, map[string]string{"email": email, "motivo": motivo})
+}
+
 // validarCredenciais verifica se as credenciais do usuário são válidas
 func validarCredenciais(usuario *modelos.Usuario) error {
 	if usuario.Email == "" {
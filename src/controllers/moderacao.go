@@ -0,0 +1,517 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/triagem"
+	"api/src/utils"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ErrSomenteModeradores é retornado quando um usuário comum tenta acessar a fila de moderação
+var ErrSomenteModeradores = errors.New("somente moderadores podem acessar a moderação")
+
+// BuscarDenuncias retorna a fila de moderação
+// @Summary Fila de moderação
+// @Description Retorna as denúncias, das mais antigas para as mais novas. Pode ser filtrada pelo status
+// @Description (aberta, em_analise ou resolvida) e, com minhas=true, pelas denúncias atribuídas ao moderador autenticado
+// @Tags moderacao
+// @Accept  json
+// @Produce  json
+// @Param   status query string false "Status das denúncias"
+// @Param   minhas query bool false "Somente as denúncias atribuídas a mim"
+// @Param   pagina query int false "Página"
+// @Param   limite query int false "Itens por página"
+// @Success 200 {array} modelos.Denuncia
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /moderacao/denuncias [get]
+func BuscarDenuncias(w http.ResponseWriter, r *http.Request) {
+	moderadorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	paginacao, erro := utils.ExtrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", modelos.StatusDenunciaAberta, modelos.StatusDenunciaEmAnalise, modelos.StatusDenunciaResolvida:
+	default:
+		respostas.Erro(w, http.StatusBadRequest, errors.New("O status deve ser aberta, em_analise ou resolvida"))
+		return
+	}
+
+	var filtroModerador uint64
+	if r.URL.Query().Get("minhas") == "true" {
+		filtroModerador = moderadorID
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if !verificarModerador(w, repos, moderadorID) {
+		return
+	}
+
+	denuncias, erro := repos.Denuncia.Buscar(status, filtroModerador, paginacao)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, denuncias)
+}
+
+// AtribuirDenuncia coloca uma denúncia sob a responsabilidade do moderador autenticado
+// @Summary Assumir uma denúncia
+// @Description Atribui a denúncia ao moderador autenticado, mesmo que ela já estivesse com outro moderador
+// @Tags moderacao
+// @Accept  json
+// @Produce  json
+// @Param   denunciaId path int true "ID da Denúncia"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 409 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /moderacao/denuncias/{denunciaId}/atribuir [post]
+func AtribuirDenuncia(w http.ResponseWriter, r *http.Request) {
+	moderadorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	denunciaID, erro := strconv.ParseUint(mux.Vars(r)["denunciaId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if !verificarModerador(w, repos, moderadorID) {
+		return
+	}
+
+	if erro = repos.Denuncia.Atribuir(denunciaID, moderadorID); erro != nil {
+		responderErroDeModeracao(w, erro)
+		return
+	}
+
+	auditar(r, repos, modelos.AuditoriaDenunciaAtribuida, moderadorID, 0, nil, map[string]uint64{"denunciaId": denunciaID})
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// ResolverDenuncia aplica a decisão do moderador sobre uma denúncia
+// @Summary Resolver uma denúncia
+// @Description Encerra a denúncia com uma das ações: descartar, ocultar (esconde a publicação denunciada), liberar (volta
+// @Description a mostrar a publicação escondida pela moderação ou retida pelos filtros; em denúncias de usuários, tira a conta da quarentena do antispam), advertir (registra uma advertência para o autor) ou suspender (impede o autor de entrar pelos dias informados)
+// @Tags moderacao
+// @Accept  json
+// @Produce  json
+// @Param   denunciaId path int true "ID da Denúncia"
+// @Param   resolucao body modelos.Resolucao true "Ação tomada"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 409 {object} respostas.Erro
+// @Failure 422 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /moderacao/denuncias/{denunciaId}/resolver [post]
+func ResolverDenuncia(w http.ResponseWriter, r *http.Request) {
+	moderadorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	denunciaID, erro := strconv.ParseUint(mux.Vars(r)["denunciaId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var resolucao modelos.Resolucao
+	if erro = json.Unmarshal(corpoRequisicao, &resolucao); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = resolucao.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if !verificarModerador(w, repos, moderadorID) {
+		return
+	}
+
+	denuncia, erro := repos.Denuncia.Resolver(denunciaID, moderadorID, resolucao)
+	if erro != nil {
+		responderErroDeModeracao(w, erro)
+		return
+	}
+
+	var usuarioAfetado uint64
+	if denuncia.UsuarioID != nil {
+		usuarioAfetado = *denuncia.UsuarioID
+	}
+	auditar(r, repos, modelos.AuditoriaDenunciaResolvida, moderadorID, usuarioAfetado,
+		map[string]interface{}{"denunciaId": denunciaID, "status": denuncia.Status, "publicacaoId": denuncia.PublicacaoID},
+		resolucao,
+	)
+
+	switch resolucao.Acao {
+	case modelos.AcaoOcultar:
+		if erro = repos.LinhaDoTempo.RemoverPublicacao(*denuncia.PublicacaoID); erro != nil {
+			repos.Logger.Error("moderação: não foi possível tirar a publicação das linhas do tempo",
+				"publicacaoId", *denuncia.PublicacaoID, "erro", erro,
+			)
+		}
+	case modelos.AcaoLiberar:
+		if denuncia.PublicacaoID != nil {
+			distribuirPublicacao(repos, *denuncia.PublicacaoID)
+		}
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarDecisoes retorna o histórico de uma denúncia
+// @Summary Histórico de uma denúncia
+// @Description Retorna todas as decisões tomadas pelos moderadores sobre a denúncia, na ordem em que aconteceram
+// @Tags moderacao
+// @Accept  json
+// @Produce  json
+// @Param   denunciaId path int true "ID da Denúncia"
+// @Success 200 {array} modelos.DecisaoModeracao
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /moderacao/denuncias/{denunciaId}/historico [get]
+func BuscarDecisoes(w http.ResponseWriter, r *http.Request) {
+	moderadorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	denunciaID, erro := strconv.ParseUint(mux.Vars(r)["denunciaId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if !verificarModerador(w, repos, moderadorID) {
+		return
+	}
+
+	decisoes, erro := repos.Denuncia.BuscarDecisoes(denunciaID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, decisoes)
+}
+
+// BuscarAdvertencias retorna as advertências que o usuário recebeu da moderação
+// @Summary Advertências de um usuário
+// @Description Retorna as advertências recebidas pelo usuário, das mais novas para as mais antigas. Só o próprio
+// @Description usuário e os moderadores podem ver
+// @Tags moderacao
+// @Accept  json
+// @Produce  json
+// @Param   usuarioId path int true "ID do Usuário"
+// @Success 200 {array} modelos.Advertencia
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /usuarios/{usuarioId}/advertencias [get]
+func BuscarAdvertencias(w http.ResponseWriter, r *http.Request) {
+	usuarioIDNoToken, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if usuarioID != usuarioIDNoToken && !verificarModerador(w, repos, usuarioIDNoToken) {
+		return
+	}
+
+	advertencias, erro := repos.Denuncia.BuscarAdvertencias(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, advertencias)
+}
+
+// BuscarFiltros retorna as regras de filtro de conteúdo
+// @Summary Listar regras de filtro
+// @Description Retorna as regras aplicadas ao título e ao conteúdo das publicações
+// @Tags moderacao
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} triagem.Regra
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /moderacao/filtros [get]
+func BuscarFiltros(w http.ResponseWriter, r *http.Request) {
+	moderadorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if !verificarModerador(w, repos, moderadorID) {
+		return
+	}
+
+	regras, erro := repos.Filtro.BuscarRegras()
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, regras)
+}
+
+// CriarFiltro cadastra uma regra de filtro de conteúdo
+// @Summary Criar regra de filtro
+// @Description Cadastra uma regra do tipo termo (palavra inteira, sem diferenciar maiúsculas), regex ou dominio
+// @Description (bloqueia links para o domínio e os seus subdomínios), com a ação mascarar, revisar ou rejeitar.
+// @Description A regra passa a valer imediatamente nesta instância e nas demais na próxima atualização dos filtros
+// @Tags moderacao
+// @Accept  json
+// @Produce  json
+// @Param   regra body triagem.Regra true "Regra de filtro"
+// @Success 201 {object} triagem.Regra
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 422 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /moderacao/filtros [post]
+func CriarFiltro(w http.ResponseWriter, r *http.Request) {
+	moderadorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var regra triagem.Regra
+	if erro = json.Unmarshal(corpoRequisicao, &regra); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = regra.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if !verificarModerador(w, repos, moderadorID) {
+		return
+	}
+
+	regra.ID, erro = repos.Filtro.CriarRegra(regra, moderadorID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	auditar(r, repos, modelos.AuditoriaFiltroCriado, moderadorID, 0, nil, regra)
+
+	recarregarFiltros(repos)
+	respostas.JSON(w, http.StatusCreated, regra)
+}
+
+// DeletarFiltro apaga uma regra de filtro de conteúdo
+// @Summary Deletar regra de filtro
+// @Description Apaga uma regra de filtro. As publicações já mascaradas ou retidas por ela não mudam
+// @Tags moderacao
+// @Accept  json
+// @Produce  json
+// @Param   filtroId path int true "ID da Regra"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /moderacao/filtros/{filtroId} [delete]
+func DeletarFiltro(w http.ResponseWriter, r *http.Request) {
+	moderadorID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	regraID, erro := strconv.ParseUint(mux.Vars(r)["filtroId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if !verificarModerador(w, repos, moderadorID) {
+		return
+	}
+
+	regras, erro := repos.Filtro.BuscarRegras()
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = repos.Filtro.DeletarRegra(regraID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	for _, regra := range regras {
+		if regra.ID == regraID {
+			auditar(r, repos, modelos.AuditoriaFiltroRemovido, moderadorID, 0, regra, nil)
+		}
+	}
+
+	recarregarFiltros(repos)
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// recarregarFiltros aplica nesta instância as regras de filtro que acabaram de mudar. Se falhar, as regras
+// novas passam a valer na próxima execução da tarefa que atualiza os filtros
+func recarregarFiltros(repos *repositorios.Repositories) {
+	regras, erro := repos.Filtro.BuscarRegras()
+	if erro == nil {
+		erro = triagem.Carregar(regras)
+	}
+
+	if erro != nil {
+		repos.Logger.Error("filtros: não foi possível recarregar as regras", "erro", erro)
+	}
+}
+
+// verificarModerador confere se o usuário é moderador, escrevendo a resposta de erro quando não for
+func verificarModerador(w http.ResponseWriter, repos *repositorios.Repositories, usuarioID uint64) bool {
+	moderador, erro := repos.Usuario.EhModerador(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return false
+	}
+
+	if !moderador {
+		respostas.Erro(w, http.StatusForbidden, ErrSomenteModeradores)
+		return false
+	}
+
+	return true
+}
+
+// responderErroDeModeracao escreve a resposta adequada para os erros das ações da moderação
+func responderErroDeModeracao(w http.ResponseWriter, erro error) {
+	switch {
+	case errors.Is(erro, repositorios.ErrDenunciaNaoEncontrada):
+		respostas.Erro(w, http.StatusNotFound, erro)
+	case errors.Is(erro, repositorios.ErrDenunciaResolvida):
+		respostas.Erro(w, http.StatusConflict, erro)
+	case errors.Is(erro, repositorios.ErrAcaoSemPublicacao), errors.Is(erro, repositorios.ErrAcaoSemUsuario):
+		respostas.Erro(w, http.StatusBadRequest, erro)
+	default:
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+	}
+}
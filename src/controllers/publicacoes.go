@@ -2,7 +2,14 @@ package controllers
 
 import (
 	"api/src/autenticacao"
+	"api/src/config"
+	"api/src/destaque"
+	"api/src/markdown"
+	"api/src/metricas"
+	"api/src/midias"
 	"api/src/modelos"
+	"api/src/relevancia"
+	"api/src/repositorios"
 	"api/src/respostas"
 	"api/src/utils"
 	"encoding/json"
@@ -10,6 +17,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -19,17 +28,36 @@ const (
 	msgErroPublicacaoNaoAutorizada = "Não é possível realizar operações em uma publicação que não seja sua"
 )
 
+const (
+	// modoCronologico ordena o feed das publicações mais novas para as mais antigas
+	modoCronologico = "cronologico"
+
+	// modoRelevante ordena o feed pela pontuação de relevância
+	modoRelevante = "relevante"
+)
+
+// tamanhoMaximoDescricaoRevisao é quantos caracteres dos motivos cabem na descrição de uma denúncia automática
+const tamanhoMaximoDescricaoRevisao = 500
+
 // CriarPublicacao cria uma nova publicação no sistema
 // @Summary Criar uma nova publicação
-// @Description Cria uma nova publicação para o usuário autenticado
+// @Description Cria uma nova publicação para o usuário autenticado, que pode ser salva como rascunho ou agendada através de status e publicarEm.
+// @Description Para anexar imagens, envie como multipart/form-data com a publicação em JSON no campo "publicacao" e as imagens no campo "imagens".
+// @Description O título e o conteúdo passam pelos filtros da moderação, que podem recusar a publicação, mascarar trechos ou deixá-la
+// @Description escondida até a revisão de um moderador (emRevisao). Publicar rápido demais, repetir conteúdo ou encher a publicação de links
+// @Description pode fazer o antispam recusar a publicação (429) ou colocar a conta em quarentena (403)
 // @Tags publicacoes
-// @Accept  json
+// @Accept  json,mpfd
 // @Produce  json
 // @Param   publicacao body modelos.Publicacao true "Dados da publicação"
 // @Success 201 {object} modelos.Publicacao
 // @Failure 400 {object} respostas.Erro
 // @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 413 {object} respostas.Erro
+// @Failure 415 {object} respostas.Erro
 // @Failure 422 {object} respostas.Erro
+// @Failure 429 {object} respostas.Erro
 // @Failure 500 {object} respostas.Erro
 // @Security ApiKeyAuth
 // @Router /publicacoes [post]
@@ -40,16 +68,27 @@ func CriarPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
-	if erro != nil {
-		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
-		return
-	}
-
 	var publicacao modelos.Publicacao
-	if erro = json.Unmarshal(corpoRequisicao, &publicacao); erro != nil {
-		respostas.Erro(w, http.StatusBadRequest, erro)
-		return
+	var imagens []midias.Imagem
+
+	if ehMultipart(r) {
+		var statusCode int
+		publicacao, imagens, statusCode, erro = lerPublicacaoComImagens(w, r)
+		if erro != nil {
+			respostas.Erro(w, statusCode, erro)
+			return
+		}
+	} else {
+		corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+		if erro != nil {
+			respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+			return
+		}
+
+		if erro = json.Unmarshal(corpoRequisicao, &publicacao); erro != nil {
+			respostas.Erro(w, http.StatusBadRequest, erro)
+			return
+		}
 	}
 
 	publicacao.AutorID = usuarioID
@@ -65,22 +104,178 @@ func CriarPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !verificarSpam(w, r, repos, usuarioID, &publicacao) {
+		return
+	}
+
 	publicacao.ID, erro = repos.Publicacao.Criar(publicacao)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
+	publicacao.Trechos, erro = repos.Trecho.Substituir(publicacao.ID, publicacao.Trechos)
+	if erro != nil {
+		repos.Publicacao.Deletar(publicacao.ID)
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if publicacao.Enquete != nil {
+		enquete, erro := repos.Enquete.Criar(publicacao.ID, *publicacao.Enquete)
+		if erro != nil {
+			repos.Publicacao.Deletar(publicacao.ID)
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+		publicacao.Enquete = &enquete
+	}
+
+	publicacao.Anexos, erro = salvarAnexos(r.Context(), repos, publicacao.ID, imagens)
+	if erro != nil {
+		// A publicação vai para a lixeira para não ficar visível sem as imagens que o autor enviou
+		repos.Publicacao.Deletar(publicacao.ID)
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	metricas.RegistrarPublicacaoCriada(publicacao.Status)
+
+	if publicacao.EmRevisao {
+		reterParaRevisao(repos, publicacao)
+	} else if publicacao.Status == modelos.StatusPublicada {
+		distribuirPublicacao(repos, publicacao.ID)
+	}
+
+	if publicacao.ConteudoHTML, erro = markdown.Renderizar(publicacao.Conteudo); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
 	respostas.JSON(w, http.StatusCreated, publicacao)
 }
 
+// distribuirPublicacao coloca uma publicação que acabou de ser publicada nas linhas do tempo. Uma falha
+// aqui não desfaz a publicação, que só fica fora do feed dos seguidores até as linhas do tempo serem reconstruídas
+func distribuirPublicacao(repos *repositorios.Repositories, publicacaoID uint64) {
+	if erro := repos.LinhaDoTempo.Distribuir(publicacaoID, config.LimiteSeguidoresLinhaDoTempo); erro != nil {
+		repos.Logger.Error("linha do tempo: não foi possível distribuir a publicação", "publicacaoId", publicacaoID, "erro", erro)
+	}
+}
+
+// trechosEnviados indica se a edição traz trechos de código, no campo trechos (mesmo vazio, para removê-los)
+// ou em blocos ``` no conteúdo. Sem eles, a publicação mantém os trechos que já tem
+func trechosEnviados(corpoRequisicao []byte, publicacao modelos.Publicacao) bool {
+	return len(publicacao.Trechos) > 0 || campoEnviado(corpoRequisicao, "trechos")
+}
+
+// campoEnviado indica se o corpo da requisição traz o campo informado, mesmo que vazio ou nulo
+func campoEnviado(corpoRequisicao []byte, campo string) bool {
+	var campos map[string]json.RawMessage
+	if erro := json.Unmarshal(corpoRequisicao, &campos); erro != nil {
+		return false
+	}
+
+	_, enviado := campos[campo]
+	return enviado
+}
+
+// reterParaRevisao abre uma denúncia automática para que um moderador decida se libera a publicação que os
+// filtros de conteúdo esconderam. Uma falha aqui só é registrada no log, já que a publicação continua escondida
+func reterParaRevisao(repos *repositorios.Repositories, publicacao modelos.Publicacao) {
+	if _, erro := repos.Denuncia.Criar(modelos.Denuncia{
+		UsuarioID:    &publicacao.AutorID,
+		PublicacaoID: &publicacao.ID,
+		Motivo:       modelos.MotivoFiltroAutomatico,
+		Descricao:    resumirMotivos(publicacao.MotivosRevisao),
+	}); erro != nil {
+		repos.Logger.Error("filtros: não foi possível abrir a revisão da publicação", "publicacaoId", publicacao.ID, "erro", erro)
+	}
+}
+
+// resumirMotivos junta os motivos de uma denúncia automática, cortando o texto no tamanho aceito pela descrição
+func resumirMotivos(motivos []string) string {
+	descricao := []rune(strings.Join(motivos, ", "))
+	if len(descricao) > tamanhoMaximoDescricaoRevisao {
+		descricao = descricao[:tamanhoMaximoDescricaoRevisao]
+	}
+
+	return string(descricao)
+}
+
+// completarPublicacoes carrega os dados que ficam fora da tabela de publicações, como anexos, trechos de código,
+// enquetes e reações, e converte o conteúdo em HTML. O usuário informado é quem está vendo as publicações
+func completarPublicacoes(repos *repositorios.Repositories, usuarioID uint64, publicacoes []modelos.Publicacao) error {
+	IDs := make([]uint64, len(publicacoes))
+	for i, publicacao := range publicacoes {
+		IDs[i] = publicacao.ID
+	}
+
+	anexos, erro := repos.Anexo.BuscarPorPublicacoes(IDs)
+	if erro != nil {
+		return erro
+	}
+
+	trechos, erro := repos.Trecho.BuscarPorPublicacoes(IDs)
+	if erro != nil {
+		return erro
+	}
+
+	enquetes, erro := repos.Enquete.BuscarPorPublicacoes(IDs, usuarioID)
+	if erro != nil {
+		return erro
+	}
+
+	reacoes, minhasReacoes, erro := repos.Reacao.ContarPorPublicacoes(IDs, usuarioID)
+	if erro != nil {
+		return erro
+	}
+
+	for i := range publicacoes {
+		if publicacoes[i].ConteudoHTML, erro = markdown.Renderizar(publicacoes[i].Conteudo); erro != nil {
+			return erro
+		}
+
+		publicacoes[i].Trechos = trechos[publicacoes[i].ID]
+		publicacoes[i].Enquete = enquetes[publicacoes[i].ID]
+		publicacoes[i].Reacoes = reacoes[publicacoes[i].ID]
+		publicacoes[i].MinhaReacao = minhasReacoes[publicacoes[i].ID]
+		publicacoes[i].Anexos = anexos[publicacoes[i].ID]
+		for j := range publicacoes[i].Anexos {
+			preencherURLs(&publicacoes[i].Anexos[j])
+		}
+	}
+
+	return nil
+}
+
+// completarPublicacao faz o mesmo que completarPublicacoes para uma única publicação
+func completarPublicacao(repos *repositorios.Repositories, usuarioID uint64, publicacao *modelos.Publicacao) error {
+	if publicacao.ID == 0 {
+		return nil
+	}
+
+	publicacoes := []modelos.Publicacao{*publicacao}
+	if erro := completarPublicacoes(repos, usuarioID, publicacoes); erro != nil {
+		return erro
+	}
+
+	*publicacao = publicacoes[0]
+	return nil
+}
+
 // BuscarPublicacoes retorna as publicações que devem aparecer no feed do usuário
 // @Summary Buscar publicações
-// @Description Retorna as publicações que aparecem no feed do usuário autenticado
+// @Description Retorna as publicações que aparecem no feed do usuário autenticado. Por padrão o feed é
+// @Description cronológico; com modo=relevante as publicações recentes são ordenadas pela pontuação de relevância
 // @Tags publicacoes
 // @Accept  json
 // @Produce  json
+// @Param   modo query string false "cronologico ou relevante"
+// @Param   pagina query int false "Página (começa em 1)"
+// @Param   limite query int false "Itens por página"
 // @Success 200 {array} modelos.Publicacao
+// @Failure 400 {object} respostas.Erro
 // @Failure 401 {object} respostas.Erro
 // @Failure 500 {object} respostas.Erro
 // @Security ApiKeyAuth
@@ -98,15 +293,57 @@ func BuscarPublicacoes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	publicacoes, erro := repos.Publicacao.Buscar(usuarioID)
+	paginacao, erro := utils.ExtrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	var publicacoes []modelos.Publicacao
+	switch r.URL.Query().Get("modo") {
+	case "", modoCronologico:
+		publicacoes, erro = repos.LinhaDoTempo.Buscar(usuarioID, config.LimiteSeguidoresLinhaDoTempo, paginacao)
+	case modoRelevante:
+		publicacoes, erro = buscarFeedRelevante(repos, usuarioID, paginacao)
+	default:
+		respostas.Erro(w, http.StatusBadRequest, errors.New("O modo do feed deve ser cronologico ou relevante"))
+		return
+	}
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
+	if erro = completarPublicacoes(repos, usuarioID, publicacoes); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
 	respostas.JSON(w, http.StatusOK, publicacoes)
 }
 
+// buscarFeedRelevante pontua as publicações recentes do feed do usuário e retorna a página pedida
+func buscarFeedRelevante(repos *repositorios.Repositories, usuarioID uint64, paginacao modelos.Paginacao) ([]modelos.Publicacao, error) {
+	agora := time.Now()
+	candidatos, erro := repos.Publicacao.BuscarCandidatosRelevantes(
+		usuarioID,
+		agora.Add(-config.JanelaFeedRelevante),
+		config.MaximoCandidatosFeedRelevante,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+
+	relevancia.Ordenar(candidatos, relevancia.PesosDaConfiguracao(), agora)
+
+	publicacoes := []modelos.Publicacao{}
+	for i := paginacao.Deslocamento(); i < uint64(len(candidatos)) && i < paginacao.Deslocamento()+paginacao.Limite; i++ {
+		publicacoes = append(publicacoes, candidatos[i].Publicacao)
+	}
+
+	return publicacoes, nil
+}
+
 // BuscarPublicacao retorna uma única publicação
 // @Summary Buscar uma publicação específica
 // @Description Retorna os dados de uma publicação específica
@@ -120,6 +357,12 @@ func BuscarPublicacoes(w http.ResponseWriter, r *http.Request) {
 // @Security ApiKeyAuth
 // @Router /publicacoes/{publicacaoId} [get]
 func BuscarPublicacao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
 	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
 	if erro != nil {
 		respostas.Erro(w, http.StatusBadRequest, erro)
@@ -138,12 +381,17 @@ func BuscarPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if erro = completarPublicacao(repos, usuarioID, &publicacao); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
 	respostas.JSON(w, http.StatusOK, publicacao)
 }
 
 // AtualizarPublicacao altera os dados de uma publicação
 // @Summary Atualizar uma publicação
-// @Description Atualiza os dados de uma publicação específica
+// @Description Atualiza os dados de uma publicação específica. A enquete não pode ser alterada depois da publicação
 // @Tags publicacoes
 // @Accept  json
 // @Produce  json
@@ -186,6 +434,10 @@ func AtualizarPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !verificarQuarentena(w, repos, usuarioID) {
+		return
+	}
+
 	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
 	if erro != nil {
 		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
@@ -198,6 +450,11 @@ func AtualizarPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if publicacao.Enquete != nil {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("A enquete não pode ser alterada depois que a publicação é publicada"))
+		return
+	}
+
 	if erro = publicacao.Preparar(); erro != nil {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
@@ -208,6 +465,28 @@ func AtualizarPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if trechosEnviados(corpoRequisicao, publicacao) {
+		if _, erro = repos.Trecho.Substituir(publicacaoID, publicacao.Trechos); erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+	}
+
+	if publicacao.EmRevisao {
+		if erro = repos.Publicacao.Ocultar(publicacaoID); erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+
+		if erro = repos.LinhaDoTempo.RemoverPublicacao(publicacaoID); erro != nil {
+			repos.Logger.Error("filtros: não foi possível tirar a publicação das linhas do tempo", "publicacaoId", publicacaoID, "erro", erro)
+		}
+
+		publicacao.ID = publicacaoID
+		publicacao.AutorID = usuarioID
+		reterParaRevisao(repos, publicacao)
+	}
+
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -260,40 +539,67 @@ func DeletarPublicacao(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	auditar(r, repos, modelos.AuditoriaPublicacaoDeletada, usuarioID, usuarioID, dadosDaPublicacao(publicacaoSalvaNoBanco), nil)
+
+	if erro = repos.LinhaDoTempo.RemoverPublicacao(publicacaoID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
 
-// BuscarPublicacoesPorUsuario retorna todas as publicações de um usuário específico
+// BuscarPublicacoesPorUsuario retorna as publicações de um usuário específico
 // @Summary Buscar publicações de um usuário
-// @Description Retorna todas as publicações de um usuário específico
+// @Description Retorna as publicações de um usuário específico, das mais novas para as mais antigas.
+// @Description As publicações fixadas vêm primeiro, na primeira página, e não contam na paginação
 // @Tags publicacoes
 // @Accept  json
 // @Produce  json
 // @Param   usuarioId path int true "ID do Usuário"
+// @Param   pagina query int false "Página (começa em 1)"
+// @Param   limite query int false "Itens por página"
 // @Success 200 {array} modelos.Publicacao
 // @Failure 400 {object} respostas.Erro
 // @Failure 500 {object} respostas.Erro
 // @Security ApiKeyAuth
 // @Router /usuarios/{usuarioId}/publicacoes [get]
 func BuscarPublicacoesPorUsuario(w http.ResponseWriter, r *http.Request) {
+	usuarioIDNoToken, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
 	usuarioID, erro := strconv.ParseUint(mux.Vars(r)["usuarioId"], 10, 64)
 	if erro != nil {
 		respostas.Erro(w, http.StatusBadRequest, erro)
 		return
 	}
 
+	paginacao, erro := utils.ExtrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
 	repos, erro := utils.ExtrairRepositorios(r)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	publicacoes, erro := repos.Publicacao.BuscarPorUsuario(usuarioID)
+	publicacoes, erro := repos.Publicacao.BuscarPorUsuario(usuarioID, paginacao)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
+	if erro = completarPublicacoes(repos, usuarioIDNoToken, publicacoes); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
 	respostas.JSON(w, http.StatusOK, publicacoes)
 }
 
@@ -362,3 +668,604 @@ func DescurtirPublicacao(w http.ResponseWriter, r *http.Request) {
 
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
+
+// BuscarLixeira retorna as publicações deletadas pelo usuário que ainda podem ser restauradas
+// @Summary Buscar publicações na lixeira
+// @Description Retorna as publicações deletadas pelo usuário autenticado que ainda não foram removidas definitivamente
+// @Tags publicacoes
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} modelos.Publicacao
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/lixeira [get]
+func BuscarLixeira(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	publicacoes, erro := repos.Publicacao.BuscarLixeira(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = completarPublicacoes(repos, usuarioID, publicacoes); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, publicacoes)
+}
+
+// RestaurarPublicacao tira uma publicação da lixeira
+// @Summary Restaurar uma publicação
+// @Description Restaura uma publicação deletada pelo usuário autenticado
+// @Tags publicacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/restaurar [post]
+func RestaurarPublicacao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = repos.Publicacao.Restaurar(publicacaoID, usuarioID); erro != nil {
+		if errors.Is(erro, repositorios.ErrPublicacaoNaoEncontradaNaLixeira) {
+			respostas.Erro(w, http.StatusNotFound, erro)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	distribuirPublicacao(repos, publicacaoID)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarRascunhos retorna os rascunhos e as publicações agendadas do usuário
+// @Summary Buscar rascunhos
+// @Description Retorna os rascunhos e as publicações agendadas do usuário autenticado
+// @Tags publicacoes
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} modelos.Publicacao
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/rascunhos [get]
+func BuscarRascunhos(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	publicacoes, erro := repos.Publicacao.BuscarRascunhos(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = completarPublicacoes(repos, usuarioID, publicacoes); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, publicacoes)
+}
+
+// AtualizarRascunho altera um rascunho ou uma publicação agendada
+// @Summary Atualizar um rascunho
+// @Description Atualiza um rascunho ou publicação agendada. Enviar o status "publicada" publica imediatamente;
+// @Description sem status nem publicarEm, o rascunho mantém o status e a data que já tinha. Enviar o campo enquete
+// @Description troca a enquete, ou a remove se ele for nulo
+// @Tags publicacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Param   publicacao body modelos.Publicacao true "Novos dados do rascunho"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/rascunhos/{publicacaoId} [put]
+func AtualizarRascunho(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	rascunhoSalvoNoBanco, erro := repos.Publicacao.BuscarRascunhoPorID(publicacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if rascunhoSalvoNoBanco.AutorID != usuarioID {
+		respostas.Erro(w, http.StatusForbidden, errors.New(msgErroPublicacaoNaoAutorizada))
+		return
+	}
+
+	if !verificarQuarentena(w, repos, usuarioID) {
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var publicacao modelos.Publicacao
+	if erro = json.Unmarshal(corpoRequisicao, &publicacao); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	// Sem status nem data de publicação na requisição, o rascunho só é salvo e continua como estava
+	if publicacao.Status == "" && publicacao.PublicarEm == nil {
+		publicacao.Status = rascunhoSalvoNoBanco.Status
+		publicacao.PublicarEm = rascunhoSalvoNoBanco.PublicarEm
+	}
+
+	// Sem o campo enquete, a enquete salva continua e é validada de novo com a data de publicação
+	if !campoEnviado(corpoRequisicao, "enquete") {
+		enquetes, erro := repos.Enquete.BuscarPorPublicacoes([]uint64{publicacaoID}, usuarioID)
+		if erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+		publicacao.Enquete = enquetes[publicacaoID]
+	}
+
+	if erro = publicacao.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = repos.Publicacao.AtualizarRascunho(publicacaoID, publicacao); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if trechosEnviados(corpoRequisicao, publicacao) {
+		if _, erro = repos.Trecho.Substituir(publicacaoID, publicacao.Trechos); erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+	}
+
+	if campoEnviado(corpoRequisicao, "enquete") {
+		if erro = repos.Enquete.Substituir(publicacaoID, publicacao.Enquete); erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+	}
+
+	if publicacao.EmRevisao {
+		if erro = repos.Publicacao.Ocultar(publicacaoID); erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+
+		publicacao.ID = publicacaoID
+		publicacao.AutorID = usuarioID
+		reterParaRevisao(repos, publicacao)
+	} else if publicacao.Status == modelos.StatusPublicada {
+		distribuirPublicacao(repos, publicacaoID)
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// DeletarRascunho exclui um rascunho ou uma publicação agendada
+// @Summary Deletar um rascunho
+// @Description Move um rascunho ou publicação agendada para a lixeira
+// @Tags publicacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/rascunhos/{publicacaoId} [delete]
+func DeletarRascunho(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	rascunhoSalvoNoBanco, erro := repos.Publicacao.BuscarRascunhoPorID(publicacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if rascunhoSalvoNoBanco.AutorID != usuarioID {
+		respostas.Erro(w, http.StatusForbidden, errors.New(msgErroPublicacaoNaoAutorizada))
+		return
+	}
+
+	if erro = repos.Publicacao.Deletar(publicacaoID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	auditar(r, repos, modelos.AuditoriaPublicacaoDeletada, usuarioID, usuarioID, dadosDaPublicacao(rascunhoSalvoNoBanco), nil)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarTrechosDestacados retorna os trechos de código de uma publicação com o HTML do destaque de sintaxe
+// @Summary Buscar trechos de código destacados
+// @Description Retorna os trechos de código de uma publicação com o HTML já destacado, para que todos os clientes exibam o código da mesma forma
+// @Tags publicacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Success 200 {array} modelos.Trecho
+// @Failure 400 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/trechos [get]
+func BuscarTrechosDestacados(w http.ResponseWriter, r *http.Request) {
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	publicacao, erro := repos.Publicacao.BuscarPorID(publicacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if publicacao.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	trechos, erro := repos.Trecho.BuscarPorPublicacoes([]uint64{publicacaoID})
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	destacados := make([]modelos.Trecho, 0, len(trechos[publicacaoID]))
+	for _, trecho := range trechos[publicacaoID] {
+		if trecho.HTML, erro = destaque.HTML(trecho.Codigo, trecho.Linguagem); erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+
+		destacados = append(destacados, trecho)
+	}
+
+	respostas.JSON(w, http.StatusOK, destacados)
+}
+
+// VotarEnquete registra o voto do usuário na enquete de uma publicação
+// @Summary Votar em uma enquete
+// @Description Registra o voto do usuário autenticado nas opções escolhidas da enquete de uma publicação
+// @Tags publicacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Param   voto body modelos.Voto true "IDs das opções escolhidas"
+// @Success 200 {object} modelos.Enquete
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 409 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/votar [post]
+func VotarEnquete(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var voto modelos.Voto
+	if erro = json.Unmarshal(corpoRequisicao, &voto); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	enquete, erro := buscarEnqueteDaPublicacao(repos, publicacaoID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if enquete == nil {
+		respostas.Erro(w, http.StatusNotFound, errors.New("Esta publicação não tem enquete"))
+		return
+	}
+
+	if erro = enquete.ValidarVoto(voto); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = repos.Enquete.Votar(*enquete, usuarioID, voto.Opcoes); erro != nil {
+		if errors.Is(erro, repositorios.ErrVotoDuplicado) {
+			respostas.Erro(w, http.StatusConflict, erro)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if enquete, erro = buscarEnqueteDaPublicacao(repos, publicacaoID, usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, enquete)
+}
+
+// RemoverVotoEnquete remove o voto do usuário na enquete de uma publicação
+// @Summary Remover voto de uma enquete
+// @Description Remove todos os votos do usuário autenticado na enquete de uma publicação
+// @Tags publicacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/desvotar [post]
+func RemoverVotoEnquete(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	enquete, erro := buscarEnqueteDaPublicacao(repos, publicacaoID, usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if enquete == nil {
+		respostas.Erro(w, http.StatusNotFound, errors.New("Esta publicação não tem enquete"))
+		return
+	}
+
+	if enquete.Encerrada {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("Esta enquete já foi encerrada"))
+		return
+	}
+
+	if erro = repos.Enquete.RemoverVotos(enquete.ID, usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// buscarEnqueteDaPublicacao traz a enquete de uma publicação visível, ou nil se ela não existir
+func buscarEnqueteDaPublicacao(repos *repositorios.Repositories, publicacaoID, usuarioID uint64) (*modelos.Enquete, error) {
+	publicacao, erro := repos.Publicacao.BuscarPorID(publicacaoID)
+	if erro != nil || publicacao.ID == 0 {
+		return nil, erro
+	}
+
+	enquetes, erro := repos.Enquete.BuscarPorPublicacoes([]uint64{publicacaoID}, usuarioID)
+	if erro != nil {
+		return nil, erro
+	}
+
+	return enquetes[publicacaoID], nil
+}
+
+// FixarPublicacao fixa uma publicação no perfil do seu autor
+// @Summary Fixar uma publicação
+// @Description Fixa uma publicação do usuário autenticado no topo do seu perfil
+// @Tags publicacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 409 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/fixar [post]
+func FixarPublicacao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	publicacaoSalvaNoBanco, erro := repos.Publicacao.BuscarPorID(publicacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if publicacaoSalvaNoBanco.AutorID != usuarioID {
+		respostas.Erro(w, http.StatusForbidden, errors.New(msgErroPublicacaoNaoAutorizada))
+		return
+	}
+
+	if erro = repos.Publicacao.Fixar(publicacaoID, usuarioID); erro != nil {
+		if errors.Is(erro, repositorios.ErrLimiteDeFixadas) {
+			respostas.Erro(w, http.StatusConflict, erro)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// DesafixarPublicacao tira uma publicação do topo do perfil do seu autor
+// @Summary Desafixar uma publicação
+// @Description Tira uma publicação do usuário autenticado do topo do seu perfil
+// @Tags publicacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/desafixar [post]
+func DesafixarPublicacao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = repos.Publicacao.Desafixar(publicacaoID, usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
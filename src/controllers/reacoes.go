@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/respostas"
+	"api/src/utils"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ReagirPublicacao registra a reação do usuário a uma publicação
+// @Summary Reagir a uma publicação
+// @Description Registra a reação do usuário autenticado a uma publicação. Cada usuário tem uma única reação
+// @Description por publicação, então reagir de novo troca o tipo da reação
+// @Tags reacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Param   reacao body modelos.Reacao true "Tipo da reação"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 422 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/reacoes [post]
+func ReagirPublicacao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var reacao modelos.Reacao
+	if erro = json.Unmarshal(corpoRequisicao, &reacao); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = reacao.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	publicacao, erro := repos.Publicacao.BuscarPorID(publicacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if publicacao.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	if erro = repos.Reacao.Reagir(publicacaoID, usuarioID, reacao.Tipo); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// RemoverReacao remove a reação do usuário a uma publicação
+// @Summary Remover reação
+// @Description Remove a reação do usuário autenticado a uma publicação
+// @Tags reacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/reacoes [delete]
+func RemoverReacao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = repos.Reacao.RemoverReacao(publicacaoID, usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarReacoes retorna quem reagiu a uma publicação
+// @Summary Buscar reações de uma publicação
+// @Description Retorna, paginados, os usuários que reagiram a uma publicação, opcionalmente filtrando pelo tipo da reação
+// @Tags reacoes
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Param   tipo query string false "Tipo da reação"
+// @Param   pagina query int false "Página (começa em 1)"
+// @Param   limite query int false "Itens por página"
+// @Success 200 {array} modelos.Reacao
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/reacoes [get]
+func BuscarReacoes(w http.ResponseWriter, r *http.Request) {
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	paginacao, erro := utils.ExtrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	tipo := r.URL.Query().Get("tipo")
+	if tipo != "" {
+		if erro = modelos.ValidarTipoDeReacao(tipo); erro != nil {
+			respostas.Erro(w, http.StatusBadRequest, erro)
+			return
+		}
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	publicacao, erro := repos.Publicacao.BuscarPorID(publicacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if publicacao.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	reacoes, erro := repos.Reacao.BuscarPorPublicacao(publicacaoID, tipo, paginacao)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, reacoes)
+}
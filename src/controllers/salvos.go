@@ -0,0 +1,429 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/utils"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// mensagens de erro comuns
+	msgErroColecaoNaoAutorizada = "Não é possível realizar operações em uma coleção que não seja sua"
+)
+
+// SalvarPublicacao salva uma publicação para o usuário ver depois, opcionalmente em uma coleção
+// @Summary Salvar uma publicação
+// @Description Salva uma publicação de forma privada para o usuário autenticado. Se ela já estiver salva, só a coleção é alterada
+// @Tags salvos
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Param   salvo body modelos.Salvo false "Coleção em que a publicação será salva (colecaoId)"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/salvar [post]
+func SalvarPublicacao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var salvo modelos.Salvo
+	if len(corpoRequisicao) > 0 {
+		if erro = json.Unmarshal(corpoRequisicao, &salvo); erro != nil {
+			respostas.Erro(w, http.StatusBadRequest, erro)
+			return
+		}
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	publicacao, erro := repos.Publicacao.BuscarPorID(publicacaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if publicacao.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, errors.New("Publicação não encontrada"))
+		return
+	}
+
+	if salvo.ColecaoID != nil {
+		colecao, erro := repos.Salvo.BuscarColecaoPorID(*salvo.ColecaoID)
+		if erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+
+		if colecao.UsuarioID != usuarioID {
+			respostas.Erro(w, http.StatusForbidden, errors.New(msgErroColecaoNaoAutorizada))
+			return
+		}
+	}
+
+	if erro = repos.Salvo.Salvar(usuarioID, publicacaoID, salvo.ColecaoID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// RemoverPublicacaoSalva tira uma publicação das salvas do usuário
+// @Summary Remover uma publicação salva
+// @Description Remove uma publicação das salvas do usuário autenticado
+// @Tags salvos
+// @Accept  json
+// @Produce  json
+// @Param   publicacaoId path int true "ID da Publicação"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /publicacoes/{publicacaoId}/salvar [delete]
+func RemoverPublicacaoSalva(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	publicacaoID, erro := strconv.ParseUint(mux.Vars(r)["publicacaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = repos.Salvo.RemoverSalvo(usuarioID, publicacaoID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// BuscarSalvos retorna as publicações salvas pelo usuário
+// @Summary Buscar publicações salvas
+// @Description Retorna, paginadas, as publicações salvas pelo usuário autenticado que ainda estão visíveis
+// @Tags salvos
+// @Accept  json
+// @Produce  json
+// @Param   colecao query int false "ID da coleção"
+// @Param   pagina query int false "Página (começa em 1)"
+// @Param   limite query int false "Itens por página"
+// @Success 200 {array} modelos.Salvo
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /salvos [get]
+func BuscarSalvos(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	paginacao, erro := utils.ExtrairPaginacao(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	var colecaoID *uint64
+	if parametro := r.URL.Query().Get("colecao"); parametro != "" {
+		valor, erro := strconv.ParseUint(parametro, 10, 64)
+		if erro != nil {
+			respostas.Erro(w, http.StatusBadRequest, erro)
+			return
+		}
+		colecaoID = &valor
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	salvos, erro := repos.Salvo.BuscarSalvos(usuarioID, colecaoID, paginacao)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	publicacoes := make([]modelos.Publicacao, len(salvos))
+	for i, salvo := range salvos {
+		publicacoes[i] = salvo.Publicacao
+	}
+
+	if erro = completarPublicacoes(repos, usuarioID, publicacoes); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	for i := range salvos {
+		salvos[i].Publicacao = publicacoes[i]
+	}
+
+	respostas.JSON(w, http.StatusOK, salvos)
+}
+
+// CriarColecao cria uma coleção para organizar as publicações salvas
+// @Summary Criar uma coleção
+// @Description Cria uma coleção de publicações salvas para o usuário autenticado
+// @Tags salvos
+// @Accept  json
+// @Produce  json
+// @Param   colecao body modelos.Colecao true "Dados da coleção"
+// @Success 201 {object} modelos.Colecao
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 409 {object} respostas.Erro
+// @Failure 422 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /colecoes [post]
+func CriarColecao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var colecao modelos.Colecao
+	if erro = json.Unmarshal(corpoRequisicao, &colecao); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	colecao.UsuarioID = usuarioID
+
+	if erro = colecao.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	colecao.ID, erro = repos.Salvo.CriarColecao(colecao)
+	if erro != nil {
+		if errors.Is(erro, repositorios.ErrColecaoDuplicada) {
+			respostas.Erro(w, http.StatusConflict, erro)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusCreated, colecao)
+}
+
+// BuscarColecoes retorna as coleções do usuário
+// @Summary Buscar coleções
+// @Description Retorna as coleções de publicações salvas do usuário autenticado
+// @Tags salvos
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} modelos.Colecao
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /colecoes [get]
+func BuscarColecoes(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	colecoes, erro := repos.Salvo.BuscarColecoes(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, colecoes)
+}
+
+// AtualizarColecao renomeia uma coleção
+// @Summary Atualizar uma coleção
+// @Description Altera o nome de uma coleção do usuário autenticado
+// @Tags salvos
+// @Accept  json
+// @Produce  json
+// @Param   colecaoId path int true "ID da Coleção"
+// @Param   colecao body modelos.Colecao true "Novos dados da coleção"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 409 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /colecoes/{colecaoId} [put]
+func AtualizarColecao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	colecaoID, erro := strconv.ParseUint(mux.Vars(r)["colecaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	colecaoSalvaNoBanco, erro := repos.Salvo.BuscarColecaoPorID(colecaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if colecaoSalvaNoBanco.UsuarioID != usuarioID {
+		respostas.Erro(w, http.StatusForbidden, errors.New(msgErroColecaoNaoAutorizada))
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var colecao modelos.Colecao
+	if erro = json.Unmarshal(corpoRequisicao, &colecao); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = colecao.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = repos.Salvo.AtualizarColecao(colecaoID, colecao); erro != nil {
+		if errors.Is(erro, repositorios.ErrColecaoDuplicada) {
+			respostas.Erro(w, http.StatusConflict, erro)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// DeletarColecao exclui uma coleção, mantendo as publicações dela salvas
+// @Summary Deletar uma coleção
+// @Description Remove uma coleção do usuário autenticado. As publicações continuam salvas, sem coleção
+// @Tags salvos
+// @Accept  json
+// @Produce  json
+// @Param   colecaoId path int true "ID da Coleção"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /colecoes/{colecaoId} [delete]
+func DeletarColecao(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	colecaoID, erro := strconv.ParseUint(mux.Vars(r)["colecaoId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	colecaoSalvaNoBanco, erro := repos.Salvo.BuscarColecaoPorID(colecaoID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if colecaoSalvaNoBanco.UsuarioID != usuarioID {
+		respostas.Erro(w, http.StatusForbidden, errors.New(msgErroColecaoNaoAutorizada))
+		return
+	}
+
+	if erro = repos.Salvo.DeletarColecao(colecaoID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"api/src/banco"
+	"api/src/modelos"
+	"api/src/respostas"
+	"api/src/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// tempoLimiteProntidao é quanto o /readyz espera pelo banco antes de considerar que ele não está respondendo
+const tempoLimiteProntidao = 2 * time.Second
+
+// encerrando é marcado quando a API recebe o sinal para parar, para que o /readyz a tire do balanceamento
+// enquanto as requisições em andamento terminam
+var encerrando atomic.Bool
+
+// IniciarEncerramento faz o /readyz passar a responder que a API não está pronta
+func IniciarEncerramento() {
+	encerrando.Store(true)
+}
+
+// VerificarVida informa se a API está no ar
+// @Summary Verificar se a API está viva
+// @Description Responde sempre que o processo está atendendo requisições, sem consultar o banco. Serve para o
+// @Description orquestrador decidir se a API precisa ser reiniciada
+// @Tags saude
+// @Produce  json
+// @Success 200 {object} modelos.Saude
+// @Router /healthz [get]
+func VerificarVida(w http.ResponseWriter, r *http.Request) {
+	respostas.JSON(w, http.StatusOK, modelos.Saude{Status: modelos.SaudeOK})
+}
+
+// VerificarProntidao informa se a API pode receber requisições
+// @Summary Verificar se a API está pronta
+// @Description Verifica se o banco responde e se o esquema dele está na versão que a API espera. Durante o
+// @Description encerramento responde 503, para que a API saia do balanceamento antes de parar
+// @Tags saude
+// @Produce  json
+// @Success 200 {object} modelos.Saude
+// @Failure 500 {object} respostas.Erro
+// @Failure 503 {object} modelos.Saude
+// @Router /readyz [get]
+func VerificarProntidao(w http.ResponseWriter, r *http.Request) {
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	ctx, cancelar := context.WithTimeout(r.Context(), tempoLimiteProntidao)
+	defer cancelar()
+
+	verificacoes := map[string]string{"banco": modelos.SaudeOK, "esquema": modelos.SaudeOK}
+	if encerrando.Load() {
+		verificacoes["encerramento"] = "a API está sendo encerrada"
+	}
+
+	if erro = repos.Saude.Pingar(ctx); erro != nil {
+		repos.Logger.Warn("readyz: o banco não respondeu", "erro", erro)
+		verificacoes["banco"] = "o banco não respondeu"
+		verificacoes["esquema"] = "não verificado"
+	} else if versao, erro := repos.Saude.BuscarVersaoEsquema(ctx); erro != nil {
+		repos.Logger.Warn("readyz: não foi possível ler a versão do esquema", "erro", erro)
+		verificacoes["esquema"] = "não foi possível ler a versão do esquema"
+	} else if versao < banco.VersaoEsquema {
+		verificacoes["esquema"] = fmt.Sprintf("o banco está na versão %d e a API espera a %d", versao, banco.VersaoEsquema)
+	}
+
+	saude := modelos.Saude{Status: modelos.SaudeOK, Verificacoes: verificacoes}
+	for _, resultado := range verificacoes {
+		if resultado != modelos.SaudeOK {
+			saude.Status = modelos.SaudeIndisponivel
+			respostas.JSON(w, http.StatusServiceUnavailable, saude)
+			return
+		}
+	}
+
+	respostas.JSON(w, http.StatusOK, saude)
+}
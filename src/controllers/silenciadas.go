@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/config"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"api/src/respostas"
+	"api/src/utils"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// BuscarPalavrasSilenciadas retorna as palavras que o usuário autenticado silenciou
+// @Summary Listar palavras silenciadas
+// @Description Retorna as palavras silenciadas pelo usuário autenticado, em ordem alfabética
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} string
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /palavras-silenciadas [get]
+func BuscarPalavrasSilenciadas(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	palavras, erro := repos.Filtro.BuscarPalavrasSilenciadas(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, palavras)
+}
+
+// SilenciarPalavra esconde do feed do usuário autenticado as publicações que contêm a palavra
+// @Summary Silenciar uma palavra
+// @Description Esconde dos feeds cronológico e relevante do usuário autenticado as publicações de outros autores
+// @Description que têm a palavra no título ou no conteúdo, sem diferenciar maiúsculas
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param   palavra body modelos.PalavraSilenciada true "Palavra a silenciar"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 409 {object} respostas.Erro
+// @Failure 422 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /palavras-silenciadas [post]
+func SilenciarPalavra(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var silenciada modelos.PalavraSilenciada
+	if erro = json.Unmarshal(corpoRequisicao, &silenciada); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = silenciada.Preparar(); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = repos.Filtro.SilenciarPalavra(usuarioID, silenciada.Palavra, config.MaximoPalavrasSilenciadas); erro != nil {
+		if errors.Is(erro, repositorios.ErrPalavrasSilenciadasDemais) {
+			respostas.Erro(w, http.StatusConflict, erro)
+			return
+		}
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// RemoverPalavraSilenciada volta a mostrar no feed do usuário autenticado as publicações com a palavra
+// @Summary Deixar de silenciar uma palavra
+// @Description Remove a palavra da lista de palavras silenciadas do usuário autenticado
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param   palavra path string true "Palavra silenciada"
+// @Success 204 "No Content"
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /palavras-silenciadas/{palavra} [delete]
+func RemoverPalavraSilenciada(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = repos.Filtro.RemoverPalavraSilenciada(usuarioID, mux.Vars(r)["palavra"]); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/config"
+	"api/src/respostas"
+	"api/src/utils"
+	"net/http"
+	"time"
+)
+
+// BuscarSugestoes retorna usuários que o usuário autenticado talvez queira seguir
+// @Summary Sugestões de quem seguir
+// @Description Retorna usuários seguidos por quem o usuário autenticado segue, dos que têm mais conexões em comum
+// @Description para os que têm menos, completando com os usuários mais seguidos. As sugestões ficam guardadas e são
+// @Description recalculadas periodicamente
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} modelos.Sugestao
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /usuarios/sugestoes [get]
+func BuscarSugestoes(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	sugestoes, calculadasEm, erro := repos.Sugestao.BuscarEmCache(usuarioID)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if time.Since(calculadasEm) > config.ValidadeSugestoes {
+		if sugestoes, erro = repos.Sugestao.Recalcular(usuarioID, config.QuantidadeSugestoes); erro != nil {
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+			return
+		}
+	}
+
+	respostas.JSON(w, http.StatusOK, sugestoes)
+}
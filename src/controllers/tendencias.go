@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"api/src/autenticacao"
+	"api/src/modelos"
+	"api/src/respostas"
+	"api/src/utils"
+	"errors"
+	"net/http"
+)
+
+// janelaPadraoTendencias é a janela de tempo usada quando nenhuma é informada
+const janelaPadraoTendencias = "24h"
+
+// BuscarTendencias retorna as publicações e hashtags em alta
+// @Summary Buscar tendências
+// @Description Retorna as publicações e hashtags com o engajamento que mais cresceu na janela de tempo informada.
+// @Description As tendências são calculadas periodicamente, então podem estar alguns minutos atrasadas
+// @Tags tendencias
+// @Accept  json
+// @Produce  json
+// @Param   janela query string false "Janela de tempo: 1h, 24h (padrão) ou 7d"
+// @Success 200 {object} modelos.Tendencias
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /tendencias [get]
+func BuscarTendencias(w http.ResponseWriter, r *http.Request) {
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	janela := r.URL.Query().Get("janela")
+	if janela == "" {
+		janela = janelaPadraoTendencias
+	}
+
+	if _, ok := modelos.JanelasTendencias[janela]; !ok {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("A janela deve ser 1h, 24h ou 7d"))
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	tendencias, erro := repos.Tendencia.Buscar(janela)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = completarPublicacoes(repos, usuarioID, tendencias.Publicacoes); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, tendencias)
+}
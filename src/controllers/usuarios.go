@@ -2,7 +2,10 @@ package controllers
 
 import (
 	"api/src/autenticacao"
+	"api/src/config"
+	"api/src/metricas"
 	"api/src/modelos"
+	"api/src/repositorios"
 	"api/src/respostas"
 	"api/src/seguranca"
 	"api/src/utils"
@@ -10,12 +13,17 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
+// quantidadeSeguidoresEmComum é quantos seguidores em comum aparecem no perfil de um usuário
+const quantidadeSeguidoresEmComum = 3
+
 // CriarUsuario cria um novo usuário no sistema
 // @Summary Criar um novo usuário
 // @Description Cria um novo usuário no sistema
@@ -52,12 +60,17 @@ func CriarUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	usuario.ID, erro = repos.Usuario.Criar(usuario)
+	usuario.ID, erro = repos.Usuario.Criar(usuario, config.ReservaNick)
 	if erro != nil {
+		if errors.Is(erro, repositorios.ErrNickReservado) {
+			respostas.Erro(w, http.StatusConflict, erro)
+			return
+		}
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
+	metricas.RegistrarUsuarioCriado()
 	respostas.JSON(w, http.StatusCreated, usuario)
 }
 
@@ -92,14 +105,17 @@ func BuscarUsuarios(w http.ResponseWriter, r *http.Request) {
 
 // BuscarUsuario busca os dados detalhados de um usuário específico
 // @Summary Buscar um usuário específico
-// @Description Retorna os dados de um usuário específico
+// @Description Retorna o perfil de um usuário específico, com as contagens de seguidores, seguindo e publicações,
+// @Description se o usuário autenticado o segue ou é seguido por ele e alguns dos seguidores que os dois têm em comum
 // @Tags usuarios
 // @Accept  json
 // @Produce  json
 // @Param   usuarioId path int true "ID do Usuário"
-// @Success 200 {object} modelos.Usuario
+// @Success 200 {object} modelos.Perfil
 // @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
 // @Failure 404 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
 // @Security ApiKeyAuth
 // @Router /usuarios/{usuarioId} [get]
 func BuscarUsuario(w http.ResponseWriter, r *http.Request) {
@@ -111,19 +127,85 @@ func BuscarUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	visitanteID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	perfil, erro := repos.Usuario.BuscarPerfil(usuarioID, visitanteID, quantidadeSeguidoresEmComum)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if perfil.ID == 0 {
+		respostas.Erro(w, http.StatusNotFound, errors.New("Usuário não encontrado"))
+		return
+	}
+
+	respostas.JSON(w, http.StatusOK, perfil)
+}
+
+// BuscarUsuarioPorNick busca o perfil de um usuário pelo nick
+// @Summary Buscar um usuário pelo nick
+// @Description Retorna o perfil do usuário que usa o nick informado. Se o nick pertenceu a um usuário que
+// @Description o trocou, a resposta redireciona para o nick atual dele
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param   nick path string true "Nick do Usuário"
+// @Success 200 {object} modelos.Perfil
+// @Success 302 "Redireciona para o nick atual"
+// @Failure 401 {object} respostas.Erro
+// @Failure 404 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
+// @Router /usuarios/nick/{nick} [get]
+func BuscarUsuarioPorNick(w http.ResponseWriter, r *http.Request) {
+	nick := mux.Vars(r)["nick"]
+
+	visitanteID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
 	repos, erro := utils.ExtrairRepositorios(r)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	usuario, erro := repos.Usuario.BuscarPorID(usuarioID)
+	usuarioID, nickAtual, erro := repos.Usuario.BuscarPorNick(nick)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if usuarioID == 0 {
+		respostas.Erro(w, http.StatusNotFound, errors.New("Usuário não encontrado"))
+		return
+	}
+
+	if !strings.EqualFold(nickAtual, nick) {
+		http.Redirect(w, r, "/usuarios/nick/"+url.PathEscape(nickAtual), http.StatusFound)
+		return
+	}
+
+	perfil, erro := repos.Usuario.BuscarPerfil(usuarioID, visitanteID, quantidadeSeguidoresEmComum)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	respostas.JSON(w, http.StatusOK, usuario)
+	respostas.JSON(w, http.StatusOK, perfil)
 }
 
 // AtualizarUsuario altera as informações de um usuário no banco de dados
@@ -183,17 +265,32 @@ func AtualizarUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if erro = repos.Usuario.Atualizar(usuarioID, usuario); erro != nil {
+	usuarioAntes, erro := repos.Usuario.BuscarPorID(usuarioID)
+	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
+	if erro = repos.Usuario.Atualizar(usuarioID, usuario, config.IntervaloTrocaNick, config.ReservaNick); erro != nil {
+		switch {
+		case errors.Is(erro, repositorios.ErrNickReservado):
+			respostas.Erro(w, http.StatusConflict, erro)
+		case errors.Is(erro, repositorios.ErrTrocaDeNickMuitoRecente):
+			respostas.Erro(w, http.StatusTooManyRequests, erro)
+		default:
+			respostas.Erro(w, http.StatusInternalServerError, erro)
+		}
+		return
+	}
+
+	auditar(r, repos, modelos.AuditoriaUsuarioAtualizado, usuarioID, usuarioID, dadosDaConta(usuarioAntes), dadosDaConta(usuario))
+
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
 
-// DeletarUsuario exclui as informações de um usuário no banco de dados
-// @Summary Deletar um usuário
-// @Description Remove um usuário do sistema
+// DesativarUsuario esconde a conta do usuário, que volta a ficar visível quando ele entrar de novo
+// @Summary Desativar um usuário
+// @Description Esconde o perfil e as publicações do usuário. A conta é reativada no próximo login
 // @Tags usuarios
 // @Accept  json
 // @Produce  json
@@ -204,6 +301,58 @@ func AtualizarUsuario(w http.ResponseWriter, r *http.Request) {
 // @Failure 403 {object} respostas.Erro
 // @Failure 500 {object} respostas.Erro
 // @Security ApiKeyAuth
+// @Router /usuarios/{usuarioId}/desativar [post]
+func DesativarUsuario(w http.ResponseWriter, r *http.Request) {
+	parametros := mux.Vars(r)
+	usuarioID, erro := strconv.ParseUint(parametros["usuarioId"], 10, 64)
+	if erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	usuarioIDNoToken, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, erro)
+		return
+	}
+
+	if usuarioID != usuarioIDNoToken {
+		respostas.Erro(w, http.StatusForbidden, errors.New("Não é possível desativar um usuário que não seja o seu"))
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = repos.Usuario.Desativar(usuarioID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	auditar(r, repos, modelos.AuditoriaContaDesativada, usuarioID, usuarioID, nil, nil)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// DeletarUsuario agenda a exclusão definitiva da conta do usuário
+// @Summary Deletar um usuário
+// @Description Desativa a conta e agenda a sua exclusão definitiva para o fim do prazo de carência. Até lá, a
+// @Description exclusão pode ser cancelada em POST /usuarios/cancelar-exclusao. A senha atual é exigida como confirmação
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param   usuarioId path int true "ID do Usuário"
+// @Param   confirmacao body modelos.ConfirmacaoSenha true "Senha atual do usuário"
+// @Success 202 {object} modelos.Usuario
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 422 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Security ApiKeyAuth
 // @Router /usuarios/{usuarioId} [delete]
 func DeletarUsuario(w http.ResponseWriter, r *http.Request) {
 	parametros := mux.Vars(r)
@@ -224,23 +373,50 @@ func DeletarUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var confirmacao modelos.ConfirmacaoSenha
+	if erro = json.Unmarshal(corpoRequisicao, &confirmacao); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
 	repos, erro := utils.ExtrairRepositorios(r)
 	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	if erro = repos.Usuario.Deletar(usuarioID); erro != nil {
+	senhaSalvaNoBanco, erro := repos.Usuario.BuscarSenha(usuarioID)
+	if erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
-	respostas.JSON(w, http.StatusNoContent, nil)
+	if erro = seguranca.VerificarSenha(senhaSalvaNoBanco, confirmacao.Senha); erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, errors.New("A senha não condiz com a que está salva no banco"))
+		return
+	}
+
+	exclusaoEm, erro := repos.Usuario.AgendarExclusao(usuarioID, time.Now().Add(config.PrazoExclusaoConta))
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	auditar(r, repos, modelos.AuditoriaExclusaoAgendada, usuarioID, usuarioID, nil, map[string]time.Time{"exclusaoAgendadaEm": exclusaoEm})
+
+	respostas.JSON(w, http.StatusAccepted, modelos.Usuario{ID: usuarioID, ExclusaoAgendadaEm: &exclusaoEm})
 }
 
 // SeguirUsuario permite que um usuário siga outro
 // @Summary Seguir um usuário
-// @Description Faz o usuário autenticado seguir outro usuário
+// @Description Faz o usuário autenticado seguir outro usuário. Seguir muitas contas em pouco tempo pode fazer o antispam
+// @Description recusar a ação (429) ou colocar a conta em quarentena (403)
 // @Tags usuarios
 // @Accept  json
 // @Produce  json
@@ -248,6 +424,8 @@ func DeletarUsuario(w http.ResponseWriter, r *http.Request) {
 // @Success 204 "No Content"
 // @Failure 400 {object} respostas.Erro
 // @Failure 401 {object} respostas.Erro
+// @Failure 403 {object} respostas.Erro
+// @Failure 429 {object} respostas.Erro
 // @Failure 500 {object} respostas.Erro
 // @Security ApiKeyAuth
 // @Router /usuarios/{usuarioId}/seguir [post]
@@ -276,11 +454,21 @@ func SeguirUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !verificarSpam(w, r, repos, seguidorID, nil) {
+		return
+	}
+
 	if erro = repos.Usuario.Seguir(usuarioID, seguidorID); erro != nil {
 		respostas.Erro(w, http.StatusInternalServerError, erro)
 		return
 	}
 
+	// O seguidor já está gravado; sem o preenchimento, as publicações antigas do autor só aparecem no feed
+	// quando as linhas do tempo forem reconstruídas
+	if erro = repos.LinhaDoTempo.Preencher(seguidorID, usuarioID, config.PreenchimentoLinhaDoTempo); erro != nil {
+		repos.Logger.Error("linha do tempo: não foi possível preencher com as publicações do autor seguido", "usuarioId", seguidorID, "autorId", usuarioID, "erro", erro)
+	}
+
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -327,6 +515,12 @@ func PararDeSeguirUsuario(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// O seguidor já foi removido; sem isso, as publicações do autor saem do feed quando as linhas do tempo
+	// forem reconstruídas
+	if erro = repos.LinhaDoTempo.RemoverAutor(seguidorID, usuarioID); erro != nil {
+		repos.Logger.Error("linha do tempo: não foi possível remover as publicações do autor deixado de seguir", "usuarioId", seguidorID, "autorId", usuarioID, "erro", erro)
+	}
+
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -467,5 +661,70 @@ func AtualizarSenha(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	auditar(r, repos, modelos.AuditoriaSenhaAlterada, usuarioID, usuarioID, nil, nil)
+
+	respostas.JSON(w, http.StatusNoContent, nil)
+}
+
+// CancelarExclusaoUsuario desiste da exclusão agendada de uma conta, a partir das suas credenciais
+// @Summary Cancelar a exclusão de um usuário
+// @Description Cancela a exclusão de uma conta que ainda está no prazo de carência e a reativa
+// @Tags usuarios
+// @Accept  json
+// @Produce  json
+// @Param   credentials body modelos.Usuario true "Credenciais do usuário"
+// @Success 204 "No Content"
+// @Failure 400 {object} respostas.Erro
+// @Failure 401 {object} respostas.Erro
+// @Failure 422 {object} respostas.Erro
+// @Failure 500 {object} respostas.Erro
+// @Router /usuarios/cancelar-exclusao [post]
+func CancelarExclusaoUsuario(w http.ResponseWriter, r *http.Request) {
+	corpoRequisicao, erro := ioutil.ReadAll(r.Body)
+	if erro != nil {
+		respostas.Erro(w, http.StatusUnprocessableEntity, erro)
+		return
+	}
+
+	var usuario modelos.Usuario
+	if erro = json.Unmarshal(corpoRequisicao, &usuario); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	if erro = validarCredenciais(&usuario); erro != nil {
+		respostas.Erro(w, http.StatusBadRequest, erro)
+		return
+	}
+
+	repos, erro := utils.ExtrairRepositorios(r)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	usuarioSalvoNoBanco, erro := repos.Usuario.BuscarPorEmail(usuario.Email)
+	if erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	if erro = seguranca.VerificarSenha(usuarioSalvoNoBanco.Senha, usuario.Senha); erro != nil {
+		respostas.Erro(w, http.StatusUnauthorized, ErrCredenciaisInvalidas)
+		return
+	}
+
+	if usuarioSalvoNoBanco.ExclusaoAgendadaEm == nil {
+		respostas.Erro(w, http.StatusBadRequest, errors.New("Esta conta não está agendada para exclusão"))
+		return
+	}
+
+	if erro = repos.Usuario.Reativar(usuarioSalvoNoBanco.ID); erro != nil {
+		respostas.Erro(w, http.StatusInternalServerError, erro)
+		return
+	}
+
+	auditar(r, repos, modelos.AuditoriaExclusaoCancelada, usuarioSalvoNoBanco.ID, usuarioSalvoNoBanco.ID, nil, nil)
+
 	respostas.JSON(w, http.StatusNoContent, nil)
 }
@@ -0,0 +1,59 @@
+package destaque
+
+import (
+	"api/src/config"
+	"errors"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// ErrLinguagemNaoSuportada é retornado quando não existe destaque de sintaxe para a linguagem informada
+var ErrLinguagemNaoSuportada = errors.New("linguagem não suportada para destaque de sintaxe")
+
+// NormalizarLinguagem retorna o nome canônico da linguagem de um trecho de código. Quando a linguagem
+// não é informada, ela é deduzida pelo nome do arquivo e, se mesmo assim não for encontrada, o trecho é
+// tratado como texto puro
+func NormalizarLinguagem(linguagem, nomeArquivo string) (string, error) {
+	var lexer chroma.Lexer
+
+	switch {
+	case linguagem != "":
+		if lexer = lexers.Get(linguagem); lexer == nil {
+			return "", ErrLinguagemNaoSuportada
+		}
+	case nomeArquivo != "":
+		lexer = lexers.Match(nomeArquivo)
+	}
+
+	if lexer == nil {
+		return "plaintext", nil
+	}
+
+	return strings.ToLower(lexer.Config().Name), nil
+}
+
+// HTML gera o HTML com o código destacado. Os estilos vão embutidos nos elementos para que todos os
+// clientes exibam o código da mesma forma sem precisar de uma folha de estilos
+func HTML(codigo, linguagem string) (string, error) {
+	lexer := lexers.Get(linguagem)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	iterador, erro := chroma.Coalesce(lexer).Tokenise(nil, codigo)
+	if erro != nil {
+		return "", erro
+	}
+
+	var resultado strings.Builder
+	formatador := html.New(html.WithClasses(false), html.TabWidth(4))
+	if erro = formatador.Format(&resultado, styles.Get(config.EstiloDestaque), iterador); erro != nil {
+		return "", erro
+	}
+
+	return resultado.String(), nil
+}
@@ -0,0 +1,125 @@
+package exportacao
+
+import (
+	"api/src/armazenamento"
+	"api/src/logs"
+	"api/src/repositorios"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+)
+
+// Gerar reúne os dados pessoais de um usuário e escreve no destino um arquivo ZIP com um JSON para cada tipo
+// de informação e, na pasta midias, as imagens que ele anexou às suas publicações. O arquivo é escrito à
+// medida que é montado, então as imagens nunca ficam todas na memória
+func Gerar(ctx context.Context, repos *repositorios.Repositories, usuarioID uint64, destino io.Writer) error {
+	dados, erro := repos.Exportacao.BuscarDadosPessoais(usuarioID)
+	if erro != nil {
+		return erro
+	}
+
+	IDs := make([]uint64, len(dados.Publicacoes))
+	for i, publicacao := range dados.Publicacoes {
+		IDs[i] = publicacao.ID
+	}
+
+	anexos, erro := repos.Anexo.BuscarPorPublicacoes(IDs)
+	if erro != nil {
+		return erro
+	}
+
+	trechos, erro := repos.Trecho.BuscarPorPublicacoes(IDs)
+	if erro != nil {
+		return erro
+	}
+
+	enquetes, erro := repos.Enquete.BuscarPorPublicacoes(IDs, usuarioID)
+	if erro != nil {
+		return erro
+	}
+
+	var chaves []string
+	for i := range dados.Publicacoes {
+		dados.Publicacoes[i].Trechos = trechos[dados.Publicacoes[i].ID]
+		dados.Publicacoes[i].Enquete = enquetes[dados.Publicacoes[i].ID]
+		dados.Publicacoes[i].Anexos = anexos[dados.Publicacoes[i].ID]
+		for j, anexo := range dados.Publicacoes[i].Anexos {
+			// Dentro do arquivo a URL aponta para a cópia da imagem que vai na pasta midias
+			dados.Publicacoes[i].Anexos[j].URL = path.Join("midias", anexo.Chave)
+			chaves = append(chaves, anexo.Chave)
+		}
+	}
+
+	arquivo := zip.NewWriter(destino)
+
+	documentos := []struct {
+		nome     string
+		conteudo interface{}
+	}{
+		{"perfil.json", dados.Perfil},
+		{"nicks_anteriores.json", dados.NicksAnteriores},
+		{"publicacoes.json", dados.Publicacoes},
+		{"reacoes.json", dados.Reacoes},
+		{"colecoes.json", dados.Colecoes},
+		{"salvos.json", dados.Salvos},
+		{"votos.json", dados.Votos},
+		{"seguidores.json", dados.Seguidores},
+		{"seguindo.json", dados.Seguindo},
+		{"palavras_silenciadas.json", dados.Silenciadas},
+	}
+
+	for _, documento := range documentos {
+		if erro = escreverJSON(arquivo, documento.nome, documento.conteudo); erro != nil {
+			return erro
+		}
+	}
+
+	for _, chave := range chaves {
+		if erro = ctx.Err(); erro != nil {
+			return erro
+		}
+
+		if erro = copiarMidia(ctx, arquivo, chave); erro != nil {
+			return erro
+		}
+	}
+
+	return arquivo.Close()
+}
+
+// escreverJSON adiciona ao arquivo um documento JSON indentado
+func escreverJSON(arquivo *zip.Writer, nome string, conteudo interface{}) error {
+	destino, erro := arquivo.Create(nome)
+	if erro != nil {
+		return erro
+	}
+
+	codificador := json.NewEncoder(destino)
+	codificador.SetIndent("", "  ")
+	return codificador.Encode(conteudo)
+}
+
+// copiarMidia adiciona ao arquivo uma imagem guardada no armazenamento. Imagens que já não existem
+// mais são ignoradas, para que um arquivo perdido não impeça o usuário de receber o resto dos dados
+func copiarMidia(ctx context.Context, arquivo *zip.Writer, chave string) error {
+	origem, erro := armazenamento.Padrao.Abrir(ctx, chave)
+	if errors.Is(erro, armazenamento.ErrArquivoNaoEncontrado) {
+		logs.DoContexto(ctx).Warn("exportação: imagem não encontrada", "chave", chave)
+		return nil
+	}
+	if erro != nil {
+		return erro
+	}
+	defer origem.Close()
+
+	destino, erro := arquivo.Create(path.Join("midias", chave))
+	if erro != nil {
+		return erro
+	}
+
+	_, erro = io.Copy(destino, origem)
+	return erro
+}
@@ -0,0 +1,83 @@
+// Package logs configura o log estruturado da API e guarda no contexto o logger de cada requisição ou tarefa,
+// para que tudo o que for registrado durante ela saia com os mesmos atributos, como o ID da requisição
+package logs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+const (
+	// FormatoJSON escreve cada linha do log como um objeto JSON
+	FormatoJSON = "json"
+
+	// FormatoTexto escreve cada linha do log como pares chave=valor
+	FormatoTexto = "texto"
+)
+
+// chaveContexto é a chave usada para guardar o logger no contexto
+type chaveContexto struct{}
+
+// estado guarda o logger no contexto. Ele é compartilhado por ponteiro para que os atributos acrescentados
+// por um middleware mais interno também apareçam para quem colocou o logger no contexto
+type estado struct {
+	mutex  sync.Mutex
+	logger *slog.Logger
+}
+
+// Configurar define o logger padrão com o nível e o formato informados. As mensagens escritas pelo pacote log
+// também passam por ele
+func Configurar(nivel, formato string) error {
+	var nivelLog slog.Level
+	if erro := nivelLog.UnmarshalText([]byte(nivel)); erro != nil {
+		return fmt.Errorf("nível de log inválido %q: use debug, info, warn ou error", nivel)
+	}
+
+	opcoes := &slog.HandlerOptions{Level: nivelLog}
+
+	var handler slog.Handler
+	switch formato {
+	case FormatoJSON:
+		handler = slog.NewJSONHandler(os.Stdout, opcoes)
+	case FormatoTexto:
+		handler = slog.NewTextHandler(os.Stdout, opcoes)
+	default:
+		return fmt.Errorf("formato de log inválido %q: use %s ou %s", formato, FormatoJSON, FormatoTexto)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// NoContexto retorna uma cópia do contexto com o logger informado
+func NoContexto(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, chaveContexto{}, &estado{logger: logger})
+}
+
+// DoContexto retorna o logger guardado no contexto ou, se não houver nenhum, o logger padrão
+func DoContexto(ctx context.Context) *slog.Logger {
+	atual, ok := ctx.Value(chaveContexto{}).(*estado)
+	if !ok {
+		return slog.Default()
+	}
+
+	atual.mutex.Lock()
+	defer atual.mutex.Unlock()
+	return atual.logger
+}
+
+// Acrescentar adiciona atributos ao logger guardado no contexto. A mudança vale para todos que compartilham
+// o contexto, inclusive os que o criaram antes da chamada
+func Acrescentar(ctx context.Context, atributos ...interface{}) {
+	atual, ok := ctx.Value(chaveContexto{}).(*estado)
+	if !ok {
+		return
+	}
+
+	atual.mutex.Lock()
+	defer atual.mutex.Unlock()
+	atual.logger = atual.logger.With(atributos...)
+}
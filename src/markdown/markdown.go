@@ -0,0 +1,103 @@
+package markdown
+
+import (
+	"api/src/config"
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	// RecursoLinks habilita links, tanto no formato [texto](url) quanto endereços soltos no texto
+	RecursoLinks = "links"
+
+	// RecursoListas habilita listas numeradas e não numeradas
+	RecursoListas = "listas"
+
+	// RecursoCodigo habilita código em linha entre crases
+	RecursoCodigo = "codigo"
+
+	// RecursoEnfase habilita itálico e negrito
+	RecursoEnfase = "enfase"
+)
+
+var (
+	inicializar sync.Once
+	conversor   goldmark.Markdown
+	sanitizador *bluemonday.Policy
+)
+
+// Renderizar converte o subconjunto de Markdown habilitado em HTML. O resultado ainda passa por um
+// sanitizador baseado em lista de permissões, então nenhum elemento ou atributo fora do subconjunto
+// chega aos clientes, mesmo que o conversor deixe passar algo
+func Renderizar(texto string) (string, error) {
+	inicializar.Do(configurar)
+
+	var resultado bytes.Buffer
+	if erro := conversor.Convert([]byte(texto), &resultado); erro != nil {
+		return "", erro
+	}
+
+	return strings.TrimSpace(sanitizador.Sanitize(resultado.String())), nil
+}
+
+// configurar monta o conversor e o sanitizador com os recursos definidos em config.RecursosMarkdown
+func configurar() {
+	recursos := make(map[string]bool)
+	for _, recurso := range config.RecursosMarkdown {
+		recursos[strings.TrimSpace(recurso)] = true
+	}
+
+	blocos := []util.PrioritizedValue{util.Prioritized(parser.NewParagraphParser(), 1000)}
+	var inlines []util.PrioritizedValue
+
+	sanitizador = bluemonday.NewPolicy()
+	sanitizador.AllowElements("p", "br")
+
+	if recursos[RecursoListas] {
+		blocos = append(blocos,
+			util.Prioritized(parser.NewListParser(), 300),
+			util.Prioritized(parser.NewListItemParser(), 400),
+		)
+		sanitizador.AllowElements("ul", "ol", "li")
+		sanitizador.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	}
+
+	if recursos[RecursoCodigo] {
+		inlines = append(inlines, util.Prioritized(parser.NewCodeSpanParser(), 100))
+		sanitizador.AllowElements("code")
+	}
+
+	if recursos[RecursoLinks] {
+		inlines = append(inlines,
+			util.Prioritized(parser.NewLinkParser(), 200),
+			util.Prioritized(parser.NewAutoLinkParser(), 300),
+			util.Prioritized(extension.NewLinkifyParser(), 999),
+		)
+		sanitizador.AllowAttrs("href").OnElements("a")
+		sanitizador.AllowURLSchemes("http", "https", "mailto")
+		sanitizador.RequireParseableURLs(true)
+		sanitizador.RequireNoFollowOnLinks(true)
+		sanitizador.AddTargetBlankToFullyQualifiedLinks(true)
+	}
+
+	if recursos[RecursoEnfase] {
+		inlines = append(inlines, util.Prioritized(parser.NewEmphasisParser(), 500))
+		sanitizador.AllowElements("em", "strong")
+	}
+
+	conversor = goldmark.New(
+		goldmark.WithParser(parser.NewParser(
+			parser.WithBlockParsers(blocos...),
+			parser.WithInlineParsers(inlines...),
+		)),
+		goldmark.WithRendererOptions(html.WithHardWraps()),
+	)
+}
@@ -0,0 +1,113 @@
+// Package metricas expõe as métricas da API no formato do Prometheus: as requisições HTTP por rota, o pool de
+// conexões com o banco e contadores do domínio, como publicações criadas e logins
+package metricas
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace é o prefixo de todas as métricas da API
+const namespace = "devbook"
+
+var (
+	requisicoes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requisicoes_total",
+		Help:      "Requisições HTTP atendidas, por rota, método e classe de status.",
+	}, []string{"rota", "metodo", "classe"})
+
+	duracaoRequisicoes = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_duracao_requisicoes_segundos",
+		Help:      "Duração das requisições HTTP, por rota e método.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"rota", "metodo"})
+
+	requisicoesEmAndamento = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requisicoes_em_andamento",
+		Help:      "Requisições HTTP sendo atendidas neste momento.",
+	})
+
+	publicacoesCriadas = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "publicacoes_criadas_total",
+		Help:      "Publicações criadas, pelo status com que foram criadas.",
+	}, []string{"status"})
+
+	usuariosCriados = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "usuarios_criados_total",
+		Help:      "Contas criadas.",
+	})
+
+	logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Tentativas de login, pelo resultado.",
+	}, []string{"resultado"})
+)
+
+// RegistrarBanco passa a expor as estatísticas do pool de conexões do banco
+func RegistrarBanco(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// IniciarRequisicao conta uma requisição em andamento e retorna a função que a encerra, registrando o status
+// e a duração. A rota é o modelo da URI, e não o caminho pedido, para que cada rota gere uma série só
+func IniciarRequisicao(rota, metodo string) func(status int) {
+	inicio := time.Now()
+	requisicoesEmAndamento.Inc()
+
+	return func(status int) {
+		requisicoesEmAndamento.Dec()
+		requisicoes.WithLabelValues(rota, metodo, strconv.Itoa(status/100)+"xx").Inc()
+		duracaoRequisicoes.WithLabelValues(rota, metodo).Observe(time.Since(inicio).Seconds())
+	}
+}
+
+// RegistrarPublicacaoCriada conta uma publicação criada
+func RegistrarPublicacaoCriada(status string) {
+	publicacoesCriadas.WithLabelValues(status).Inc()
+}
+
+// RegistrarUsuarioCriado conta uma conta criada
+func RegistrarUsuarioCriado() {
+	usuariosCriados.Inc()
+}
+
+// RegistrarLogin conta uma tentativa de login, bem-sucedida ou não
+func RegistrarLogin(sucesso bool) {
+	resultado := "falha"
+	if sucesso {
+		resultado = "sucesso"
+	}
+	logins.WithLabelValues(resultado).Inc()
+}
+
+// Handler retorna o handler que expõe as métricas. Com um token definido, ele precisa vir no cabeçalho
+// Authorization como Bearer
+func Handler(token string) http.Handler {
+	metricas := promhttp.Handler()
+	if token == "" {
+		return metricas
+	}
+
+	esperado := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), esperado) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		metricas.ServeHTTP(w, r)
+	})
+}
@@ -1,31 +1,31 @@
 package middlewares
 
 import (
-	"api/src/banco"
+	"api/src/logs"
+	"api/src/rastreamento"
 	"api/src/repositorios"
 	"context"
+	"database/sql"
 	"net/http"
 )
 
 // ChaveRepositorios é a chave que será usada para armazenar os repositórios no contexto
 const ChaveRepositorios = "repositories"
 
-// InjetarDependencias é um middleware que injeta as dependências necessárias no contexto da requisição
-func InjetarDependencias(next http.HandlerFunc) http.HandlerFunc {
+// InjetarDependencias é um middleware que injeta as dependências necessárias no contexto da requisição.
+// Todas as requisições usam o mesmo pool de conexões com o banco
+func InjetarDependencias(db *sql.DB, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		db, erro := banco.Conectar()
-		if erro != nil {
-			http.Error(w, "Erro ao conectar ao banco de dados", http.StatusInternalServerError)
-			return
-		}
-		defer db.Close()
+		_, span := rastreamento.IniciarFilho(r.Context(), "middlewares.InjetarDependencias")
 
 		// Cria os repositórios
-		repos := repositorios.NovoRepositories(db)
+		repos := repositorios.NovoRepositories(r.Context(), db)
+		repos.Logger = logs.DoContexto(r.Context())
 
 		// Adiciona os repositórios no contexto da requisição
 		ctx := context.WithValue(r.Context(), ChaveRepositorios, repos)
 		r = r.WithContext(ctx)
+		span.End()
 
 		next(w, r)
 	}
@@ -2,26 +2,196 @@ package middlewares
 
 import (
 	"api/src/autenticacao"
+	"api/src/logs"
+	"api/src/metricas"
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"api/src/repositorios"
 	"api/src/respostas"
-	"log"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log/slog"
 	"net/http"
+	"regexp"
+	"strconv"
+	"time"
 )
 
-// Logger escreve informações da requisição no terminal
+// CabecalhoRequestID é o cabeçalho que identifica a requisição nos logs, recebido do cliente ou gerado pela API
+const CabecalhoRequestID = "X-Request-ID"
+
+// requestIDValido limita os IDs recebidos do cliente, para que um valor qualquer não vá parar nos logs
+var requestIDValido = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// respostaRegistrada guarda o status e a quantidade de bytes escritos na resposta, para o log da requisição
+type respostaRegistrada struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (resposta *respostaRegistrada) WriteHeader(status int) {
+	if resposta.status == 0 {
+		resposta.status = status
+	}
+	resposta.ResponseWriter.WriteHeader(status)
+}
+
+func (resposta *respostaRegistrada) Write(dados []byte) (int, error) {
+	if resposta.status == 0 {
+		resposta.status = http.StatusOK
+	}
+
+	escritos, erro := resposta.ResponseWriter.Write(dados)
+	resposta.bytes += escritos
+	return escritos, erro
+}
+
+// statusFinal retorna o status enviado, que é 200 quando o handler escreveu sem chamar WriteHeader ou não escreveu nada
+func (resposta *respostaRegistrada) statusFinal() int {
+	if resposta.status == 0 {
+		return http.StatusOK
+	}
+	return resposta.status
+}
+
+// Unwrap permite que o http.ResponseController chegue ao ResponseWriter original
+func (resposta *respostaRegistrada) Unwrap() http.ResponseWriter {
+	return resposta.ResponseWriter
+}
+
+// Rastrear abre o span da requisição, continuando o trace de quem a fez quando ela chega com um traceparent.
+// Os spans dos middlewares, dos repositórios e das consultas ao banco ficam todos dentro dele
+func Rastrear(rota string, proximaFuncao http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r, encerrar := rastreamento.IniciarRequisicao(r, rota)
+		resposta := &respostaRegistrada{ResponseWriter: w}
+
+		proximaFuncao(resposta, r)
+
+		encerrar(resposta.statusFinal())
+	}
+}
+
+// Logger registra cada requisição com o status, o tamanho da resposta e a duração. A requisição é identificada
+// pelo X-Request-ID, que volta na resposta, e o logger com esse ID fica no contexto para os demais middlewares,
+// os controllers e os repositórios. Quando a requisição é rastreada, o ID do trace também vai para o log
 func Logger(proximaFuncao http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("\n%s %s %s", r.Method, r.RequestURI, r.Host)
-		proximaFuncao(w, r)
+		inicio := time.Now()
+		_, span := rastreamento.IniciarFilho(r.Context(), "middlewares.Logger")
+
+		requestID := r.Header.Get(CabecalhoRequestID)
+		if !requestIDValido.MatchString(requestID) {
+			requestID = gerarRequestID()
+		}
+		w.Header().Set(CabecalhoRequestID, requestID)
+
+		logger := slog.Default().With("requestId", requestID)
+		if traceID := rastreamento.TraceID(r.Context()); traceID != "" {
+			logger = logger.With("traceId", traceID)
+		}
+
+		ctx := logs.NoContexto(r.Context(), logger)
+		resposta := &respostaRegistrada{ResponseWriter: w}
+		span.End()
+
+		proximaFuncao(resposta, r.WithContext(ctx))
+
+		status := resposta.statusFinal()
+
+		nivel := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			nivel = slog.LevelError
+		}
+
+		// Só o caminho vai para o log: a query string pode levar credenciais, como a assinatura dos links
+		// de download das exportações
+		logs.DoContexto(ctx).LogAttrs(ctx, nivel, "requisição",
+			slog.String("metodo", r.Method),
+			slog.String("caminho", r.URL.Path),
+			slog.String("host", r.Host),
+			slog.String("remoto", r.RemoteAddr),
+			slog.Int("status", status),
+			slog.Int("bytes", resposta.bytes),
+			slog.Float64("duracaoMs", float64(time.Since(inicio).Microseconds())/1000),
+		)
 	}
 }
 
-// Autenticar verifica se o usuário fazendo a requisição está autenticado
-func Autenticar(proximaFuncao http.HandlerFunc) http.HandlerFunc {
+// Medir registra as métricas da requisição. A rota é o modelo da URI, como /usuarios/{usuarioId}
+func Medir(rota string, proximaFuncao http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if erro := autenticacao.ValidarToken(r); erro != nil {
-			respostas.Erro(w, http.StatusUnauthorized, erro)
+		_, span := rastreamento.IniciarFilho(r.Context(), "middlewares.Medir")
+		encerrar := metricas.IniciarRequisicao(rota, r.Method)
+		resposta := &respostaRegistrada{ResponseWriter: w}
+		span.End()
+
+		proximaFuncao(resposta, r)
+
+		encerrar(resposta.statusFinal())
+	}
+}
+
+// Autenticar verifica se o usuário fazendo a requisição está autenticado e se a conta dele ainda pode ser usada.
+// A conta é consultada a cada requisição, para que uma suspensão, desativação ou exclusão agendada valha na hora,
+// sem esperar o token expirar
+func Autenticar(db *sql.DB, proximaFuncao http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := rastreamento.IniciarFilho(r.Context(), "middlewares.Autenticar")
+		status, erro := autenticar(ctx, db, r)
+		rastreamento.Encerrar(span, erro)
+
+		if erro != nil {
+			respostas.Erro(w, status, erro)
 			return
 		}
+
 		proximaFuncao(w, r)
 	}
 }
+
+// autenticar valida o token e a situação da conta, retornando o status da resposta quando a requisição
+// deve ser recusada
+func autenticar(ctx context.Context, db *sql.DB, r *http.Request) (int, error) {
+	if erro := autenticacao.ValidarToken(r); erro != nil {
+		return http.StatusUnauthorized, erro
+	}
+
+	usuarioID, erro := autenticacao.ExtrairUsuarioID(r)
+	if erro != nil {
+		return http.StatusUnauthorized, erro
+	}
+	logs.Acrescentar(r.Context(), "usuarioId", usuarioID)
+
+	usuario, erro := repositorios.NovoRepositorioDeUsuarios(ctx, db).BuscarSituacao(usuarioID)
+	if errors.Is(erro, repositorios.ErrUsuarioNaoEncontrado) {
+		return http.StatusUnauthorized, erro
+	}
+	if erro != nil {
+		return http.StatusInternalServerError, erro
+	}
+
+	if erro = usuario.Bloqueio(time.Now()); erro != nil {
+		return http.StatusForbidden, erro
+	}
+
+	if usuario.DeletadoEm != nil {
+		return http.StatusUnauthorized, modelos.ErrContaDesativada
+	}
+
+	return 0, nil
+}
+
+// gerarRequestID cria um ID aleatório para as requisições que chegam sem um X-Request-ID válido
+func gerarRequestID() string {
+	bytes := make([]byte, 16)
+	if _, erro := rand.Read(bytes); erro != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return hex.EncodeToString(bytes)
+}
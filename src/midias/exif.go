@@ -0,0 +1,121 @@
+package midias
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// tagOrientacao é o identificador da tag EXIF que indica como a foto deve ser girada para exibição
+const tagOrientacao = 0x0112
+
+// lerOrientacao procura a orientação EXIF de um JPEG, retornando 1 (sem transformação)
+// quando ela não existe ou o arquivo não pode ser interpretado
+func lerOrientacao(dados []byte) int {
+	if len(dados) < 4 || dados[0] != 0xFF || dados[1] != 0xD8 {
+		return 1
+	}
+
+	posicao := 2
+	for posicao+4 <= len(dados) {
+		if dados[posicao] != 0xFF {
+			return 1
+		}
+
+		marcador := dados[posicao+1]
+		tamanho := int(binary.BigEndian.Uint16(dados[posicao+2:]))
+		if marcador == 0xDA || tamanho < 2 || posicao+2+tamanho > len(dados) {
+			return 1
+		}
+
+		segmento := dados[posicao+4 : posicao+2+tamanho]
+		if marcador == 0xE1 && len(segmento) > 6 && string(segmento[:6]) == "Exif\x00\x00" {
+			return lerOrientacaoTIFF(segmento[6:])
+		}
+
+		posicao += 2 + tamanho
+	}
+
+	return 1
+}
+
+// lerOrientacaoTIFF lê a tag de orientação do primeiro diretório de um bloco TIFF
+func lerOrientacaoTIFF(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var ordem binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		ordem = binary.LittleEndian
+	case "MM":
+		ordem = binary.BigEndian
+	default:
+		return 1
+	}
+
+	diretorio := int(ordem.Uint32(tiff[4:]))
+	if diretorio+2 > len(tiff) {
+		return 1
+	}
+
+	entradas := int(ordem.Uint16(tiff[diretorio:]))
+	for i := 0; i < entradas; i++ {
+		entrada := diretorio + 2 + i*12
+		if entrada+12 > len(tiff) {
+			return 1
+		}
+
+		if ordem.Uint16(tiff[entrada:]) == tagOrientacao {
+			orientacao := int(ordem.Uint16(tiff[entrada+8:]))
+			if orientacao < 1 || orientacao > 8 {
+				return 1
+			}
+			return orientacao
+		}
+	}
+
+	return 1
+}
+
+// orientar aplica na imagem a rotação ou espelhamento indicado pela orientação EXIF
+func orientar(imagem image.Image, orientacao int) image.Image {
+	if orientacao <= 1 || orientacao > 8 {
+		return imagem
+	}
+
+	limites := imagem.Bounds()
+	largura, altura := limites.Dx(), limites.Dy()
+
+	// Orientações de 5 a 8 giram a imagem em 90 graus, trocando largura e altura
+	transpor := orientacao >= 5
+	destino := image.NewRGBA(image.Rect(0, 0, largura, altura))
+	if transpor {
+		destino = image.NewRGBA(image.Rect(0, 0, altura, largura))
+	}
+
+	for y := 0; y < altura; y++ {
+		for x := 0; x < largura; x++ {
+			var dx, dy int
+			switch orientacao {
+			case 2:
+				dx, dy = largura-1-x, y
+			case 3:
+				dx, dy = largura-1-x, altura-1-y
+			case 4:
+				dx, dy = x, altura-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = altura-1-y, x
+			case 7:
+				dx, dy = altura-1-y, largura-1-x
+			case 8:
+				dx, dy = y, largura-1-x
+			}
+			destino.Set(dx, dy, imagem.At(limites.Min.X+x, limites.Min.Y+y))
+		}
+	}
+
+	return destino
+}
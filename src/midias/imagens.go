@@ -0,0 +1,121 @@
+package midias
+
+import (
+	"api/src/config"
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+// dimensaoMaxima limita largura e altura das imagens aceitas, evitando que imagens pequenas
+// em bytes ocupem memória demais ao serem decodificadas
+const dimensaoMaxima = 8000
+
+var (
+	// ErrImagemMuitoGrande é retornado quando a imagem passa do tamanho máximo configurado
+	ErrImagemMuitoGrande = errors.New("a imagem é maior do que o tamanho permitido")
+
+	// ErrTipoNaoSuportado é retornado quando o arquivo enviado não é uma imagem JPEG ou PNG
+	ErrTipoNaoSuportado = errors.New("apenas imagens JPEG e PNG são aceitas")
+
+	// ErrImagemInvalida é retornado quando a imagem não pode ser decodificada
+	ErrImagemInvalida = errors.New("a imagem enviada está corrompida ou tem dimensões grandes demais")
+)
+
+// Imagem é uma imagem enviada por um usuário, já sem metadados e pronta para ser guardada
+type Imagem struct {
+	Tipo      string
+	Extensao  string
+	Conteudo  []byte
+	Miniatura []byte
+	Largura   int
+	Altura    int
+}
+
+// Processar valida uma imagem enviada, descobrindo seu tipo pelo conteúdo e não pelo nome do arquivo,
+// e a recodifica sem os metadados EXIF, gerando também a sua miniatura
+func Processar(dados []byte) (Imagem, error) {
+	if int64(len(dados)) > config.TamanhoMaximoImagem {
+		return Imagem{}, ErrImagemMuitoGrande
+	}
+
+	tipo := http.DetectContentType(dados)
+	if tipo != "image/jpeg" && tipo != "image/png" {
+		return Imagem{}, ErrTipoNaoSuportado
+	}
+
+	configuracao, _, erro := image.DecodeConfig(bytes.NewReader(dados))
+	if erro != nil || configuracao.Width > dimensaoMaxima || configuracao.Height > dimensaoMaxima {
+		return Imagem{}, ErrImagemInvalida
+	}
+
+	original, _, erro := image.Decode(bytes.NewReader(dados))
+	if erro != nil {
+		return Imagem{}, ErrImagemInvalida
+	}
+
+	// Os metadados são descartados ao recodificar, então a orientação precisa ser aplicada nos pixels
+	if tipo == "image/jpeg" {
+		original = orientar(original, lerOrientacao(dados))
+	}
+
+	imagem := Imagem{
+		Tipo:     tipo,
+		Largura:  original.Bounds().Dx(),
+		Altura:   original.Bounds().Dy(),
+		Extensao: ".png",
+	}
+	if tipo == "image/jpeg" {
+		imagem.Extensao = ".jpg"
+	}
+
+	if imagem.Conteudo, erro = codificar(original, tipo); erro != nil {
+		return Imagem{}, erro
+	}
+
+	if imagem.Miniatura, erro = codificar(reduzir(original, config.LarguraMiniatura), tipo); erro != nil {
+		return Imagem{}, erro
+	}
+
+	return imagem, nil
+}
+
+// codificar gera os bytes da imagem no formato informado
+func codificar(imagem image.Image, tipo string) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	var erro error
+	if tipo == "image/jpeg" {
+		erro = jpeg.Encode(&buffer, imagem, &jpeg.Options{Quality: 85})
+	} else {
+		erro = png.Encode(&buffer, imagem)
+	}
+	if erro != nil {
+		return nil, erro
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// reduzir redimensiona a imagem para a largura informada, mantendo a proporção.
+// Imagens que já são menores são devolvidas sem alteração
+func reduzir(imagem image.Image, largura int) image.Image {
+	limites := imagem.Bounds()
+	if largura <= 0 || limites.Dx() <= largura {
+		return imagem
+	}
+
+	altura := limites.Dy() * largura / limites.Dx()
+	if altura < 1 {
+		altura = 1
+	}
+
+	reduzida := image.NewRGBA(image.Rect(0, 0, largura, altura))
+	draw.CatmullRom.Scale(reduzida, reduzida.Bounds(), imagem, limites, draw.Over, nil)
+	return reduzida
+}
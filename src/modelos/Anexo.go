@@ -0,0 +1,18 @@
+package modelos
+
+import "time"
+
+// Anexo representa uma imagem enviada junto com uma publicação
+type Anexo struct {
+	ID             uint64    `json:"id,omitempty"`
+	PublicacaoID   uint64    `json:"publicacaoId,omitempty"`
+	Chave          string    `json:"-"`
+	ChaveMiniatura string    `json:"-"`
+	Tipo           string    `json:"tipo,omitempty"`
+	Tamanho        uint64    `json:"tamanho,omitempty"`
+	Largura        int       `json:"largura,omitempty"`
+	Altura         int       `json:"altura,omitempty"`
+	URL            string    `json:"url,omitempty"`
+	URLMiniatura   string    `json:"urlMiniatura,omitempty"`
+	CriadoEm       time.Time `json:"criadoEm,omitempty"`
+}
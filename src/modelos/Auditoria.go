@@ -0,0 +1,81 @@
+package modelos
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// AuditoriaLoginSucesso registra uma entrada bem-sucedida na conta
+	AuditoriaLoginSucesso = "login_sucesso"
+
+	// AuditoriaLoginFalha registra uma tentativa de entrada recusada, seja pela senha ou pela situação da conta
+	AuditoriaLoginFalha = "login_falha"
+
+	// AuditoriaUsuarioAtualizado registra a troca do nome, do nick ou do e-mail
+	AuditoriaUsuarioAtualizado = "usuario_atualizado"
+
+	// AuditoriaSenhaAlterada registra a troca de senha. Nenhuma das senhas é guardada
+	AuditoriaSenhaAlterada = "senha_alterada"
+
+	// AuditoriaContaDesativada registra a desativação da conta pelo próprio usuário
+	AuditoriaContaDesativada = "conta_desativada"
+
+	// AuditoriaExclusaoAgendada registra o pedido de exclusão definitiva da conta
+	AuditoriaExclusaoAgendada = "exclusao_agendada"
+
+	// AuditoriaExclusaoCancelada registra a desistência da exclusão dentro do prazo de carência
+	AuditoriaExclusaoCancelada = "exclusao_cancelada"
+
+	// AuditoriaContaExcluida registra a remoção definitiva de uma conta ao fim do prazo de carência
+	AuditoriaContaExcluida = "conta_excluida"
+
+	// AuditoriaPublicacaoDeletada registra a ida de uma publicação ou de um rascunho para a lixeira
+	AuditoriaPublicacaoDeletada = "publicacao_deletada"
+
+	// AuditoriaPapelAlterado registra a concessão ou a retirada do papel de moderador
+	AuditoriaPapelAlterado = "papel_alterado"
+
+	// AuditoriaDenunciaAtribuida registra que um moderador assumiu uma denúncia
+	AuditoriaDenunciaAtribuida = "denuncia_atribuida"
+
+	// AuditoriaDenunciaResolvida registra a decisão de um moderador sobre uma denúncia
+	AuditoriaDenunciaResolvida = "denuncia_resolvida"
+
+	// AuditoriaFiltroCriado registra a criação de uma regra de filtro de conteúdo
+	AuditoriaFiltroCriado = "filtro_criado"
+
+	// AuditoriaFiltroRemovido registra a remoção de uma regra de filtro de conteúdo
+	AuditoriaFiltroRemovido = "filtro_removido"
+
+	// AuditoriaQuarentena registra que o antispam colocou a conta em quarentena
+	AuditoriaQuarentena = "quarentena"
+)
+
+// RegistroAuditoria representa uma ação relevante para a segurança, com quem a fez, de onde e o que mudou
+type RegistroAuditoria struct {
+	ID        uint64          `json:"id"`
+	Acao      string          `json:"acao"`
+	AtorID    *uint64         `json:"atorId,omitempty"`
+	AtorNick  string          `json:"atorNick,omitempty"`
+	UsuarioID *uint64         `json:"usuarioId,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	UserAgent string          `json:"userAgent,omitempty"`
+	Antes     json.RawMessage `json:"antes,omitempty"`
+	Depois    json.RawMessage `json:"depois,omitempty"`
+	CriadoEm  time.Time       `json:"criadoEm"`
+}
+
+// FiltroAuditoria restringe a busca na auditoria. Campos vazios não filtram
+type FiltroAuditoria struct {
+	Acao      string
+	AtorID    uint64
+	UsuarioID uint64
+	Desde     *time.Time
+	Ate       *time.Time
+}
+
+// Papel é o corpo da requisição que concede ou retira o papel de moderador de um usuário
+type Papel struct {
+	Moderador bool `json:"moderador"`
+}
@@ -0,0 +1,43 @@
+package modelos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tamanhoMaximoNomeColecao é a quantidade máxima de caracteres do nome de uma coleção
+const tamanhoMaximoNomeColecao = 50
+
+// Colecao representa um agrupamento de publicações salvas criado por um usuário
+type Colecao struct {
+	ID        uint64    `json:"id,omitempty"`
+	Nome      string    `json:"nome,omitempty"`
+	UsuarioID uint64    `json:"usuarioId,omitempty"`
+	Salvos    uint64    `json:"salvos"`
+	CriadaEm  time.Time `json:"criadaEm,omitempty"`
+}
+
+// Salvo representa uma publicação que um usuário salvou para ver depois
+type Salvo struct {
+	Publicacao Publicacao `json:"publicacao"`
+	ColecaoID  *uint64    `json:"colecaoId,omitempty"`
+	SalvoEm    time.Time  `json:"salvoEm"`
+}
+
+// Preparar vai chamar os métodos para validar e formatar a coleção recebida
+func (colecao *Colecao) Preparar() error {
+	colecao.Nome = strings.TrimSpace(colecao.Nome)
+
+	if colecao.Nome == "" {
+		return errors.New("O nome da coleção é obrigatório e não pode estar em branco")
+	}
+
+	if utf8.RuneCountInString(colecao.Nome) > tamanhoMaximoNomeColecao {
+		return fmt.Errorf("O nome da coleção pode ter no máximo %d caracteres", tamanhoMaximoNomeColecao)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package modelos
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tamanhoMaximoDescricaoDenuncia é a quantidade máxima de caracteres da descrição de uma denúncia
+const tamanhoMaximoDescricaoDenuncia = 500
+
+// maximoDiasSuspensao é a suspensão mais longa que um moderador pode aplicar. O limite também mantém a data
+// final dentro do que a coluna timestamp consegue guardar
+const maximoDiasSuspensao = 5 * 365
+
+// MotivosDenuncia são as categorias aceitas ao denunciar uma publicação ou um usuário
+var MotivosDenuncia = map[string]string{
+	"spam":               "Spam ou propaganda",
+	"assedio":            "Assédio ou intimidação",
+	"discurso_de_odio":   "Discurso de ódio",
+	"conteudo_improprio": "Conteúdo impróprio",
+	"informacao_falsa":   "Informação falsa",
+	"outro":              "Outro motivo",
+}
+
+// MotivoFiltroAutomatico é o motivo das denúncias abertas pelos filtros de conteúdo ao reter uma publicação
+const MotivoFiltroAutomatico = "filtro_automatico"
+
+// MotivoAntispam é o motivo das denúncias abertas pelo antispam ao colocar uma conta em quarentena
+const MotivoAntispam = "antispam"
+
+const (
+	// StatusDenunciaAberta indica uma denúncia que ainda não foi pega por nenhum moderador
+	StatusDenunciaAberta = "aberta"
+
+	// StatusDenunciaEmAnalise indica uma denúncia atribuída a um moderador e ainda não resolvida
+	StatusDenunciaEmAnalise = "em_analise"
+
+	// StatusDenunciaResolvida indica uma denúncia que já recebeu uma decisão
+	StatusDenunciaResolvida = "resolvida"
+)
+
+const (
+	// AcaoAtribuir registra no histórico que um moderador assumiu a denúncia
+	AcaoAtribuir = "atribuir"
+
+	// AcaoDescartar encerra a denúncia sem nenhuma medida
+	AcaoDescartar = "descartar"
+
+	// AcaoOcultar esconde a publicação denunciada
+	AcaoOcultar = "ocultar"
+
+	// AcaoLiberar volta a mostrar a publicação denunciada, escondida pela moderação ou retida pelos filtros.
+	// Em denúncias de usuários, tira a conta da quarentena do antispam
+	AcaoLiberar = "liberar"
+
+	// AcaoAdvertir registra uma advertência para o autor do conteúdo denunciado
+	AcaoAdvertir = "advertir"
+
+	// AcaoSuspender impede o autor do conteúdo denunciado de entrar na conta por alguns dias
+	AcaoSuspender = "suspender"
+)
+
+// Denuncia representa uma denúncia feita por um usuário contra uma publicação ou outro usuário
+type Denuncia struct {
+	ID            uint64     `json:"id,omitempty"`
+	DenuncianteID *uint64    `json:"denuncianteId,omitempty"`
+	UsuarioID     *uint64    `json:"usuarioId,omitempty"`
+	PublicacaoID  *uint64    `json:"publicacaoId,omitempty"`
+	Motivo        string     `json:"motivo"`
+	Descricao     string     `json:"descricao,omitempty"`
+	Status        string     `json:"status,omitempty"`
+	ModeradorID   *uint64    `json:"moderadorId,omitempty"`
+	Acao          string     `json:"acao,omitempty"`
+	CriadaEm      time.Time  `json:"criadaEm,omitempty"`
+	ResolvidaEm   *time.Time `json:"resolvidaEm,omitempty"`
+}
+
+// Resolucao representa a decisão de um moderador sobre uma denúncia
+type Resolucao struct {
+	Acao          string `json:"acao"`
+	Observacao    string `json:"observacao,omitempty"`
+	DiasSuspensao int    `json:"diasSuspensao,omitempty"`
+}
+
+// DecisaoModeracao representa uma entrada do histórico de uma denúncia
+type DecisaoModeracao struct {
+	ID            uint64    `json:"id"`
+	DenunciaID    uint64    `json:"denunciaId"`
+	ModeradorID   *uint64   `json:"moderadorId,omitempty"`
+	ModeradorNick string    `json:"moderadorNick,omitempty"`
+	Acao          string    `json:"acao"`
+	Observacao    string    `json:"observacao,omitempty"`
+	CriadaEm      time.Time `json:"criadaEm"`
+}
+
+// Advertencia representa uma advertência dada a um usuário pela moderação
+type Advertencia struct {
+	ID         uint64    `json:"id"`
+	DenunciaID *uint64   `json:"denunciaId,omitempty"`
+	Motivo     string    `json:"motivo"`
+	Observacao string    `json:"observacao,omitempty"`
+	CriadaEm   time.Time `json:"criadaEm"`
+}
+
+// Preparar vai validar e formatar a denúncia recebida
+func (denuncia *Denuncia) Preparar() error {
+	denuncia.Motivo = strings.TrimSpace(denuncia.Motivo)
+	denuncia.Descricao = strings.TrimSpace(denuncia.Descricao)
+
+	if _, existe := MotivosDenuncia[denuncia.Motivo]; !existe {
+		motivos := make([]string, 0, len(MotivosDenuncia))
+		for motivo := range MotivosDenuncia {
+			motivos = append(motivos, motivo)
+		}
+		sort.Strings(motivos)
+		return errors.New("Motivo inválido. Use um destes: " + strings.Join(motivos, ", "))
+	}
+
+	if denuncia.Motivo == "outro" && denuncia.Descricao == "" {
+		return errors.New("Descreva o problema quando o motivo for outro")
+	}
+
+	if utf8.RuneCountInString(denuncia.Descricao) > tamanhoMaximoDescricaoDenuncia {
+		return fmt.Errorf("A descrição pode ter no máximo %d caracteres", tamanhoMaximoDescricaoDenuncia)
+	}
+
+	return nil
+}
+
+// Preparar vai validar e formatar a resolução recebida
+func (resolucao *Resolucao) Preparar() error {
+	resolucao.Acao = strings.TrimSpace(resolucao.Acao)
+	resolucao.Observacao = strings.TrimSpace(resolucao.Observacao)
+
+	switch resolucao.Acao {
+	case AcaoDescartar, AcaoOcultar, AcaoLiberar, AcaoAdvertir:
+	case AcaoSuspender:
+		if resolucao.DiasSuspensao <= 0 {
+			return errors.New("Informe por quantos dias o usuário vai ficar suspenso")
+		}
+
+		if resolucao.DiasSuspensao > maximoDiasSuspensao {
+			return fmt.Errorf("Uma suspensão pode durar no máximo %d dias", maximoDiasSuspensao)
+		}
+	default:
+		return fmt.Errorf(
+			"Ação inválida. Use uma destas: %s, %s, %s, %s, %s",
+			AcaoDescartar, AcaoOcultar, AcaoLiberar, AcaoAdvertir, AcaoSuspender,
+		)
+	}
+
+	if utf8.RuneCountInString(resolucao.Observacao) > tamanhoMaximoDescricaoDenuncia {
+		return fmt.Errorf("A observação pode ter no máximo %d caracteres", tamanhoMaximoDescricaoDenuncia)
+	}
+
+	return nil
+}
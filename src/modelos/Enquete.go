@@ -0,0 +1,120 @@
+package modelos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// minimoOpcoesEnquete é a quantidade mínima de opções de uma enquete
+	minimoOpcoesEnquete = 2
+
+	// maximoOpcoesEnquete é a quantidade máxima de opções de uma enquete
+	maximoOpcoesEnquete = 6
+
+	// tamanhoMaximoOpcao é a quantidade máxima de caracteres do texto de uma opção
+	tamanhoMaximoOpcao = 100
+)
+
+// Enquete representa uma votação anexada a uma publicação
+type Enquete struct {
+	ID              uint64         `json:"id,omitempty"`
+	PublicacaoID    uint64         `json:"publicacaoId,omitempty"`
+	MultiplaEscolha bool           `json:"multiplaEscolha"`
+	Anonima         bool           `json:"anonima"`
+	ExpiraEm        time.Time      `json:"expiraEm"`
+	Encerrada       bool           `json:"encerrada"`
+	TotalVotantes   uint64         `json:"totalVotantes"`
+	MeusVotos       []uint64       `json:"meusVotos,omitempty"`
+	Opcoes          []OpcaoEnquete `json:"opcoes"`
+}
+
+// OpcaoEnquete representa uma das opções de uma enquete
+type OpcaoEnquete struct {
+	ID       uint64    `json:"id,omitempty"`
+	Texto    string    `json:"texto"`
+	Votos    uint64    `json:"votos"`
+	Votantes []Usuario `json:"votantes,omitempty"`
+}
+
+// Voto representa o formato da requisição de voto em uma enquete
+type Voto struct {
+	Opcoes []uint64 `json:"opcoes"`
+}
+
+// Preparar vai chamar os métodos para validar e formatar a enquete recebida
+func (enquete *Enquete) Preparar() error {
+	enquete.formatar()
+	return enquete.validar()
+}
+
+func (enquete *Enquete) validar() error {
+	if len(enquete.Opcoes) < minimoOpcoesEnquete || len(enquete.Opcoes) > maximoOpcoesEnquete {
+		return fmt.Errorf("Uma enquete deve ter entre %d e %d opções", minimoOpcoesEnquete, maximoOpcoesEnquete)
+	}
+
+	textos := make(map[string]bool)
+	for _, opcao := range enquete.Opcoes {
+		if opcao.Texto == "" {
+			return errors.New("O texto das opções da enquete é obrigatório e não pode estar em branco")
+		}
+
+		if utf8.RuneCountInString(opcao.Texto) > tamanhoMaximoOpcao {
+			return fmt.Errorf("O texto de cada opção pode ter no máximo %d caracteres", tamanhoMaximoOpcao)
+		}
+
+		if textos[strings.ToLower(opcao.Texto)] {
+			return errors.New("A enquete não pode ter opções repetidas")
+		}
+		textos[strings.ToLower(opcao.Texto)] = true
+	}
+
+	if !enquete.ExpiraEm.After(time.Now()) {
+		return errors.New("A data de encerramento da enquete precisa estar no futuro")
+	}
+
+	return nil
+}
+
+func (enquete *Enquete) formatar() {
+	for i := range enquete.Opcoes {
+		enquete.Opcoes[i].Texto = strings.TrimSpace(enquete.Opcoes[i].Texto)
+	}
+}
+
+// ValidarVoto verifica se as opções escolhidas podem ser votadas nesta enquete
+func (enquete Enquete) ValidarVoto(voto Voto) error {
+	if enquete.Encerrada || !enquete.ExpiraEm.After(time.Now()) {
+		return errors.New("Esta enquete já foi encerrada")
+	}
+
+	if len(voto.Opcoes) == 0 {
+		return errors.New("Escolha ao menos uma opção")
+	}
+
+	if !enquete.MultiplaEscolha && len(voto.Opcoes) > 1 {
+		return errors.New("Esta enquete aceita apenas uma opção")
+	}
+
+	opcoes := make(map[uint64]bool)
+	for _, opcao := range enquete.Opcoes {
+		opcoes[opcao.ID] = true
+	}
+
+	escolhidas := make(map[uint64]bool)
+	for _, opcaoID := range voto.Opcoes {
+		if !opcoes[opcaoID] {
+			return errors.New("A opção escolhida não pertence a esta enquete")
+		}
+
+		if escolhidas[opcaoID] {
+			return errors.New("A mesma opção não pode ser escolhida mais de uma vez")
+		}
+		escolhidas[opcaoID] = true
+	}
+
+	return nil
+}
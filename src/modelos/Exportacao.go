@@ -0,0 +1,70 @@
+package modelos
+
+import "time"
+
+const (
+	// StatusExportacaoPendente indica uma exportação que ainda não começou a ser gerada
+	StatusExportacaoPendente = "pendente"
+
+	// StatusExportacaoProcessando indica uma exportação que está sendo gerada
+	StatusExportacaoProcessando = "processando"
+
+	// StatusExportacaoPronta indica uma exportação que já pode ser baixada
+	StatusExportacaoPronta = "pronta"
+
+	// StatusExportacaoFalhou indica uma exportação que não pôde ser gerada
+	StatusExportacaoFalhou = "falhou"
+)
+
+// Exportacao representa um pedido de cópia dos dados pessoais de um usuário
+type Exportacao struct {
+	ID           uint64     `json:"id"`
+	UsuarioID    uint64     `json:"usuarioId"`
+	Status       string     `json:"status"`
+	Chave        string     `json:"-"`
+	SolicitadaEm time.Time  `json:"solicitadaEm"`
+	ConcluidaEm  *time.Time `json:"concluidaEm,omitempty"`
+	ExpiraEm     *time.Time `json:"expiraEm,omitempty"`
+	URL          string     `json:"url,omitempty"`
+}
+
+// DadosPessoais reúne tudo o que a API guarda sobre um usuário
+type DadosPessoais struct {
+	Perfil          Usuario           `json:"perfil"`
+	NicksAnteriores []NickAnterior    `json:"nicksAnteriores"`
+	Publicacoes     []Publicacao      `json:"publicacoes"`
+	Reacoes         []ReacaoExportada `json:"reacoes"`
+	Colecoes        []Colecao         `json:"colecoes"`
+	Salvos          []SalvoExportado  `json:"salvos"`
+	Votos           []VotoExportado   `json:"votos"`
+	Seguidores      []Usuario         `json:"seguidores"`
+	Seguindo        []Usuario         `json:"seguindo"`
+	Silenciadas     []string          `json:"palavrasSilenciadas"`
+}
+
+// NickAnterior representa um nick que o usuário usou antes do atual
+type NickAnterior struct {
+	Nick      string    `json:"nick"`
+	TrocadoEm time.Time `json:"trocadoEm"`
+}
+
+// ReacaoExportada representa uma reação do usuário a uma publicação
+type ReacaoExportada struct {
+	PublicacaoID uint64    `json:"publicacaoId"`
+	Tipo         string    `json:"tipo"`
+	ReagidoEm    time.Time `json:"reagidoEm"`
+}
+
+// SalvoExportado representa uma publicação salva pelo usuário
+type SalvoExportado struct {
+	PublicacaoID uint64    `json:"publicacaoId"`
+	ColecaoID    *uint64   `json:"colecaoId,omitempty"`
+	SalvoEm      time.Time `json:"salvoEm"`
+}
+
+// VotoExportado representa um voto do usuário em uma enquete
+type VotoExportado struct {
+	PublicacaoID uint64    `json:"publicacaoId"`
+	Opcao        string    `json:"opcao"`
+	VotadoEm     time.Time `json:"votadoEm"`
+}
@@ -0,0 +1,16 @@
+package modelos
+
+// Paginacao representa a página pedida em uma listagem
+type Paginacao struct {
+	Pagina uint64 `json:"pagina"`
+	Limite uint64 `json:"limite"`
+}
+
+// Deslocamento retorna quantos itens devem ser pulados para chegar na página
+func (paginacao Paginacao) Deslocamento() uint64 {
+	if paginacao.Pagina == 0 {
+		return 0
+	}
+
+	return (paginacao.Pagina - 1) * paginacao.Limite
+}
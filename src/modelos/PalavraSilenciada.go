@@ -0,0 +1,31 @@
+package modelos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// tamanhoMaximoPalavraSilenciada é a quantidade máxima de caracteres de uma palavra silenciada
+const tamanhoMaximoPalavraSilenciada = 50
+
+// PalavraSilenciada representa uma palavra que o usuário não quer ver no seu feed
+type PalavraSilenciada struct {
+	Palavra string `json:"palavra"`
+}
+
+// Preparar vai validar e formatar a palavra recebida
+func (silenciada *PalavraSilenciada) Preparar() error {
+	silenciada.Palavra = strings.TrimSpace(silenciada.Palavra)
+
+	if silenciada.Palavra == "" {
+		return errors.New("A palavra é obrigatória e não pode estar em branco")
+	}
+
+	if utf8.RuneCountInString(silenciada.Palavra) > tamanhoMaximoPalavraSilenciada {
+		return fmt.Errorf("A palavra pode ter no máximo %d caracteres", tamanhoMaximoPalavraSilenciada)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package modelos
+
+// Perfil representa os dados de um usuário mostrados no seu perfil, com as contagens e a relação
+// dele com quem está vendo
+type Perfil struct {
+	Usuario
+	Seguidores  uint64 `json:"seguidores"`
+	Seguindo    uint64 `json:"seguindo"`
+	Publicacoes uint64 `json:"publicacoes"`
+	EuSigo      bool   `json:"euSigo"`
+	MeSegue     bool   `json:"meSegue"`
+	// SeguidoresEmComum são alguns dos seguidores do usuário que quem está vendo o perfil também segue
+	SeguidoresEmComum      []Usuario `json:"seguidoresEmComum"`
+	TotalSeguidoresEmComum uint64    `json:"totalSeguidoresEmComum"`
+}
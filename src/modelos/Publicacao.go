@@ -1,30 +1,75 @@
 package modelos
 
 import (
+	"api/src/config"
+	"api/src/triagem"
 	"errors"
+	"fmt"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// tamanhoMaximoConteudo é a quantidade máxima de caracteres do texto de uma publicação
+const tamanhoMaximoConteudo = 300
+
+// blocoDeCodigo encontra blocos cercados por ``` no conteúdo, com a linguagem e o nome do arquivo opcionais
+// na linha de abertura (ex: ```go main.go)
+var blocoDeCodigo = regexp.MustCompile("(?ms)^```[ \\t]*([^\\s`]*)[ \\t]*([^\\n`]*)\\n(.*?)^```[ \\t]*$\\n?")
+
+const (
+	// StatusRascunho indica uma publicação que só o autor pode ver e que ainda não tem data para ser publicada
+	StatusRascunho = "rascunho"
+
+	// StatusAgendada indica uma publicação que será publicada automaticamente em PublicarEm
+	StatusAgendada = "agendada"
+
+	// StatusPublicada indica uma publicação visível para os demais usuários
+	StatusPublicada = "publicada"
+)
+
+// ErrConteudoBloqueado é retornado quando a publicação tem termos ou links bloqueados pela moderação
+var ErrConteudoBloqueado = errors.New("A publicação tem termos ou links que não são permitidos")
+
 // Publicacao representa uma publicação feita por um usuário
 type Publicacao struct {
-	ID        uint64    `json:"id,omitempty"`
-	Titulo    string    `json:"titulo,omitempty"`
-	Conteudo  string    `json:"conteudo,omitempty"`
-	AutorID   uint64    `json:"autorId,omitempty"`
-	AutorNick string    `json:"autorNick,omitempty"`
-	Curtidas  uint64    `json:"curtidas"`
-	CriadaEm  time.Time `json:"criadaEm,omitempty"`
+	ID             uint64            `json:"id,omitempty"`
+	Titulo         string            `json:"titulo,omitempty"`
+	Conteudo       string            `json:"conteudo,omitempty"`
+	ConteudoHTML   string            `json:"conteudoHtml,omitempty"`
+	AutorID        uint64            `json:"autorId,omitempty"`
+	AutorNick      string            `json:"autorNick,omitempty"`
+	Curtidas       uint64            `json:"curtidas"`
+	CriadaEm       time.Time         `json:"criadaEm,omitempty"`
+	DeletadoEm     *time.Time        `json:"deletadoEm,omitempty"`
+	Status         string            `json:"status,omitempty"`
+	PublicarEm     *time.Time        `json:"publicarEm,omitempty"`
+	Fixada         bool              `json:"fixada"`
+	Reacoes        map[string]uint64 `json:"reacoes,omitempty"`
+	MinhaReacao    string            `json:"minhaReacao,omitempty"`
+	Anexos         []Anexo           `json:"anexos,omitempty"`
+	Trechos        []Trecho          `json:"trechos,omitempty"`
+	Enquete        *Enquete          `json:"enquete,omitempty"`
+	EmRevisao      bool              `json:"emRevisao,omitempty"`
+	MotivosRevisao []string          `json:"-"`
 }
 
 // Preparar vai chamar os métodos para validar e formatar a publicação recebida
 func (publicacao *Publicacao) Preparar() error {
+	publicacao.extrairTrechos()
+
 	if erro := publicacao.validar(); erro != nil {
 		return erro
 	}
 
 	publicacao.formatar()
-	return nil
+
+	if erro := publicacao.triar(); erro != nil {
+		return erro
+	}
+
+	return publicacao.definirStatus()
 }
 
 func (publicacao *Publicacao) validar() error {
@@ -32,10 +77,40 @@ func (publicacao *Publicacao) validar() error {
 		return errors.New("O título é obrigatório e não pode estar em branco")
 	}
 
-	if publicacao.Conteudo == "" {
+	if strings.TrimSpace(publicacao.Conteudo) == "" && len(publicacao.Trechos) == 0 {
 		return errors.New("O conteúdo é obrigatório e não pode estar em branco")
 	}
 
+	if utf8.RuneCountInString(strings.TrimSpace(publicacao.Conteudo)) > tamanhoMaximoConteudo {
+		return fmt.Errorf("O conteúdo pode ter no máximo %d caracteres. Para compartilhar código, use um bloco ```", tamanhoMaximoConteudo)
+	}
+
+	if len(publicacao.Trechos) > config.MaximoTrechosPorPublicacao {
+		return fmt.Errorf("Uma publicação pode ter no máximo %d trechos de código", config.MaximoTrechosPorPublicacao)
+	}
+
+	for i := range publicacao.Trechos {
+		if erro := publicacao.Trechos[i].Preparar(); erro != nil {
+			return erro
+		}
+	}
+
+	if publicacao.Enquete != nil {
+		if erro := publicacao.Enquete.Preparar(); erro != nil {
+			return erro
+		}
+
+		if publicacao.PublicarEm != nil && !publicacao.Enquete.ExpiraEm.After(*publicacao.PublicarEm) {
+			return errors.New("A data de encerramento da enquete precisa ser depois da data de publicação")
+		}
+	}
+
+	switch publicacao.Status {
+	case "", StatusRascunho, StatusAgendada, StatusPublicada:
+	default:
+		return errors.New("O status deve ser rascunho, agendada ou publicada")
+	}
+
 	return nil
 }
 
@@ -43,3 +118,82 @@ func (publicacao *Publicacao) formatar() {
 	publicacao.Titulo = strings.TrimSpace(publicacao.Titulo)
 	publicacao.Conteudo = strings.TrimSpace(publicacao.Conteudo)
 }
+
+// triar passa o título, o conteúdo, os trechos de código e as opções da enquete pelas regras de filtro da
+// moderação. Os trechos mascarados já ficam trocados na publicação e, se alguma regra pedir revisão, a
+// publicação é marcada para ficar escondida, guardando em MotivosRevisao as regras que casaram
+func (publicacao *Publicacao) triar() error {
+	publicacao.EmRevisao = false
+	publicacao.MotivosRevisao = nil
+
+	for _, campo := range publicacao.camposTriados() {
+		resultado := triagem.Avaliar(*campo)
+
+		switch resultado.Acao {
+		case triagem.AcaoRejeitar:
+			return ErrConteudoBloqueado
+		case triagem.AcaoRevisar:
+			publicacao.EmRevisao = true
+			publicacao.MotivosRevisao = append(publicacao.MotivosRevisao, resultado.Motivos...)
+		}
+
+		*campo = resultado.Texto
+	}
+
+	return nil
+}
+
+// camposTriados retorna todos os textos da publicação que passam pelos filtros da moderação, incluindo os
+// blocos de código já extraídos do conteúdo
+func (publicacao *Publicacao) camposTriados() []*string {
+	campos := []*string{&publicacao.Titulo, &publicacao.Conteudo}
+
+	for i := range publicacao.Trechos {
+		campos = append(campos, &publicacao.Trechos[i].NomeArquivo, &publicacao.Trechos[i].Codigo)
+	}
+
+	if publicacao.Enquete != nil {
+		for i := range publicacao.Enquete.Opcoes {
+			campos = append(campos, &publicacao.Enquete.Opcoes[i].Texto)
+		}
+	}
+
+	return campos
+}
+
+// extrairTrechos move os blocos de código cercados por ``` do conteúdo para os trechos da publicação,
+// para que o código não conte no limite de caracteres do texto e possa ser destacado
+func (publicacao *Publicacao) extrairTrechos() {
+	for _, bloco := range blocoDeCodigo.FindAllStringSubmatch(publicacao.Conteudo, -1) {
+		publicacao.Trechos = append(publicacao.Trechos, Trecho{
+			Linguagem:   bloco[1],
+			NomeArquivo: bloco[2],
+			Codigo:      bloco[3],
+		})
+	}
+
+	publicacao.Conteudo = blocoDeCodigo.ReplaceAllString(publicacao.Conteudo, "")
+}
+
+// definirStatus deduz o status da publicação a partir do status e da data de publicação recebidos
+func (publicacao *Publicacao) definirStatus() error {
+	if publicacao.Status == StatusRascunho {
+		return nil
+	}
+
+	if publicacao.PublicarEm == nil {
+		if publicacao.Status == StatusAgendada {
+			return errors.New("A data de publicação é obrigatória para agendar uma publicação")
+		}
+
+		publicacao.Status = StatusPublicada
+		return nil
+	}
+
+	if !publicacao.PublicarEm.After(time.Now()) {
+		return errors.New("A data de publicação precisa estar no futuro")
+	}
+
+	publicacao.Status = StatusAgendada
+	return nil
+}
@@ -0,0 +1,33 @@
+package modelos
+
+import (
+	"api/src/config"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Reacao representa a reação de um usuário a uma publicação
+type Reacao struct {
+	Tipo      string    `json:"tipo"`
+	UsuarioID uint64    `json:"usuarioId,omitempty"`
+	Nick      string    `json:"nick,omitempty"`
+	ReagidoEm time.Time `json:"reagidoEm,omitempty"`
+}
+
+// Preparar vai validar a reação recebida
+func (reacao *Reacao) Preparar() error {
+	reacao.Tipo = strings.TrimSpace(reacao.Tipo)
+	return ValidarTipoDeReacao(reacao.Tipo)
+}
+
+// ValidarTipoDeReacao verifica se o tipo informado está entre as reações permitidas
+func ValidarTipoDeReacao(tipo string) error {
+	for _, permitido := range config.TiposDeReacao {
+		if tipo == permitido {
+			return nil
+		}
+	}
+
+	return errors.New("Reação inválida. Use uma destas: " + strings.Join(config.TiposDeReacao, " "))
+}
@@ -0,0 +1,16 @@
+package modelos
+
+const (
+	// SaudeOK indica que a API, ou uma das verificações dela, está funcionando
+	SaudeOK = "ok"
+
+	// SaudeIndisponivel indica que a API não deve receber requisições, seja por uma verificação que falhou
+	// ou porque ela está sendo encerrada
+	SaudeIndisponivel = "indisponivel"
+)
+
+// Saude representa a resposta do /healthz e do /readyz, com o resultado de cada verificação feita
+type Saude struct {
+	Status       string            `json:"status"`
+	Verificacoes map[string]string `json:"verificacoes,omitempty"`
+}
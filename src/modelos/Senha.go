@@ -5,3 +5,8 @@ type Senha struct {
 	Nova  string `json:"nova"`
 	Atual string `json:"atual"`
 }
+
+// ConfirmacaoSenha representa o formato das requisições que pedem a senha atual para confirmar uma ação
+type ConfirmacaoSenha struct {
+	Senha string `json:"senha"`
+}
@@ -0,0 +1,8 @@
+package modelos
+
+// Sugestao representa um usuário sugerido para alguém seguir
+type Sugestao struct {
+	Usuario Usuario `json:"usuario"`
+	// ConexoesEmComum é quantos dos usuários seguidos por quem recebe a sugestão também seguem o sugerido
+	ConexoesEmComum uint64 `json:"conexoesEmComum"`
+}
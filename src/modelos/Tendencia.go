@@ -0,0 +1,57 @@
+package modelos
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// hashtag encontra as hashtags em um texto, como #golang ou #segunda_feira
+var hashtag = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/])#([\p{L}\p{N}_]{1,100})`)
+
+// JanelasTendencias são os períodos sobre os quais as tendências são calculadas
+var JanelasTendencias = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// Tendencias representa as publicações e hashtags em alta em uma janela de tempo
+type Tendencias struct {
+	Janela       string       `json:"janela"`
+	CalculadasEm *time.Time   `json:"calculadasEm"`
+	Publicacoes  []Publicacao `json:"publicacoes"`
+	Hashtags     []Hashtag    `json:"hashtags"`
+}
+
+// Hashtag representa uma hashtag em alta e quantas publicações a usaram na janela de tempo
+type Hashtag struct {
+	Nome        string  `json:"nome"`
+	Publicacoes uint64  `json:"publicacoes"`
+	Pontuacao   float64 `json:"pontuacao"`
+}
+
+// Engajamento representa uma publicação candidata às tendências, com a pontuação das interações que ela
+// recebeu dentro da janela de tempo
+type Engajamento struct {
+	PublicacaoID uint64
+	Conteudo     string
+	CriadaEm     time.Time
+	Pontuacao    float64
+}
+
+// ExtrairHashtags retorna as hashtags de um texto em letras minúsculas, sem o # e sem repetições
+func ExtrairHashtags(texto string) []string {
+	var hashtags []string
+	vistas := make(map[string]bool)
+
+	for _, encontrada := range hashtag.FindAllStringSubmatch(texto, -1) {
+		nome := strings.ToLower(encontrada[1])
+		if !vistas[nome] {
+			vistas[nome] = true
+			hashtags = append(hashtags, nome)
+		}
+	}
+
+	return hashtags
+}
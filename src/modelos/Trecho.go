@@ -0,0 +1,64 @@
+package modelos
+
+import (
+	"api/src/config"
+	"api/src/destaque"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// tamanhoMaximoNomeArquivo é o tamanho máximo do nome de arquivo de um trecho de código
+const tamanhoMaximoNomeArquivo = 100
+
+// Trecho representa um trecho de código anexado a uma publicação
+type Trecho struct {
+	ID           uint64 `json:"id,omitempty"`
+	PublicacaoID uint64 `json:"publicacaoId,omitempty"`
+	Linguagem    string `json:"linguagem,omitempty"`
+	NomeArquivo  string `json:"nomeArquivo,omitempty"`
+	Codigo       string `json:"codigo,omitempty"`
+	HTML         string `json:"html,omitempty"`
+}
+
+// Preparar vai chamar os métodos para validar e formatar o trecho recebido
+func (trecho *Trecho) Preparar() error {
+	trecho.formatar()
+
+	if erro := trecho.validar(); erro != nil {
+		return erro
+	}
+
+	linguagem, erro := destaque.NormalizarLinguagem(trecho.Linguagem, trecho.NomeArquivo)
+	if erro != nil {
+		return fmt.Errorf("A linguagem %q não é suportada", trecho.Linguagem)
+	}
+
+	trecho.Linguagem = linguagem
+	return nil
+}
+
+func (trecho *Trecho) validar() error {
+	if strings.TrimSpace(trecho.Codigo) == "" {
+		return errors.New("O código de um trecho é obrigatório e não pode estar em branco")
+	}
+
+	if utf8.RuneCountInString(trecho.Codigo) > config.TamanhoMaximoTrecho {
+		return fmt.Errorf("Um trecho de código pode ter no máximo %d caracteres", config.TamanhoMaximoTrecho)
+	}
+
+	if utf8.RuneCountInString(trecho.NomeArquivo) > tamanhoMaximoNomeArquivo {
+		return fmt.Errorf("O nome do arquivo pode ter no máximo %d caracteres", tamanhoMaximoNomeArquivo)
+	}
+
+	return nil
+}
+
+// formatar remove espaços das pontas dos metadados e linhas em branco no fim do código,
+// preservando a indentação
+func (trecho *Trecho) formatar() {
+	trecho.Linguagem = strings.ToLower(strings.TrimSpace(trecho.Linguagem))
+	trecho.NomeArquivo = strings.TrimSpace(trecho.NomeArquivo)
+	trecho.Codigo = strings.TrimRight(trecho.Codigo, " \t\r\n")
+}
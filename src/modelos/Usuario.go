@@ -3,20 +3,49 @@ package modelos
 import (
 	"api/src/seguranca"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/badoux/checkmail"
 )
 
+var (
+	// ErrExclusaoAgendada é retornado quando o usuário tenta usar uma conta com exclusão agendada
+	ErrExclusaoAgendada = errors.New("esta conta está agendada para exclusão; cancele a exclusão para voltar a usá-la")
+
+	// ErrContaSuspensa é retornado quando o usuário tenta usar uma conta suspensa pela moderação
+	ErrContaSuspensa = errors.New("esta conta foi suspensa pela moderação")
+
+	// ErrContaDesativada é retornado quando um token emitido antes da desativação da conta é usado
+	ErrContaDesativada = errors.New("esta conta está desativada; entre novamente para reativá-la")
+)
+
 // Usuario representa um usuário utilizando a rede social
 type Usuario struct {
-	ID       uint64    `json:"id,omitempty"`
-	Nome     string    `json:"nome,omitempty"`
-	Nick     string    `json:"nick,omitempty"`
-	Email    string    `json:"email,omitempty"`
-	Senha    string    `json:"senha,omitempty"`
-	CriadoEm time.Time `json:"CriadoEm,omitempty"`
+	ID                 uint64     `json:"id,omitempty"`
+	Nome               string     `json:"nome,omitempty"`
+	Nick               string     `json:"nick,omitempty"`
+	Email              string     `json:"email,omitempty"`
+	Senha              string     `json:"senha,omitempty"`
+	CriadoEm           time.Time  `json:"CriadoEm,omitempty"`
+	DeletadoEm         *time.Time `json:"deletadoEm,omitempty"`
+	ExclusaoAgendadaEm *time.Time `json:"exclusaoAgendadaEm,omitempty"`
+	SuspensoAte        *time.Time `json:"suspensoAte,omitempty"`
+}
+
+// Bloqueio retorna o motivo pelo qual a conta não pode ser usada: a exclusão agendada ou uma suspensão ainda
+// em vigor. A desativação fica de fora, já que entrar de novo reativa a conta
+func (usuario Usuario) Bloqueio(agora time.Time) error {
+	if usuario.ExclusaoAgendadaEm != nil {
+		return ErrExclusaoAgendada
+	}
+
+	if usuario.SuspensoAte != nil && agora.Before(*usuario.SuspensoAte) {
+		return fmt.Errorf("%w até %s", ErrContaSuspensa, usuario.SuspensoAte.Format("02/01/2006 15:04"))
+	}
+
+	return nil
 }
 
 // Preparar vai chamar os métodos para validar e formatar o usuário recebido
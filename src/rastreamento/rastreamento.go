@@ -0,0 +1,135 @@
+// Package rastreamento configura o rastreamento distribuído da API com o OpenTelemetry. Os traces seguem o padrão
+// W3C Trace Context, então uma requisição que chega com o cabeçalho traceparent continua o trace de quem a fez
+package rastreamento
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// nomeServico identifica a API no coletor de traces
+	nomeServico = "devbook-api"
+
+	// nomeTracer identifica os spans criados pelo código da API
+	nomeTracer = "api"
+)
+
+// Configurar instala a propagação do W3C Trace Context e, com um endpoint definido, o provedor que exporta os
+// traces para um coletor OTLP/HTTP. Retorna a função que envia os spans pendentes e encerra o provedor
+func Configurar(ctx context.Context, endpoint string, inseguro bool, amostragem float64) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opcoes := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	if inseguro {
+		opcoes = append(opcoes, otlptracehttp.WithInsecure())
+	}
+
+	exportador, erro := otlptracehttp.New(ctx, opcoes...)
+	if erro != nil {
+		return nil, erro
+	}
+
+	provedor, erro := NovoProvedor(exportador, amostragem)
+	if erro != nil {
+		return nil, erro
+	}
+
+	otel.SetTracerProvider(provedor)
+	return provedor.Shutdown, nil
+}
+
+// NovoProvedor cria um provedor de traces que envia os spans para o exportador informado, como o OTLP ou o
+// tracetest.InMemoryExporter. A amostragem vale para os traces que começam na API; os que vêm de fora seguem
+// a decisão de quem os começou
+func NovoProvedor(exportador sdktrace.SpanExporter, amostragem float64) (*sdktrace.TracerProvider, error) {
+	recurso, erro := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(nomeServico)),
+	)
+	if erro != nil {
+		return nil, erro
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exportador),
+		sdktrace.WithResource(recurso),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(amostragem))),
+	), nil
+}
+
+// Iniciar abre um span filho do que estiver no contexto, ou a raiz de um trace novo se não houver nenhum
+func Iniciar(ctx context.Context, nome string, opcoes ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(nomeTracer).Start(ctx, nome, opcoes...)
+}
+
+// IniciarFilho abre um span só quando o contexto já faz parte de um trace. Fora dele, como nas tarefas em
+// segundo plano, retorna um span que não registra nada, para que cada execução não vire um trace à parte
+func IniciarFilho(ctx context.Context, nome string) (context.Context, trace.Span) {
+	if !EmTrace(ctx) {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+
+	return Iniciar(ctx, nome)
+}
+
+// EmTrace indica se o contexto faz parte de um trace
+func EmTrace(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
+
+// TraceID retorna o ID do trace do contexto, ou vazio se ele não fizer parte de um
+func TraceID(ctx context.Context) string {
+	if !EmTrace(ctx) {
+		return ""
+	}
+
+	return trace.SpanContextFromContext(ctx).TraceID().String()
+}
+
+// Encerrar fecha o span, marcando-o como falho quando houver um erro
+func Encerrar(span trace.Span, erro error) {
+	if erro != nil {
+		span.RecordError(erro)
+		span.SetStatus(codes.Error, erro.Error())
+	}
+
+	span.End()
+}
+
+// IniciarRequisicao abre o span de servidor de uma requisição, continuando o trace informado no traceparent.
+// A rota é o modelo da URI, como em /usuarios/{usuarioId}. Retorna a requisição com o span no contexto e a
+// função que o encerra com o status da resposta
+func IniciarRequisicao(r *http.Request, rota string) (*http.Request, func(status int)) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+	ctx, span := Iniciar(ctx, r.Method+" "+rota,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.HTTPRoute(rota),
+			semconv.URLPath(r.URL.Path),
+		),
+	)
+
+	return r.WithContext(ctx), func(status int) {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		span.End()
+	}
+}
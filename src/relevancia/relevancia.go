@@ -0,0 +1,89 @@
+// Package relevancia calcula a pontuação usada para ordenar o feed relevante. A pontuação combina o
+// engajamento da publicação, a afinidade de quem está vendo com o autor e um decaimento pela idade.
+//
+// Comentários, republicações e hashtags seguidas não entram na pontuação porque a API ainda não tem esses
+// recursos. No lugar deles o engajamento conta as reações, os salvamentos e os votos em enquetes. Quando
+// eles existirem, cada um deve virar um campo de Sinais com o seu peso em Pesos
+package relevancia
+
+import (
+	"api/src/config"
+	"api/src/modelos"
+	"math"
+	"sort"
+	"time"
+)
+
+// Pesos define quanto cada sinal contribui para a pontuação de uma publicação
+type Pesos struct {
+	// MeiaVida é o tempo depois do qual a pontuação de uma publicação cai pela metade
+	MeiaVida  time.Duration
+	Curtida   float64
+	Reacao    float64
+	Salvo     float64
+	Voto      float64
+	Afinidade float64
+}
+
+// Sinais são os dados de uma publicação usados no cálculo da pontuação
+type Sinais struct {
+	PublicadaEm time.Time
+	Curtidas    uint64
+	Reacoes     uint64
+	Salvos      uint64
+	Votos       uint64
+	// Interacoes é quantas vezes quem está vendo o feed já interagiu com publicações do mesmo autor
+	Interacoes uint64
+}
+
+// Candidato é uma publicação que pode entrar no feed relevante, com os sinais usados para pontuá-la
+type Candidato struct {
+	Publicacao modelos.Publicacao
+	Sinais     Sinais
+	Pontuacao  float64
+}
+
+// PesosDaConfiguracao retorna os pesos definidos nas variáveis de ambiente
+func PesosDaConfiguracao() Pesos {
+	return Pesos{
+		MeiaVida:  config.MeiaVidaRelevancia,
+		Curtida:   config.PesoCurtidaRelevancia,
+		Reacao:    config.PesoReacaoRelevancia,
+		Salvo:     config.PesoSalvoRelevancia,
+		Voto:      config.PesoVotoRelevancia,
+		Afinidade: config.PesoAfinidadeRelevancia,
+	}
+}
+
+// Pontuar calcula a pontuação de uma publicação no instante informado. O engajamento e a afinidade
+// crescem de forma logarítmica, para que publicações muito populares não dominem o feed
+func Pontuar(sinais Sinais, pesos Pesos, agora time.Time) float64 {
+	engajamento := pesos.Curtida*float64(sinais.Curtidas) +
+		pesos.Reacao*float64(sinais.Reacoes) +
+		pesos.Salvo*float64(sinais.Salvos) +
+		pesos.Voto*float64(sinais.Votos)
+
+	afinidade := 1 + pesos.Afinidade*math.Log1p(float64(sinais.Interacoes))
+
+	decaimento := 1.0
+	if idade := agora.Sub(sinais.PublicadaEm); pesos.MeiaVida > 0 && idade > 0 {
+		decaimento = math.Pow(0.5, float64(idade)/float64(pesos.MeiaVida))
+	}
+
+	return (1 + math.Log1p(math.Max(engajamento, 0))) * afinidade * decaimento
+}
+
+// Ordenar pontua os candidatos e os ordena da maior para a menor pontuação. Em caso de empate, a
+// publicação mais nova vem primeiro
+func Ordenar(candidatos []Candidato, pesos Pesos, agora time.Time) {
+	for i := range candidatos {
+		candidatos[i].Pontuacao = Pontuar(candidatos[i].Sinais, pesos, agora)
+	}
+
+	sort.SliceStable(candidatos, func(i, j int) bool {
+		if candidatos[i].Pontuacao != candidatos[j].Pontuacao {
+			return candidatos[i].Pontuacao > candidatos[j].Pontuacao
+		}
+		return candidatos[i].Publicacao.ID > candidatos[j].Publicacao.ID
+	})
+}
@@ -0,0 +1,149 @@
+package relevancia
+
+import (
+	"api/src/modelos"
+	"math"
+	"testing"
+	"time"
+)
+
+// agora é o instante fixo usado nos testes, para que a pontuação não dependa do relógio
+var agora = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+// pesosDeTeste são pesos simples, para que as pontuações esperadas possam ser calculadas à mão
+var pesosDeTeste = Pesos{MeiaVida: time.Hour, Curtida: 1, Reacao: 2, Salvo: 3, Voto: 0.5, Afinidade: 1}
+
+func TestPontuar(t *testing.T) {
+	testes := []struct {
+		nome     string
+		sinais   Sinais
+		pesos    Pesos
+		esperado float64
+	}{
+		{
+			nome:     "publicação nova sem engajamento",
+			sinais:   Sinais{PublicadaEm: agora},
+			pesos:    pesosDeTeste,
+			esperado: 1,
+		},
+		{
+			nome:     "engajamento soma os sinais com os pesos",
+			sinais:   Sinais{PublicadaEm: agora, Curtidas: 1, Reacoes: 1, Salvos: 1, Votos: 2},
+			pesos:    pesosDeTeste,
+			esperado: 1 + math.Log1p(1+2+3+1),
+		},
+		{
+			nome:     "afinidade multiplica a pontuação",
+			sinais:   Sinais{PublicadaEm: agora, Interacoes: 3},
+			pesos:    pesosDeTeste,
+			esperado: 1 + math.Log1p(3),
+		},
+		{
+			nome:     "pontuação cai pela metade a cada meia-vida",
+			sinais:   Sinais{PublicadaEm: agora.Add(-2 * time.Hour)},
+			pesos:    pesosDeTeste,
+			esperado: 0.25,
+		},
+		{
+			nome:     "publicação com data no futuro não ganha bônus",
+			sinais:   Sinais{PublicadaEm: agora.Add(time.Hour)},
+			pesos:    pesosDeTeste,
+			esperado: 1,
+		},
+		{
+			nome:     "meia-vida zero desliga o decaimento",
+			sinais:   Sinais{PublicadaEm: agora.Add(-48 * time.Hour), Curtidas: 1},
+			pesos:    Pesos{Curtida: 1},
+			esperado: 1 + math.Log1p(1),
+		},
+		{
+			nome:     "engajamento com peso negativo não fica abaixo de zero",
+			sinais:   Sinais{PublicadaEm: agora, Curtidas: 10},
+			pesos:    Pesos{MeiaVida: time.Hour, Curtida: -1},
+			esperado: 1,
+		},
+	}
+
+	for _, teste := range testes {
+		t.Run(teste.nome, func(t *testing.T) {
+			if pontuacao := Pontuar(teste.sinais, teste.pesos, agora); math.Abs(pontuacao-teste.esperado) > 1e-9 {
+				t.Errorf("esperava %v, recebeu %v", teste.esperado, pontuacao)
+			}
+		})
+	}
+}
+
+func TestOrdenar(t *testing.T) {
+	candidato := func(ID uint64, sinais Sinais) Candidato {
+		return Candidato{Publicacao: modelos.Publicacao{ID: ID}, Sinais: sinais}
+	}
+
+	testes := []struct {
+		nome       string
+		candidatos []Candidato
+		esperado   []uint64
+	}{
+		{
+			nome:       "sem candidatos",
+			candidatos: nil,
+			esperado:   nil,
+		},
+		{
+			nome: "mais engajamento vem primeiro",
+			candidatos: []Candidato{
+				candidato(1, Sinais{PublicadaEm: agora, Curtidas: 1}),
+				candidato(2, Sinais{PublicadaEm: agora, Curtidas: 10}),
+				candidato(3, Sinais{PublicadaEm: agora}),
+			},
+			esperado: []uint64{2, 1, 3},
+		},
+		{
+			nome: "publicação antiga perde para uma nova com menos engajamento",
+			candidatos: []Candidato{
+				candidato(1, Sinais{PublicadaEm: agora.Add(-24 * time.Hour), Curtidas: 100}),
+				candidato(2, Sinais{PublicadaEm: agora, Curtidas: 1}),
+			},
+			esperado: []uint64{2, 1},
+		},
+		{
+			nome: "afinidade com o autor desempata o engajamento",
+			candidatos: []Candidato{
+				candidato(1, Sinais{PublicadaEm: agora, Curtidas: 5}),
+				candidato(2, Sinais{PublicadaEm: agora, Curtidas: 5, Interacoes: 4}),
+			},
+			esperado: []uint64{2, 1},
+		},
+		{
+			nome: "empate fica com a publicação mais nova",
+			candidatos: []Candidato{
+				candidato(1, Sinais{PublicadaEm: agora}),
+				candidato(3, Sinais{PublicadaEm: agora}),
+				candidato(2, Sinais{PublicadaEm: agora}),
+			},
+			esperado: []uint64{3, 2, 1},
+		},
+	}
+
+	for _, teste := range testes {
+		t.Run(teste.nome, func(t *testing.T) {
+			Ordenar(teste.candidatos, pesosDeTeste, agora)
+
+			var IDs []uint64
+			for _, candidato := range teste.candidatos {
+				if candidato.Pontuacao != Pontuar(candidato.Sinais, pesosDeTeste, agora) {
+					t.Errorf("a pontuação guardada na publicação %d não é a calculada", candidato.Publicacao.ID)
+				}
+				IDs = append(IDs, candidato.Publicacao.ID)
+			}
+
+			if len(IDs) != len(teste.esperado) {
+				t.Fatalf("esperava %v, recebeu %v", teste.esperado, IDs)
+			}
+			for i := range IDs {
+				if IDs[i] != teste.esperado[i] {
+					t.Fatalf("esperava %v, recebeu %v", teste.esperado, IDs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Anexos representa um repositório de anexos de publicações
+type Anexos struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeAnexos cria um repositório de anexos que faz as consultas no contexto informado
+func NovoRepositorioDeAnexos(ctx context.Context, db *sql.DB) *Anexos {
+	return &Anexos{db, ctx}
+}
+
+// Criar insere os dados de um anexo no banco de dados
+func (repositorio Anexos) Criar(anexo modelos.Anexo) (uint64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Anexos.Criar")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, `
+		insert into anexos (publicacao_id, chave, chaveMiniatura, tipo, tamanho, largura, altura)
+		values (?, ?, ?, ?, ?, ?, ?)`,
+	)
+	if erro != nil {
+		return 0, erro
+	}
+	defer statement.Close()
+
+	resultado, erro := statement.ExecContext(ctx,
+		anexo.PublicacaoID,
+		anexo.Chave,
+		anexo.ChaveMiniatura,
+		anexo.Tipo,
+		anexo.Tamanho,
+		anexo.Largura,
+		anexo.Altura,
+	)
+	if erro != nil {
+		return 0, erro
+	}
+
+	ultimoIDInserido, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+
+	return uint64(ultimoIDInserido), nil
+}
+
+// BuscarPorPublicacoes traz os anexos de várias publicações, agrupados pelo ID da publicação
+func (repositorio Anexos) BuscarPorPublicacoes(publicacaoIDs []uint64) (map[uint64][]modelos.Anexo, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Anexos.BuscarPorPublicacoes")
+	defer span.End()
+
+	anexos := make(map[uint64][]modelos.Anexo)
+	if len(publicacaoIDs) == 0 {
+		return anexos, nil
+	}
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select id, publicacao_id, chave, chaveMiniatura, tipo, tamanho, largura, altura, criadoEm
+		from anexos where publicacao_id in (`+marcadores(len(publicacaoIDs))+`)
+		order by id`,
+		argumentos(publicacaoIDs)...,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	for linhas.Next() {
+		var anexo modelos.Anexo
+
+		if erro = linhas.Scan(
+			&anexo.ID,
+			&anexo.PublicacaoID,
+			&anexo.Chave,
+			&anexo.ChaveMiniatura,
+			&anexo.Tipo,
+			&anexo.Tamanho,
+			&anexo.Largura,
+			&anexo.Altura,
+			&anexo.CriadoEm,
+		); erro != nil {
+			return nil, erro
+		}
+
+		anexos[anexo.PublicacaoID] = append(anexos[anexo.PublicacaoID], anexo)
+	}
+
+	return anexos, linhas.Err()
+}
+
+// Visivel indica se o arquivo da chave, imagem ou miniatura, pertence a um anexo que o usuário pode ver:
+// o de uma publicação visível para todos ou o de uma publicação do próprio usuário. Sem usuário, o ID é zero
+func (repositorio Anexos) Visivel(chave string, usuarioID uint64) (bool, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Anexos.Visivel")
+	defer span.End()
+
+	var visivel bool
+	erro := repositorio.db.QueryRowContext(ctx, `
+		select exists (
+			select 1 from anexos a
+			join publicacoes p on p.id = a.publicacao_id
+			join usuarios u on u.id = p.autor_id
+			where (a.chave = ? or a.chaveMiniatura = ?)
+			and (p.autor_id = ? or (`+filtroPublicadas+`))
+		)`,
+		chave, chave, usuarioID,
+	).Scan(&visivel)
+
+	return visivel, erro
+}
+
+// BuscarChavesPurgaveis traz as chaves dos arquivos de publicações que estão na lixeira desde antes
+// do limite informado, para que sejam apagados do armazenamento junto com elas
+func (repositorio Anexos) BuscarChavesPurgaveis(limite time.Time) ([]string, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Anexos.BuscarChavesPurgaveis")
+	defer span.End()
+
+	return repositorio.buscarChaves(ctx, `
+		select a.chave, a.chaveMiniatura from anexos a
+		join publicacoes p on p.id = a.publicacao_id
+		where p.deletadoEm < ?`,
+		limite,
+	)
+}
+
+// BuscarChavesPorAutor traz as chaves de todos os arquivos anexados às publicações de um usuário
+func (repositorio Anexos) BuscarChavesPorAutor(autorID uint64) ([]string, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Anexos.BuscarChavesPorAutor")
+	defer span.End()
+
+	return repositorio.buscarChaves(ctx, `
+		select a.chave, a.chaveMiniatura from anexos a
+		join publicacoes p on p.id = a.publicacao_id
+		where p.autor_id = ?`,
+		autorID,
+	)
+}
+
+// buscarChaves executa uma consulta que traz a chave e a chave da miniatura de anexos
+func (repositorio Anexos) buscarChaves(ctx context.Context, consulta string, parametros ...interface{}) ([]string, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, consulta, parametros...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var chaves []string
+
+	for linhas.Next() {
+		var chave, chaveMiniatura string
+
+		if erro = linhas.Scan(&chave, &chaveMiniatura); erro != nil {
+			return nil, erro
+		}
+
+		chaves = append(chaves, chave, chaveMiniatura)
+	}
+
+	return chaves, linhas.Err()
+}
+
+// marcadores gera a lista de "?" usada em cláusulas in com a quantidade de valores informada
+func marcadores(quantidade int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", quantidade), ", ")
+}
+
+// argumentos converte uma lista de IDs nos argumentos de uma consulta
+func argumentos(IDs []uint64) []interface{} {
+	valores := make([]interface{}, len(IDs))
+	for i, ID := range IDs {
+		valores[i] = ID
+	}
+
+	return valores
+}
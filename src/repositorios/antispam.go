@@ -0,0 +1,106 @@
+package repositorios
+
+import (
+	"api/src/antispam"
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Antispam representa um repositório com os dados usados para identificar contas que se comportam como spam
+type Antispam struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeAntispam cria um repositório do antispam que faz as consultas no contexto informado
+func NovoRepositorioDeAntispam(ctx context.Context, db *sql.DB) *Antispam {
+	return &Antispam{db, ctx}
+}
+
+// BuscarSinais traz a idade e a situação da conta, quantas publicações ela fez e quantos usuários passou a seguir
+// na janela de velocidade e quantas publicações com o mesmo hash de conteúdo foram feitas na janela de duplicadas.
+// Com o hash vazio, como ao seguir alguém, a contagem de duplicadas fica zerada
+func (repositorio Antispam) BuscarSinais(usuarioID uint64, hashConteudo string, agora time.Time) (antispam.Sinais, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Antispam.BuscarSinais")
+	defer span.End()
+
+	desde := agora.Add(-antispam.JanelaVelocidade)
+
+	var sinais antispam.Sinais
+	erro := repositorio.db.QueryRowContext(ctx, `
+		select u.criadoEm, u.quarentenaEm is not null,
+		(select count(*) from publicacoes where autor_id = u.id and criadaEm >= ?),
+		(select count(*) from seguidores where seguidor_id = u.id and seguidoEm >= ?),
+		(select count(*) from publicacoes where ? <> '' and hashConteudo = ? and criadaEm >= ?)
+		from usuarios u where u.id = ?`,
+		desde, desde, hashConteudo, hashConteudo, agora.Add(-antispam.JanelaDuplicadas), usuarioID,
+	).Scan(
+		&sinais.ContaCriadaEm,
+		&sinais.EmQuarentena,
+		&sinais.PublicacoesRecentes,
+		&sinais.SeguidasRecentes,
+		&sinais.Duplicadas,
+	)
+	if erro == sql.ErrNoRows {
+		return antispam.Sinais{}, nil
+	}
+
+	return sinais, erro
+}
+
+// EmQuarentena indica se a conta está em quarentena
+func (repositorio Antispam) EmQuarentena(usuarioID uint64) (bool, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Antispam.EmQuarentena")
+	defer span.End()
+
+	var emQuarentena bool
+	erro := repositorio.db.QueryRowContext(ctx,
+		"select quarentenaEm is not null from usuarios where id = ?", usuarioID,
+	).Scan(&emQuarentena)
+	if erro == sql.ErrNoRows {
+		return false, nil
+	}
+
+	return emQuarentena, erro
+}
+
+// Quarentenar coloca a conta em quarentena e abre uma denúncia automática para que um moderador decida se a
+// libera. Retorna false quando a conta já estava em quarentena, caso em que nenhuma denúncia nova é aberta
+func (repositorio Antispam) Quarentenar(usuarioID uint64, motivos string) (bool, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Antispam.Quarentenar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return false, erro
+	}
+	defer transacao.Rollback()
+
+	resultado, erro := transacao.ExecContext(ctx,
+		"update usuarios set quarentenaEm = current_timestamp() where id = ? and quarentenaEm is null", usuarioID,
+	)
+	if erro != nil {
+		return false, erro
+	}
+
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
+		return false, erro
+	}
+
+	if linhasAfetadas == 0 {
+		return false, nil
+	}
+
+	if _, erro = transacao.ExecContext(ctx,
+		"insert into denuncias (usuario_id, motivo, descricao) values (?, ?, ?)",
+		usuarioID, modelos.MotivoAntispam, motivos,
+	); erro != nil {
+		return false, erro
+	}
+
+	return true, transacao.Commit()
+}
@@ -0,0 +1,155 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+)
+
+// Auditoria representa um repositório do registro de ações relevantes para a segurança. O registro só
+// recebe inclusões; não há como alterar ou apagar o que já foi gravado
+type Auditoria struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeAuditoria cria um repositório da auditoria que faz as consultas no contexto informado
+func NovoRepositorioDeAuditoria(ctx context.Context, db *sql.DB) *Auditoria {
+	return &Auditoria{db, ctx}
+}
+
+// Registrar grava uma ação na auditoria
+func (repositorio Auditoria) Registrar(registro modelos.RegistroAuditoria) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Auditoria.Registrar")
+	defer span.End()
+
+	depois, erro := comConta(registro)
+	if erro != nil {
+		return erro
+	}
+
+	_, erro = repositorio.db.ExecContext(ctx,
+		`insert into auditoria (acao, ator_id, usuario_id, ip, userAgent, antes, depois)
+		values (?, ?, ?, ?, ?, ?, ?)`,
+		registro.Acao,
+		registro.AtorID,
+		registro.UsuarioID,
+		registro.IP,
+		registro.UserAgent,
+		jsonOuNulo(registro.Antes),
+		jsonOuNulo(depois),
+	)
+	return erro
+}
+
+// Buscar traz os registros que atendem ao filtro, dos mais novos para os mais antigos
+func (repositorio Auditoria) Buscar(filtro modelos.FiltroAuditoria, paginacao modelos.Paginacao) ([]modelos.RegistroAuditoria, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Auditoria.Buscar")
+	defer span.End()
+
+	consulta := `
+		select a.id, a.acao, a.ator_id, coalesce(u.nick, ''), a.usuario_id, a.ip, a.userAgent, a.antes, a.depois, a.criadoEm
+		from auditoria a
+		left join usuarios u on u.id = a.ator_id
+		where 1 = 1`
+	var parametros []interface{}
+
+	if filtro.Acao != "" {
+		consulta += " and a.acao = ?"
+		parametros = append(parametros, filtro.Acao)
+	}
+
+	if filtro.AtorID != 0 {
+		consulta += " and a.ator_id = ?"
+		parametros = append(parametros, filtro.AtorID)
+	}
+
+	if filtro.UsuarioID != 0 {
+		consulta += " and a.usuario_id = ?"
+		parametros = append(parametros, filtro.UsuarioID)
+	}
+
+	if filtro.Desde != nil {
+		consulta += " and a.criadoEm >= ?"
+		parametros = append(parametros, *filtro.Desde)
+	}
+
+	if filtro.Ate != nil {
+		consulta += " and a.criadoEm < ?"
+		parametros = append(parametros, *filtro.Ate)
+	}
+
+	consulta += " order by a.criadoEm desc, a.id desc limit ? offset ?"
+	parametros = append(parametros, paginacao.Limite, paginacao.Deslocamento())
+
+	linhas, erro := repositorio.db.QueryContext(ctx, consulta, parametros...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	registros := []modelos.RegistroAuditoria{}
+	for linhas.Next() {
+		var registro modelos.RegistroAuditoria
+		var antes, depois []byte
+
+		if erro = linhas.Scan(
+			&registro.ID,
+			&registro.Acao,
+			&registro.AtorID,
+			&registro.AtorNick,
+			&registro.UsuarioID,
+			&registro.IP,
+			&registro.UserAgent,
+			&antes,
+			&depois,
+			&registro.CriadoEm,
+		); erro != nil {
+			return nil, erro
+		}
+
+		registro.Antes, registro.Depois = antes, depois
+		registros = append(registros, registro)
+	}
+
+	return registros, linhas.Err()
+}
+
+// comConta acrescenta ao estado final do registro o ID da conta afetada. A chave estrangeira fica nula quando
+// a conta é excluída, e é por esse estado que o registro continua dizendo a quem se referia. O ID de quem fez
+// a ação fica de fora, já que o dono da conta não deve ver quem agiu sobre ela. Um estado que não é um objeto
+// JSON é gravado como veio
+func comConta(registro modelos.RegistroAuditoria) (json.RawMessage, error) {
+	if registro.UsuarioID == nil {
+		return registro.Depois, nil
+	}
+
+	var depois map[string]json.RawMessage
+	if len(registro.Depois) > 0 {
+		if erro := json.Unmarshal(registro.Depois, &depois); erro != nil {
+			return registro.Depois, nil
+		}
+	}
+
+	if depois == nil {
+		depois = make(map[string]json.RawMessage)
+	}
+
+	if _, existe := depois["usuarioId"]; !existe {
+		depois["usuarioId"] = json.RawMessage(strconv.FormatUint(*registro.UsuarioID, 10))
+	}
+
+	return json.Marshal(depois)
+}
+
+// jsonOuNulo converte um JSON vazio em NULL, para que as colunas json não recebam texto em branco
+func jsonOuNulo(valor []byte) interface{} {
+	if len(valor) == 0 {
+		return nil
+	}
+
+	return string(valor)
+}
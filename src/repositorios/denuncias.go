@@ -0,0 +1,379 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrDenunciaDuplicada é retornado quando o usuário denuncia de novo algo que ele já denunciou e ainda não foi resolvido
+	ErrDenunciaDuplicada = errors.New("você já denunciou isso e a denúncia ainda está sendo analisada")
+
+	// ErrDenunciaNaoEncontrada é retornado quando a denúncia pedida não existe
+	ErrDenunciaNaoEncontrada = errors.New("denúncia não encontrada")
+
+	// ErrDenunciaResolvida é retornado quando um moderador tenta mexer em uma denúncia que já foi resolvida
+	ErrDenunciaResolvida = errors.New("esta denúncia já foi resolvida")
+
+	// ErrAcaoSemPublicacao é retornado quando a ação pedida precisa de uma publicação e a denúncia não tem
+	ErrAcaoSemPublicacao = errors.New("esta ação só pode ser usada em denúncias de publicações")
+
+	// ErrAcaoSemUsuario é retornado quando a ação pedida precisa de um usuário e a conta denunciada não existe mais
+	ErrAcaoSemUsuario = errors.New("a conta denunciada não existe mais")
+)
+
+// colunasDenuncia são as colunas lidas sempre que uma denúncia é trazida do banco
+const colunasDenuncia = "id, denunciante_id, usuario_id, publicacao_id, motivo, descricao, status, moderador_id, coalesce(acao, ''), criadaEm, resolvidaEm"
+
+// Denuncias representa um repositório de denúncias e decisões da moderação
+type Denuncias struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeDenuncias cria um repositório de denúncias que faz as consultas no contexto informado
+func NovoRepositorioDeDenuncias(ctx context.Context, db *sql.DB) *Denuncias {
+	return &Denuncias{db, ctx}
+}
+
+// Criar registra uma denúncia. Um usuário não pode denunciar de novo o mesmo alvo enquanto a denúncia
+// anterior não for resolvida
+func (repositorio Denuncias) Criar(denuncia modelos.Denuncia) (uint64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Denuncias.Criar")
+	defer span.End()
+
+	var duplicada bool
+	if erro := repositorio.db.QueryRowContext(ctx, `
+		select exists(
+			select 1 from denuncias
+			where denunciante_id = ? and usuario_id <=> ? and publicacao_id <=> ? and status <> ?
+		)`,
+		denuncia.DenuncianteID, denuncia.UsuarioID, denuncia.PublicacaoID, modelos.StatusDenunciaResolvida,
+	).Scan(&duplicada); erro != nil {
+		return 0, erro
+	}
+
+	if duplicada {
+		return 0, ErrDenunciaDuplicada
+	}
+
+	statement, erro := repositorio.db.PrepareContext(ctx,
+		"insert into denuncias (denunciante_id, usuario_id, publicacao_id, motivo, descricao) values (?, ?, ?, ?, ?)",
+	)
+	if erro != nil {
+		return 0, erro
+	}
+	defer statement.Close()
+
+	resultado, erro := statement.ExecContext(ctx,
+		denuncia.DenuncianteID, denuncia.UsuarioID, denuncia.PublicacaoID, denuncia.Motivo, denuncia.Descricao,
+	)
+	if erro != nil {
+		return 0, erro
+	}
+
+	ultimoIDInserido, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+
+	return uint64(ultimoIDInserido), nil
+}
+
+// Buscar traz a fila de moderação, das denúncias mais antigas para as mais novas. O status e o moderador
+// são opcionais e filtram a fila quando informados
+func (repositorio Denuncias) Buscar(status string, moderadorID uint64, paginacao modelos.Paginacao) ([]modelos.Denuncia, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Denuncias.Buscar")
+	defer span.End()
+
+	consulta := "select " + colunasDenuncia + " from denuncias where 1 = 1"
+	var parametros []interface{}
+
+	if status != "" {
+		consulta += " and status = ?"
+		parametros = append(parametros, status)
+	}
+
+	if moderadorID != 0 {
+		consulta += " and moderador_id = ?"
+		parametros = append(parametros, moderadorID)
+	}
+
+	consulta += " order by criadaEm, id limit ? offset ?"
+	parametros = append(parametros, paginacao.Limite, paginacao.Deslocamento())
+
+	return repositorio.consultar(ctx, consulta, parametros...)
+}
+
+// BuscarPorID traz uma denúncia. Se ela não existir, a denúncia retornada tem ID zero
+func (repositorio Denuncias) BuscarPorID(denunciaID uint64) (modelos.Denuncia, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Denuncias.BuscarPorID")
+	defer span.End()
+
+	denuncias, erro := repositorio.consultar(ctx, "select "+colunasDenuncia+" from denuncias where id = ?", denunciaID)
+	if erro != nil || len(denuncias) == 0 {
+		return modelos.Denuncia{}, erro
+	}
+
+	return denuncias[0], nil
+}
+
+// Atribuir coloca a denúncia sob a responsabilidade de um moderador e registra isso no histórico
+func (repositorio Denuncias) Atribuir(denunciaID, moderadorID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Denuncias.Atribuir")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	denuncia, erro := travarDenuncia(ctx, transacao, denunciaID)
+	if erro != nil {
+		return erro
+	}
+
+	if denuncia.Status == modelos.StatusDenunciaResolvida {
+		return ErrDenunciaResolvida
+	}
+
+	if _, erro = transacao.ExecContext(ctx,
+		"update denuncias set moderador_id = ?, status = ? where id = ?",
+		moderadorID, modelos.StatusDenunciaEmAnalise, denunciaID,
+	); erro != nil {
+		return erro
+	}
+
+	if erro = registrarDecisao(ctx, transacao, denunciaID, moderadorID, modelos.AcaoAtribuir, ""); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+// Resolver aplica a decisão do moderador, encerra a denúncia e registra a decisão no histórico.
+// Retorna a denúncia como estava antes de ser resolvida
+func (repositorio Denuncias) Resolver(denunciaID, moderadorID uint64, resolucao modelos.Resolucao) (modelos.Denuncia, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Denuncias.Resolver")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return modelos.Denuncia{}, erro
+	}
+	defer transacao.Rollback()
+
+	denuncia, erro := travarDenuncia(ctx, transacao, denunciaID)
+	if erro != nil {
+		return modelos.Denuncia{}, erro
+	}
+
+	if denuncia.Status == modelos.StatusDenunciaResolvida {
+		return modelos.Denuncia{}, ErrDenunciaResolvida
+	}
+
+	switch resolucao.Acao {
+	case modelos.AcaoOcultar:
+		if denuncia.PublicacaoID == nil {
+			return modelos.Denuncia{}, ErrAcaoSemPublicacao
+		}
+
+		if erro = ocultarPublicacao(ctx, transacao, *denuncia.PublicacaoID); erro != nil {
+			return modelos.Denuncia{}, erro
+		}
+
+	case modelos.AcaoLiberar:
+		// Em denúncias de publicações a publicação volta a aparecer; nas de usuários a conta sai da quarentena
+		if denuncia.PublicacaoID != nil {
+			if erro = liberarPublicacao(ctx, transacao, *denuncia.PublicacaoID); erro != nil {
+				return modelos.Denuncia{}, erro
+			}
+		} else if denuncia.UsuarioID != nil {
+			if _, erro = transacao.ExecContext(ctx,
+				"update usuarios set quarentenaEm = null where id = ?", *denuncia.UsuarioID,
+			); erro != nil {
+				return modelos.Denuncia{}, erro
+			}
+		} else {
+			return modelos.Denuncia{}, ErrAcaoSemUsuario
+		}
+
+	case modelos.AcaoAdvertir:
+		if denuncia.UsuarioID == nil {
+			return modelos.Denuncia{}, ErrAcaoSemUsuario
+		}
+
+		if _, erro = transacao.ExecContext(ctx,
+			"insert into advertencias (usuario_id, denuncia_id, motivo, observacao) values (?, ?, ?, ?)",
+			*denuncia.UsuarioID, denunciaID, denuncia.Motivo, resolucao.Observacao,
+		); erro != nil {
+			return modelos.Denuncia{}, erro
+		}
+
+	case modelos.AcaoSuspender:
+		if denuncia.UsuarioID == nil {
+			return modelos.Denuncia{}, ErrAcaoSemUsuario
+		}
+
+		// Uma suspensão nova nunca encurta uma que já está em andamento
+		ate := time.Now().AddDate(0, 0, resolucao.DiasSuspensao)
+		if _, erro = transacao.ExecContext(ctx,
+			"update usuarios set suspensoAte = greatest(coalesce(suspensoAte, ?), ?) where id = ?",
+			ate, ate, *denuncia.UsuarioID,
+		); erro != nil {
+			return modelos.Denuncia{}, erro
+		}
+	}
+
+	if _, erro = transacao.ExecContext(ctx,
+		"update denuncias set status = ?, acao = ?, moderador_id = ?, resolvidaEm = current_timestamp() where id = ?",
+		modelos.StatusDenunciaResolvida, resolucao.Acao, moderadorID, denunciaID,
+	); erro != nil {
+		return modelos.Denuncia{}, erro
+	}
+
+	if erro = registrarDecisao(ctx, transacao, denunciaID, moderadorID, resolucao.Acao, resolucao.Observacao); erro != nil {
+		return modelos.Denuncia{}, erro
+	}
+
+	if erro = transacao.Commit(); erro != nil {
+		return modelos.Denuncia{}, erro
+	}
+
+	return denuncia, nil
+}
+
+// BuscarDecisoes traz o histórico de uma denúncia, na ordem em que as decisões foram tomadas
+func (repositorio Denuncias) BuscarDecisoes(denunciaID uint64) ([]modelos.DecisaoModeracao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Denuncias.BuscarDecisoes")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select d.id, d.denuncia_id, d.moderador_id, coalesce(u.nick, ''), d.acao, d.observacao, d.criadaEm
+		from decisoes_moderacao d
+		left join usuarios u on u.id = d.moderador_id
+		where d.denuncia_id = ?
+		order by d.criadaEm, d.id`,
+		denunciaID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	decisoes := []modelos.DecisaoModeracao{}
+	for linhas.Next() {
+		var decisao modelos.DecisaoModeracao
+
+		if erro = linhas.Scan(
+			&decisao.ID,
+			&decisao.DenunciaID,
+			&decisao.ModeradorID,
+			&decisao.ModeradorNick,
+			&decisao.Acao,
+			&decisao.Observacao,
+			&decisao.CriadaEm,
+		); erro != nil {
+			return nil, erro
+		}
+
+		decisoes = append(decisoes, decisao)
+	}
+
+	return decisoes, linhas.Err()
+}
+
+// BuscarAdvertencias traz as advertências que um usuário recebeu, das mais novas para as mais antigas
+func (repositorio Denuncias) BuscarAdvertencias(usuarioID uint64) ([]modelos.Advertencia, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Denuncias.BuscarAdvertencias")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx,
+		"select id, denuncia_id, motivo, observacao, criadaEm from advertencias where usuario_id = ? order by criadaEm desc, id desc",
+		usuarioID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	advertencias := []modelos.Advertencia{}
+	for linhas.Next() {
+		var advertencia modelos.Advertencia
+
+		if erro = linhas.Scan(
+			&advertencia.ID,
+			&advertencia.DenunciaID,
+			&advertencia.Motivo,
+			&advertencia.Observacao,
+			&advertencia.CriadaEm,
+		); erro != nil {
+			return nil, erro
+		}
+
+		advertencias = append(advertencias, advertencia)
+	}
+
+	return advertencias, linhas.Err()
+}
+
+// travarDenuncia lê uma denúncia travando a linha até o fim da transação
+func travarDenuncia(ctx context.Context, transacao *sql.Tx, denunciaID uint64) (modelos.Denuncia, error) {
+	var denuncia modelos.Denuncia
+	erro := transacao.QueryRowContext(ctx,
+		"select id, usuario_id, publicacao_id, motivo, status from denuncias where id = ? for update", denunciaID,
+	).Scan(&denuncia.ID, &denuncia.UsuarioID, &denuncia.PublicacaoID, &denuncia.Motivo, &denuncia.Status)
+	if erro == sql.ErrNoRows {
+		return modelos.Denuncia{}, ErrDenunciaNaoEncontrada
+	}
+
+	return denuncia, erro
+}
+
+// registrarDecisao acrescenta uma entrada ao histórico da denúncia. O histórico só recebe inserções
+func registrarDecisao(ctx context.Context, transacao *sql.Tx, denunciaID, moderadorID uint64, acao, observacao string) error {
+	_, erro := transacao.ExecContext(ctx,
+		"insert into decisoes_moderacao (denuncia_id, moderador_id, acao, observacao) values (?, ?, ?, ?)",
+		denunciaID, moderadorID, acao, observacao,
+	)
+	return erro
+}
+
+// consultar executa uma consulta que traz as colunas de colunasDenuncia
+func (repositorio Denuncias) consultar(ctx context.Context, consulta string, parametros ...interface{}) ([]modelos.Denuncia, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, consulta, parametros...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	denuncias := []modelos.Denuncia{}
+	for linhas.Next() {
+		var denuncia modelos.Denuncia
+
+		if erro = linhas.Scan(
+			&denuncia.ID,
+			&denuncia.DenuncianteID,
+			&denuncia.UsuarioID,
+			&denuncia.PublicacaoID,
+			&denuncia.Motivo,
+			&denuncia.Descricao,
+			&denuncia.Status,
+			&denuncia.ModeradorID,
+			&denuncia.Acao,
+			&denuncia.CriadaEm,
+			&denuncia.ResolvidaEm,
+		); erro != nil {
+			return nil, erro
+		}
+
+		denuncias = append(denuncias, denuncia)
+	}
+
+	return denuncias, linhas.Err()
+}
@@ -0,0 +1,291 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// codigoErroChaveDuplicada é o código de erro do MySQL para violação de chave única
+const codigoErroChaveDuplicada = 1062
+
+var (
+	// ErrVotoDuplicado é retornado quando o usuário tenta votar novamente em uma enquete ou opção
+	ErrVotoDuplicado = errors.New("você já votou nesta enquete")
+)
+
+// Enquetes representa um repositório de enquetes
+type Enquetes struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeEnquetes cria um repositório de enquetes que faz as consultas no contexto informado
+func NovoRepositorioDeEnquetes(ctx context.Context, db *sql.DB) *Enquetes {
+	return &Enquetes{db, ctx}
+}
+
+// Criar insere uma enquete e as suas opções no banco de dados
+func (repositorio Enquetes) Criar(publicacaoID uint64, enquete modelos.Enquete) (modelos.Enquete, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Enquetes.Criar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return modelos.Enquete{}, erro
+	}
+	defer transacao.Rollback()
+
+	if enquete, erro = inserirEnquete(ctx, transacao, publicacaoID, enquete); erro != nil {
+		return modelos.Enquete{}, erro
+	}
+
+	if erro = transacao.Commit(); erro != nil {
+		return modelos.Enquete{}, erro
+	}
+
+	return enquete, nil
+}
+
+// Substituir troca a enquete de uma publicação pela informada, ou só a remove se ela for nil. Serve para os
+// rascunhos e as publicações agendadas, que ainda não podem ter recebido votos
+func (repositorio Enquetes) Substituir(publicacaoID uint64, enquete *modelos.Enquete) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Enquetes.Substituir")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	if _, erro = transacao.ExecContext(ctx, "delete from enquetes where publicacao_id = ?", publicacaoID); erro != nil {
+		return erro
+	}
+
+	if enquete != nil {
+		if _, erro = inserirEnquete(ctx, transacao, publicacaoID, *enquete); erro != nil {
+			return erro
+		}
+	}
+
+	return transacao.Commit()
+}
+
+// inserirEnquete insere a enquete e as suas opções na transação informada e a retorna com os IDs
+func inserirEnquete(ctx context.Context, transacao *sql.Tx, publicacaoID uint64, enquete modelos.Enquete) (modelos.Enquete, error) {
+	resultado, erro := transacao.ExecContext(ctx,
+		"insert into enquetes (publicacao_id, multiplaEscolha, anonima, expiraEm) values (?, ?, ?, ?)",
+		publicacaoID, enquete.MultiplaEscolha, enquete.Anonima, enquete.ExpiraEm,
+	)
+	if erro != nil {
+		return modelos.Enquete{}, erro
+	}
+
+	enqueteID, erro := resultado.LastInsertId()
+	if erro != nil {
+		return modelos.Enquete{}, erro
+	}
+
+	enquete.ID = uint64(enqueteID)
+	enquete.PublicacaoID = publicacaoID
+
+	for i := range enquete.Opcoes {
+		resultado, erro := transacao.ExecContext(ctx,
+			"insert into enquete_opcoes (enquete_id, texto, posicao) values (?, ?, ?)",
+			enquete.ID, enquete.Opcoes[i].Texto, i,
+		)
+		if erro != nil {
+			return modelos.Enquete{}, erro
+		}
+
+		opcaoID, erro := resultado.LastInsertId()
+		if erro != nil {
+			return modelos.Enquete{}, erro
+		}
+
+		enquete.Opcoes[i].ID = uint64(opcaoID)
+	}
+
+	return enquete, nil
+}
+
+// BuscarPorPublicacoes traz as enquetes de várias publicações com os resultados agregados e os votos
+// do usuário informado. Os votantes de cada opção só são trazidos nas enquetes que não são anônimas
+func (repositorio Enquetes) BuscarPorPublicacoes(publicacaoIDs []uint64, usuarioID uint64) (map[uint64]*modelos.Enquete, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Enquetes.BuscarPorPublicacoes")
+	defer span.End()
+
+	enquetes := make(map[uint64]*modelos.Enquete)
+	if len(publicacaoIDs) == 0 {
+		return enquetes, nil
+	}
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select e.id, e.publicacao_id, e.multiplaEscolha, e.anonima, e.expiraEm, e.expiraEm <= current_timestamp(),
+		(select count(distinct v.usuario_id) from enquete_votos v where v.enquete_id = e.id),
+		o.id, o.texto, (select count(*) from enquete_votos v where v.opcao_id = o.id)
+		from enquetes e inner join enquete_opcoes o on o.enquete_id = e.id
+		where e.publicacao_id in (`+marcadores(len(publicacaoIDs))+`)
+		order by e.id, o.posicao`,
+		argumentos(publicacaoIDs)...,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	porOpcao := make(map[uint64]*modelos.Enquete)
+	for linhas.Next() {
+		var enquete modelos.Enquete
+		var opcao modelos.OpcaoEnquete
+
+		if erro = linhas.Scan(
+			&enquete.ID,
+			&enquete.PublicacaoID,
+			&enquete.MultiplaEscolha,
+			&enquete.Anonima,
+			&enquete.ExpiraEm,
+			&enquete.Encerrada,
+			&enquete.TotalVotantes,
+			&opcao.ID,
+			&opcao.Texto,
+			&opcao.Votos,
+		); erro != nil {
+			return nil, erro
+		}
+
+		existente, ok := enquetes[enquete.PublicacaoID]
+		if !ok {
+			existente = &enquete
+			enquetes[enquete.PublicacaoID] = existente
+		}
+
+		existente.Opcoes = append(existente.Opcoes, opcao)
+		porOpcao[opcao.ID] = existente
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return nil, erro
+	}
+
+	if erro = repositorio.buscarVotos(ctx, enquetes, porOpcao, publicacaoIDs, usuarioID); erro != nil {
+		return nil, erro
+	}
+
+	return enquetes, nil
+}
+
+// buscarVotos preenche os votos do usuário e os votantes das enquetes que não são anônimas
+func (repositorio Enquetes) buscarVotos(
+	ctx context.Context,
+	enquetes map[uint64]*modelos.Enquete,
+	porOpcao map[uint64]*modelos.Enquete,
+	publicacaoIDs []uint64,
+	usuarioID uint64,
+) error {
+	if len(enquetes) == 0 {
+		return nil
+	}
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select v.opcao_id, u.id, u.nick, e.anonima from enquete_votos v
+		inner join enquetes e on e.id = v.enquete_id
+		inner join usuarios u on u.id = v.usuario_id
+		where e.publicacao_id in (`+marcadores(len(publicacaoIDs))+`)
+		and (e.anonima = false or v.usuario_id = ?)
+		and u.deletadoEm is null
+		order by v.votadoEm`,
+		append(argumentos(publicacaoIDs), usuarioID)...,
+	)
+	if erro != nil {
+		return erro
+	}
+	defer linhas.Close()
+
+	for linhas.Next() {
+		var opcaoID uint64
+		var anonima bool
+		var votante modelos.Usuario
+
+		if erro = linhas.Scan(&opcaoID, &votante.ID, &votante.Nick, &anonima); erro != nil {
+			return erro
+		}
+
+		enquete, ok := porOpcao[opcaoID]
+		if !ok {
+			continue
+		}
+
+		if votante.ID == usuarioID {
+			enquete.MeusVotos = append(enquete.MeusVotos, opcaoID)
+		}
+
+		if anonima {
+			continue
+		}
+
+		for i := range enquete.Opcoes {
+			if enquete.Opcoes[i].ID == opcaoID {
+				enquete.Opcoes[i].Votantes = append(enquete.Opcoes[i].Votantes, votante)
+			}
+		}
+	}
+
+	return linhas.Err()
+}
+
+// Votar registra os votos de um usuário nas opções escolhidas. A chave primária de enquete_votos
+// impede que o mesmo usuário vote duas vezes, mesmo com requisições simultâneas
+func (repositorio Enquetes) Votar(enquete modelos.Enquete, usuarioID uint64, opcoes []uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Enquetes.Votar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	for _, opcaoID := range opcoes {
+		vaga := uint64(0)
+		if enquete.MultiplaEscolha {
+			vaga = opcaoID
+		}
+
+		if _, erro = transacao.ExecContext(ctx,
+			"insert into enquete_votos (enquete_id, opcao_id, usuario_id, vaga) values (?, ?, ?, ?)",
+			enquete.ID, opcaoID, usuarioID, vaga,
+		); erro != nil {
+			var erroMySQL *mysql.MySQLError
+			if errors.As(erro, &erroMySQL) && erroMySQL.Number == codigoErroChaveDuplicada {
+				return ErrVotoDuplicado
+			}
+			return erro
+		}
+	}
+
+	return transacao.Commit()
+}
+
+// RemoverVotos apaga todos os votos de um usuário em uma enquete
+func (repositorio Enquetes) RemoverVotos(enqueteID, usuarioID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Enquetes.RemoverVotos")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from enquete_votos where enquete_id = ? and usuario_id = ?")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, enqueteID, usuarioID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
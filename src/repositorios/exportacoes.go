@@ -0,0 +1,402 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrExportacaoEmAndamento é retornado quando o usuário pede uma exportação enquanto outra ainda está sendo gerada
+	ErrExportacaoEmAndamento = errors.New("já existe uma exportação dos seus dados em andamento")
+)
+
+// colunasExportacao são as colunas lidas sempre que uma exportação é trazida do banco
+const colunasExportacao = "id, usuario_id, status, chave, solicitadaEm, concluidaEm, expiraEm"
+
+// Exportacoes representa um repositório dos pedidos de exportação de dados pessoais
+type Exportacoes struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeExportacoes cria um repositório de exportações que faz as consultas no contexto informado
+func NovoRepositorioDeExportacoes(ctx context.Context, db *sql.DB) *Exportacoes {
+	return &Exportacoes{db, ctx}
+}
+
+// Criar registra um pedido de exportação para o usuário. O usuário que já tem uma exportação pendente
+// ou sendo gerada precisa esperar ela terminar
+func (repositorio Exportacoes) Criar(usuarioID uint64) (uint64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Exportacoes.Criar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return 0, erro
+	}
+	defer transacao.Rollback()
+
+	var travado uint64
+	if erro = transacao.QueryRowContext(ctx, "select id from usuarios where id = ? for update", usuarioID).Scan(&travado); erro != nil {
+		return 0, erro
+	}
+
+	var emAndamento bool
+	if erro = transacao.QueryRowContext(ctx,
+		"select exists(select 1 from exportacoes where usuario_id = ? and status in (?, ?))",
+		usuarioID, modelos.StatusExportacaoPendente, modelos.StatusExportacaoProcessando,
+	).Scan(&emAndamento); erro != nil {
+		return 0, erro
+	}
+
+	if emAndamento {
+		return 0, ErrExportacaoEmAndamento
+	}
+
+	resultado, erro := transacao.ExecContext(ctx,
+		"insert into exportacoes (usuario_id, status) values (?, ?)", usuarioID, modelos.StatusExportacaoPendente,
+	)
+	if erro != nil {
+		return 0, erro
+	}
+
+	ultimoIDInserido, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+
+	if erro = transacao.Commit(); erro != nil {
+		return 0, erro
+	}
+
+	return uint64(ultimoIDInserido), nil
+}
+
+// BuscarPorID traz uma exportação. Se ela não existir, a exportação retornada tem ID zero
+func (repositorio Exportacoes) BuscarPorID(exportacaoID uint64) (modelos.Exportacao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Exportacoes.BuscarPorID")
+	defer span.End()
+
+	exportacoes, erro := repositorio.consultar(ctx,
+		"select "+colunasExportacao+" from exportacoes where id = ?", exportacaoID,
+	)
+	if erro != nil || len(exportacoes) == 0 {
+		return modelos.Exportacao{}, erro
+	}
+
+	return exportacoes[0], nil
+}
+
+// BuscarPorUsuario traz as exportações de um usuário, das mais novas para as mais antigas
+func (repositorio Exportacoes) BuscarPorUsuario(usuarioID uint64) ([]modelos.Exportacao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Exportacoes.BuscarPorUsuario")
+	defer span.End()
+
+	return repositorio.consultar(ctx,
+		"select "+colunasExportacao+" from exportacoes where usuario_id = ? order by solicitadaEm desc, id desc",
+		usuarioID,
+	)
+}
+
+// Reservar marca como em processamento a exportação pendente mais antiga e a retorna. Exportações que estão
+// em processamento desde antes do limite são consideradas abandonadas e podem ser reservadas de novo.
+// As linhas são travadas com skip locked, então várias instâncias da API podem rodar isso ao mesmo tempo.
+// Se não houver nada a fazer, a exportação retornada tem ID zero
+func (repositorio Exportacoes) Reservar(abandonadasAntesDe time.Time) (modelos.Exportacao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Exportacoes.Reservar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return modelos.Exportacao{}, erro
+	}
+	defer transacao.Rollback()
+
+	var exportacao modelos.Exportacao
+	erro = transacao.QueryRowContext(ctx, `
+		select id, usuario_id, solicitadaEm from exportacoes
+		where status = ? or (status = ? and iniciadaEm < ?)
+		order by solicitadaEm
+		limit 1
+		for update skip locked`,
+		modelos.StatusExportacaoPendente, modelos.StatusExportacaoProcessando, abandonadasAntesDe,
+	).Scan(&exportacao.ID, &exportacao.UsuarioID, &exportacao.SolicitadaEm)
+	if erro == sql.ErrNoRows {
+		return modelos.Exportacao{}, nil
+	}
+	if erro != nil {
+		return modelos.Exportacao{}, erro
+	}
+
+	if _, erro = transacao.ExecContext(ctx,
+		"update exportacoes set status = ?, iniciadaEm = current_timestamp() where id = ?",
+		modelos.StatusExportacaoProcessando, exportacao.ID,
+	); erro != nil {
+		return modelos.Exportacao{}, erro
+	}
+
+	if erro = transacao.Commit(); erro != nil {
+		return modelos.Exportacao{}, erro
+	}
+
+	exportacao.Status = modelos.StatusExportacaoProcessando
+	return exportacao, nil
+}
+
+// Concluir registra que o arquivo da exportação foi gerado e até quando ele pode ser baixado
+func (repositorio Exportacoes) Concluir(exportacaoID uint64, chave string, expiraEm time.Time) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Exportacoes.Concluir")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx,
+		"update exportacoes set status = ?, chave = ?, concluidaEm = current_timestamp(), expiraEm = ? where id = ?",
+	)
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, modelos.StatusExportacaoPronta, chave, expiraEm, exportacaoID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// Falhar registra que não foi possível gerar a exportação
+func (repositorio Exportacoes) Falhar(exportacaoID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Exportacoes.Falhar")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx,
+		"update exportacoes set status = ?, concluidaEm = current_timestamp() where id = ?",
+	)
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, modelos.StatusExportacaoFalhou, exportacaoID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// Devolver põe de volta na fila uma exportação que começou a ser gerada, para que seja reservada de novo
+func (repositorio Exportacoes) Devolver(exportacaoID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Exportacoes.Devolver")
+	defer span.End()
+
+	_, erro := repositorio.db.ExecContext(ctx,
+		"update exportacoes set status = ?, iniciadaEm = null where id = ? and status = ?",
+		modelos.StatusExportacaoPendente, exportacaoID, modelos.StatusExportacaoProcessando,
+	)
+	return erro
+}
+
+// BuscarExpiradas traz as exportações cujo prazo para download já passou
+func (repositorio Exportacoes) BuscarExpiradas(agora time.Time) ([]modelos.Exportacao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Exportacoes.BuscarExpiradas")
+	defer span.End()
+
+	return repositorio.consultar(ctx,
+		"select "+colunasExportacao+" from exportacoes where expiraEm < ?", agora,
+	)
+}
+
+// Deletar apaga o registro de uma exportação
+func (repositorio Exportacoes) Deletar(exportacaoID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Exportacoes.Deletar")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from exportacoes where id = ?")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, exportacaoID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// BuscarDadosPessoais reúne os dados guardados sobre um usuário, incluindo as publicações que estão
+// em rascunho, agendadas ou na lixeira
+func (repositorio Exportacoes) BuscarDadosPessoais(usuarioID uint64) (modelos.DadosPessoais, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Exportacoes.BuscarDadosPessoais")
+	defer span.End()
+
+	var dados modelos.DadosPessoais
+
+	if erro := repositorio.db.QueryRowContext(ctx,
+		"select id, nome, nick, email, criadoEm, deletadoEm from usuarios where id = ?", usuarioID,
+	).Scan(
+		&dados.Perfil.ID,
+		&dados.Perfil.Nome,
+		&dados.Perfil.Nick,
+		&dados.Perfil.Email,
+		&dados.Perfil.CriadoEm,
+		&dados.Perfil.DeletadoEm,
+	); erro != nil {
+		return modelos.DadosPessoais{}, erro
+	}
+
+	consultas := []struct {
+		consulta string
+		ler      func(*sql.Rows) error
+	}{
+		{
+			"select nick, trocadoEm from nicks_anteriores where usuario_id = ? order by trocadoEm",
+			func(linhas *sql.Rows) error {
+				var nick modelos.NickAnterior
+				erro := linhas.Scan(&nick.Nick, &nick.TrocadoEm)
+				dados.NicksAnteriores = append(dados.NicksAnteriores, nick)
+				return erro
+			},
+		},
+		{
+			"select " + colunasPublicacao + " from publicacoes p inner join usuarios u on u.id = p.autor_id where p.autor_id = ? order by p.id",
+			func(linhas *sql.Rows) error {
+				publicacao, erro := escanearPublicacao(linhas)
+				dados.Publicacoes = append(dados.Publicacoes, publicacao)
+				return erro
+			},
+		},
+		{
+			"select publicacao_id, tipo, reagidoEm from reacoes where usuario_id = ? order by reagidoEm",
+			func(linhas *sql.Rows) error {
+				var reacao modelos.ReacaoExportada
+				erro := linhas.Scan(&reacao.PublicacaoID, &reacao.Tipo, &reacao.ReagidoEm)
+				dados.Reacoes = append(dados.Reacoes, reacao)
+				return erro
+			},
+		},
+		{
+			"select id, nome, usuario_id, criadaEm from colecoes where usuario_id = ? order by id",
+			func(linhas *sql.Rows) error {
+				var colecao modelos.Colecao
+				erro := linhas.Scan(&colecao.ID, &colecao.Nome, &colecao.UsuarioID, &colecao.CriadaEm)
+				dados.Colecoes = append(dados.Colecoes, colecao)
+				return erro
+			},
+		},
+		{
+			"select publicacao_id, colecao_id, salvoEm from salvos where usuario_id = ? order by salvoEm",
+			func(linhas *sql.Rows) error {
+				var salvo modelos.SalvoExportado
+				erro := linhas.Scan(&salvo.PublicacaoID, &salvo.ColecaoID, &salvo.SalvoEm)
+				dados.Salvos = append(dados.Salvos, salvo)
+				return erro
+			},
+		},
+		{
+			`select e.publicacao_id, o.texto, v.votadoEm from enquete_votos v
+			inner join enquetes e on e.id = v.enquete_id
+			inner join enquete_opcoes o on o.id = v.opcao_id
+			where v.usuario_id = ? order by v.votadoEm`,
+			func(linhas *sql.Rows) error {
+				var voto modelos.VotoExportado
+				erro := linhas.Scan(&voto.PublicacaoID, &voto.Opcao, &voto.VotadoEm)
+				dados.Votos = append(dados.Votos, voto)
+				return erro
+			},
+		},
+		{
+			`select u.id, u.nick from seguidores s inner join usuarios u on u.id = s.seguidor_id
+			where s.usuario_id = ? order by u.id`,
+			func(linhas *sql.Rows) error {
+				var seguidor modelos.Usuario
+				erro := linhas.Scan(&seguidor.ID, &seguidor.Nick)
+				dados.Seguidores = append(dados.Seguidores, seguidor)
+				return erro
+			},
+		},
+		{
+			`select u.id, u.nick from seguidores s inner join usuarios u on u.id = s.usuario_id
+			where s.seguidor_id = ? order by u.id`,
+			func(linhas *sql.Rows) error {
+				var seguido modelos.Usuario
+				erro := linhas.Scan(&seguido.ID, &seguido.Nick)
+				dados.Seguindo = append(dados.Seguindo, seguido)
+				return erro
+			},
+		},
+		{
+			"select palavra from palavras_silenciadas where usuario_id = ? order by palavra",
+			func(linhas *sql.Rows) error {
+				var palavra string
+				erro := linhas.Scan(&palavra)
+				dados.Silenciadas = append(dados.Silenciadas, palavra)
+				return erro
+			},
+		},
+	}
+
+	for _, c := range consultas {
+		if erro := repositorio.percorrer(ctx, c.consulta, c.ler, usuarioID); erro != nil {
+			return modelos.DadosPessoais{}, erro
+		}
+	}
+
+	return dados, nil
+}
+
+// percorrer executa uma consulta e chama a função de leitura para cada linha retornada
+func (repositorio Exportacoes) percorrer(ctx context.Context, consulta string, ler func(*sql.Rows) error, parametros ...interface{}) error {
+	linhas, erro := repositorio.db.QueryContext(ctx, consulta, parametros...)
+	if erro != nil {
+		return erro
+	}
+	defer linhas.Close()
+
+	for linhas.Next() {
+		if erro = ler(linhas); erro != nil {
+			return erro
+		}
+	}
+
+	return linhas.Err()
+}
+
+// consultar executa uma consulta que traz as colunas de colunasExportacao
+func (repositorio Exportacoes) consultar(ctx context.Context, consulta string, parametros ...interface{}) ([]modelos.Exportacao, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, consulta, parametros...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	exportacoes := []modelos.Exportacao{}
+	for linhas.Next() {
+		var exportacao modelos.Exportacao
+		var chave sql.NullString
+
+		if erro = linhas.Scan(
+			&exportacao.ID,
+			&exportacao.UsuarioID,
+			&exportacao.Status,
+			&chave,
+			&exportacao.SolicitadaEm,
+			&exportacao.ConcluidaEm,
+			&exportacao.ExpiraEm,
+		); erro != nil {
+			return nil, erro
+		}
+
+		exportacao.Chave = chave.String
+		exportacoes = append(exportacoes, exportacao)
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return nil, erro
+	}
+
+	return exportacoes, nil
+}
@@ -0,0 +1,175 @@
+package repositorios
+
+import (
+	"api/src/rastreamento"
+	"api/src/triagem"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrPalavrasSilenciadasDemais é retornado quando o usuário já silenciou a quantidade máxima de palavras
+var ErrPalavrasSilenciadasDemais = errors.New("você já silenciou a quantidade máxima de palavras")
+
+// Filtros representa um repositório das regras de filtro de conteúdo e das palavras silenciadas pelos usuários
+type Filtros struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeFiltros cria um repositório de filtros que faz as consultas no contexto informado
+func NovoRepositorioDeFiltros(ctx context.Context, db *sql.DB) *Filtros {
+	return &Filtros{db, ctx}
+}
+
+// BuscarRegras traz todas as regras de filtro, na ordem em que foram criadas
+func (repositorio Filtros) BuscarRegras() ([]triagem.Regra, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Filtros.BuscarRegras")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, "select id, tipo, padrao, acao, criadaEm from regras_filtro order by id")
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	regras := []triagem.Regra{}
+	for linhas.Next() {
+		var regra triagem.Regra
+
+		if erro = linhas.Scan(&regra.ID, &regra.Tipo, &regra.Padrao, &regra.Acao, &regra.CriadaEm); erro != nil {
+			return nil, erro
+		}
+
+		regras = append(regras, regra)
+	}
+
+	return regras, linhas.Err()
+}
+
+// CriarRegra grava uma regra de filtro, registrando o moderador que a criou
+func (repositorio Filtros) CriarRegra(regra triagem.Regra, moderadorID uint64) (uint64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Filtros.CriarRegra")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx,
+		"insert into regras_filtro (tipo, padrao, acao, moderador_id) values (?, ?, ?, ?)",
+	)
+	if erro != nil {
+		return 0, erro
+	}
+	defer statement.Close()
+
+	resultado, erro := statement.ExecContext(ctx, regra.Tipo, regra.Padrao, regra.Acao, moderadorID)
+	if erro != nil {
+		return 0, erro
+	}
+
+	ultimoIDInserido, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+
+	return uint64(ultimoIDInserido), nil
+}
+
+// DeletarRegra apaga uma regra de filtro
+func (repositorio Filtros) DeletarRegra(regraID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Filtros.DeletarRegra")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from regras_filtro where id = ?")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, regraID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// BuscarPalavrasSilenciadas traz as palavras que o usuário silenciou, em ordem alfabética
+func (repositorio Filtros) BuscarPalavrasSilenciadas(usuarioID uint64) ([]string, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Filtros.BuscarPalavrasSilenciadas")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx,
+		"select palavra from palavras_silenciadas where usuario_id = ? order by palavra", usuarioID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	palavras := []string{}
+	for linhas.Next() {
+		var palavra string
+
+		if erro = linhas.Scan(&palavra); erro != nil {
+			return nil, erro
+		}
+
+		palavras = append(palavras, palavra)
+	}
+
+	return palavras, linhas.Err()
+}
+
+// SilenciarPalavra esconde do feed do usuário as publicações que contêm a palavra inteira. Silenciar de novo
+// uma palavra que já estava silenciada não faz nada
+func (repositorio Filtros) SilenciarPalavra(usuarioID uint64, palavra string, maximo int) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Filtros.SilenciarPalavra")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	var travado uint64
+	if erro = transacao.QueryRowContext(ctx, "select id from usuarios where id = ? for update", usuarioID).Scan(&travado); erro != nil {
+		return erro
+	}
+
+	var quantidade int
+	if erro = transacao.QueryRowContext(ctx,
+		"select count(*) from palavras_silenciadas where usuario_id = ? and palavra <> ?", usuarioID, palavra,
+	).Scan(&quantidade); erro != nil {
+		return erro
+	}
+
+	if quantidade >= maximo {
+		return ErrPalavrasSilenciadasDemais
+	}
+
+	if _, erro = transacao.ExecContext(ctx,
+		"insert ignore into palavras_silenciadas (usuario_id, palavra, padrao) values (?, ?, ?)",
+		usuarioID, palavra, triagem.PadraoPalavraInteira(palavra),
+	); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+// RemoverPalavraSilenciada volta a mostrar no feed do usuário as publicações que contêm a palavra
+func (repositorio Filtros) RemoverPalavraSilenciada(usuarioID uint64, palavra string) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Filtros.RemoverPalavraSilenciada")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from palavras_silenciadas where usuario_id = ? and palavra = ?")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, usuarioID, palavra); erro != nil {
+		return erro
+	}
+
+	return nil
+}
@@ -1,14 +1,22 @@
 package repositorios
 
-import "api/src/modelos"
+import (
+	"api/src/antispam"
+	"api/src/modelos"
+	"api/src/relevancia"
+	"api/src/triagem"
+	"context"
+	"time"
+)
 
 // IUsuarioRepository define as operações disponíveis para o repositório de usuários
 type IUsuarioRepository interface {
-	Criar(usuario modelos.Usuario) (uint64, error)
+	Criar(usuario modelos.Usuario, reservaNick time.Duration) (uint64, error)
 	Buscar(nomeOuNick string) ([]modelos.Usuario, error)
 	BuscarPorID(ID uint64) (modelos.Usuario, error)
-	Atualizar(ID uint64, usuario modelos.Usuario) error
-	Deletar(ID uint64) error
+	Atualizar(ID uint64, usuario modelos.Usuario, intervaloTrocaNick, reservaNick time.Duration) error
+	Desativar(ID uint64) error
+	AgendarExclusao(ID uint64, exclusaoEm time.Time) (time.Time, error)
 	BuscarPorEmail(email string) (modelos.Usuario, error)
 	Seguir(usuarioID, seguidorID uint64) error
 	PararDeSeguir(usuarioID, seguidorID uint64) error
@@ -16,16 +24,158 @@ type IUsuarioRepository interface {
 	BuscarSeguindo(usuarioID uint64) ([]modelos.Usuario, error)
 	BuscarSenha(usuarioID uint64) (string, error)
 	AtualizarSenha(usuarioID uint64, senha string) error
+	Reativar(ID uint64) error
+	BuscarExclusoesVencidas(agora time.Time) ([]uint64, error)
+	Excluir(ID uint64) (bool, error)
+	BuscarPerfil(usuarioID, visitanteID uint64, quantidadeEmComum int) (modelos.Perfil, error)
+	BuscarPorNick(nick string) (uint64, string, error)
+	EhModerador(usuarioID uint64) (bool, error)
+	EhAdministrador(usuarioID uint64) (bool, error)
+	DefinirModerador(usuarioID uint64, moderador bool) (bool, error)
+	BuscarSituacao(usuarioID uint64) (modelos.Usuario, error)
 }
 
 // IPublicacaoRepository define as operações disponíveis para o repositório de publicações
 type IPublicacaoRepository interface {
 	Criar(publicacao modelos.Publicacao) (uint64, error)
 	BuscarPorID(publicacaoID uint64) (modelos.Publicacao, error)
-	Buscar(usuarioID uint64) ([]modelos.Publicacao, error)
+	BuscarCandidatosRelevantes(usuarioID uint64, desde time.Time, limite int) ([]relevancia.Candidato, error)
 	Atualizar(publicacaoID uint64, publicacao modelos.Publicacao) error
+	Ocultar(publicacaoID uint64) error
 	Deletar(publicacaoID uint64) error
-	BuscarPorUsuario(usuarioID uint64) ([]modelos.Publicacao, error)
+	BuscarPorUsuario(usuarioID uint64, paginacao modelos.Paginacao) ([]modelos.Publicacao, error)
 	Curtir(publicacaoID uint64) error
 	Descurtir(publicacaoID uint64) error
-} 
\ No newline at end of file
+	BuscarLixeira(usuarioID uint64) ([]modelos.Publicacao, error)
+	Restaurar(publicacaoID, usuarioID uint64) error
+	Purgar(limite time.Time) (int64, error)
+	BuscarRascunhos(usuarioID uint64) ([]modelos.Publicacao, error)
+	BuscarRascunhoPorID(publicacaoID uint64) (modelos.Publicacao, error)
+	AtualizarRascunho(publicacaoID uint64, publicacao modelos.Publicacao) error
+	PublicarAgendadas(agora time.Time) ([]uint64, error)
+	Fixar(publicacaoID, autorID uint64) error
+	Desafixar(publicacaoID, autorID uint64) error
+}
+
+// IAnexoRepository define as operações disponíveis para o repositório de anexos
+type IAnexoRepository interface {
+	Criar(anexo modelos.Anexo) (uint64, error)
+	BuscarPorPublicacoes(publicacaoIDs []uint64) (map[uint64][]modelos.Anexo, error)
+	Visivel(chave string, usuarioID uint64) (bool, error)
+	BuscarChavesPurgaveis(limite time.Time) ([]string, error)
+	BuscarChavesPorAutor(autorID uint64) ([]string, error)
+}
+
+// ITrechoRepository define as operações disponíveis para o repositório de trechos de código
+type ITrechoRepository interface {
+	Substituir(publicacaoID uint64, trechos []modelos.Trecho) ([]modelos.Trecho, error)
+	BuscarPorPublicacoes(publicacaoIDs []uint64) (map[uint64][]modelos.Trecho, error)
+}
+
+// IEnqueteRepository define as operações disponíveis para o repositório de enquetes
+type IEnqueteRepository interface {
+	Criar(publicacaoID uint64, enquete modelos.Enquete) (modelos.Enquete, error)
+	Substituir(publicacaoID uint64, enquete *modelos.Enquete) error
+	BuscarPorPublicacoes(publicacaoIDs []uint64, usuarioID uint64) (map[uint64]*modelos.Enquete, error)
+	Votar(enquete modelos.Enquete, usuarioID uint64, opcoes []uint64) error
+	RemoverVotos(enqueteID, usuarioID uint64) error
+}
+
+// ISalvoRepository define as operações disponíveis para o repositório de publicações salvas e coleções
+type ISalvoRepository interface {
+	Salvar(usuarioID, publicacaoID uint64, colecaoID *uint64) error
+	RemoverSalvo(usuarioID, publicacaoID uint64) error
+	BuscarSalvos(usuarioID uint64, colecaoID *uint64, paginacao modelos.Paginacao) ([]modelos.Salvo, error)
+	CriarColecao(colecao modelos.Colecao) (uint64, error)
+	BuscarColecoes(usuarioID uint64) ([]modelos.Colecao, error)
+	BuscarColecaoPorID(colecaoID uint64) (modelos.Colecao, error)
+	AtualizarColecao(colecaoID uint64, colecao modelos.Colecao) error
+	DeletarColecao(colecaoID uint64) error
+}
+
+// IReacaoRepository define as operações disponíveis para o repositório de reações
+type IReacaoRepository interface {
+	Reagir(publicacaoID, usuarioID uint64, tipo string) error
+	RemoverReacao(publicacaoID, usuarioID uint64) error
+	ContarPorPublicacoes(publicacaoIDs []uint64, usuarioID uint64) (map[uint64]map[string]uint64, map[uint64]string, error)
+	BuscarPorPublicacao(publicacaoID uint64, tipo string, paginacao modelos.Paginacao) ([]modelos.Reacao, error)
+}
+
+// ILinhaDoTempoRepository define as operações disponíveis para o repositório de linhas do tempo
+type ILinhaDoTempoRepository interface {
+	Distribuir(publicacaoID uint64, limiteSeguidores int) error
+	Preencher(usuarioID, autorID uint64, quantidade int) error
+	RemoverAutor(usuarioID, autorID uint64) error
+	RemoverPublicacao(publicacaoID uint64) error
+	Buscar(usuarioID uint64, limiteSeguidores int, paginacao modelos.Paginacao) ([]modelos.Publicacao, error)
+	Reconstruir(usuarioID uint64, limiteSeguidores, tamanho int) error
+	BuscarUsuarios() ([]uint64, error)
+}
+
+// ISugestaoRepository define as operações disponíveis para o repositório de sugestões de quem seguir
+type ISugestaoRepository interface {
+	BuscarEmCache(usuarioID uint64) ([]modelos.Sugestao, time.Time, error)
+	Recalcular(usuarioID uint64, quantidade int) ([]modelos.Sugestao, error)
+	BuscarDesatualizados(limite time.Time, quantidade int) ([]uint64, error)
+}
+
+// ITendenciaRepository define as operações disponíveis para o repositório de tendências
+type ITendenciaRepository interface {
+	BuscarEngajamento(desde time.Time, pesos relevancia.Pesos, limite int) ([]modelos.Engajamento, error)
+	Salvar(janela string, publicacoes []modelos.Engajamento, hashtags []modelos.Hashtag) error
+	Buscar(janela string) (modelos.Tendencias, error)
+}
+
+// IExportacaoRepository define as operações disponíveis para o repositório de exportações de dados pessoais
+type IExportacaoRepository interface {
+	Criar(usuarioID uint64) (uint64, error)
+	BuscarPorID(exportacaoID uint64) (modelos.Exportacao, error)
+	BuscarPorUsuario(usuarioID uint64) ([]modelos.Exportacao, error)
+	Reservar(abandonadasAntesDe time.Time) (modelos.Exportacao, error)
+	Concluir(exportacaoID uint64, chave string, expiraEm time.Time) error
+	Falhar(exportacaoID uint64) error
+	Devolver(exportacaoID uint64) error
+	BuscarExpiradas(agora time.Time) ([]modelos.Exportacao, error)
+	Deletar(exportacaoID uint64) error
+	BuscarDadosPessoais(usuarioID uint64) (modelos.DadosPessoais, error)
+}
+
+// IDenunciaRepository define as operações disponíveis para o repositório de denúncias e moderação
+type IDenunciaRepository interface {
+	Criar(denuncia modelos.Denuncia) (uint64, error)
+	Buscar(status string, moderadorID uint64, paginacao modelos.Paginacao) ([]modelos.Denuncia, error)
+	BuscarPorID(denunciaID uint64) (modelos.Denuncia, error)
+	Atribuir(denunciaID, moderadorID uint64) error
+	Resolver(denunciaID, moderadorID uint64, resolucao modelos.Resolucao) (modelos.Denuncia, error)
+	BuscarDecisoes(denunciaID uint64) ([]modelos.DecisaoModeracao, error)
+	BuscarAdvertencias(usuarioID uint64) ([]modelos.Advertencia, error)
+}
+
+// IFiltroRepository define as operações disponíveis para o repositório de filtros de conteúdo
+type IFiltroRepository interface {
+	BuscarRegras() ([]triagem.Regra, error)
+	CriarRegra(regra triagem.Regra, moderadorID uint64) (uint64, error)
+	DeletarRegra(regraID uint64) error
+	BuscarPalavrasSilenciadas(usuarioID uint64) ([]string, error)
+	SilenciarPalavra(usuarioID uint64, palavra string, maximo int) error
+	RemoverPalavraSilenciada(usuarioID uint64, palavra string) error
+}
+
+// IAntispamRepository define as operações disponíveis para o repositório do antispam
+type IAntispamRepository interface {
+	BuscarSinais(usuarioID uint64, hashConteudo string, agora time.Time) (antispam.Sinais, error)
+	EmQuarentena(usuarioID uint64) (bool, error)
+	Quarentenar(usuarioID uint64, motivos string) (bool, error)
+}
+
+// IAuditoriaRepository define as operações disponíveis para o repositório da auditoria
+type IAuditoriaRepository interface {
+	Registrar(registro modelos.RegistroAuditoria) error
+	Buscar(filtro modelos.FiltroAuditoria, paginacao modelos.Paginacao) ([]modelos.RegistroAuditoria, error)
+}
+
+// ISaudeRepository define as verificações do banco usadas pelo /readyz
+type ISaudeRepository interface {
+	Pingar(ctx context.Context) error
+	BuscarVersaoEsquema(ctx context.Context) (int, error)
+}
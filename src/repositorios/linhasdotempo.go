@@ -0,0 +1,230 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+)
+
+// LinhasDoTempo representa um repositório das linhas do tempo pré-calculadas. Cada usuário tem uma lista
+// com os IDs das publicações que devem aparecer no seu feed, preenchida quando elas são publicadas.
+// Autores com mais seguidores do que o limite informado não são distribuídos, e as publicações deles
+// são buscadas na hora da leitura
+type LinhasDoTempo struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeLinhasDoTempo cria um repositório de linhas do tempo que faz as consultas no contexto informado
+func NovoRepositorioDeLinhasDoTempo(ctx context.Context, db *sql.DB) *LinhasDoTempo {
+	return &LinhasDoTempo{db, ctx}
+}
+
+// Distribuir coloca uma publicação na linha do tempo do autor e, se ele não tiver mais seguidores do que
+// o limite, na linha do tempo de cada um dos seus seguidores
+func (repositorio LinhasDoTempo) Distribuir(publicacaoID uint64, limiteSeguidores int) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "LinhasDoTempo.Distribuir")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, `
+		insert ignore into linha_do_tempo (usuario_id, publicacao_id, autor_id, publicadaEm)
+		select p.autor_id, p.id, p.autor_id, p.criadaEm from publicacoes p
+		where p.id = ? and p.status = '`+modelos.StatusPublicada+`' and p.deletadoEm is null and p.ocultadaEm is null
+		union all
+		select s.seguidor_id, p.id, p.autor_id, p.criadaEm from publicacoes p
+		inner join usuarios a on a.id = p.autor_id
+		inner join seguidores s on s.usuario_id = p.autor_id
+		where p.id = ? and p.status = '`+modelos.StatusPublicada+`' and p.deletadoEm is null and p.ocultadaEm is null
+		and a.totalSeguidores <= ?`,
+	)
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, publicacaoID, publicacaoID, limiteSeguidores); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// Preencher coloca na linha do tempo de um usuário as publicações mais recentes de um autor que ele
+// acabou de seguir
+func (repositorio LinhasDoTempo) Preencher(usuarioID, autorID uint64, quantidade int) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "LinhasDoTempo.Preencher")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, `
+		insert ignore into linha_do_tempo (usuario_id, publicacao_id, autor_id, publicadaEm)
+		select ?, p.id, p.autor_id, p.criadaEm from publicacoes p
+		where p.autor_id = ? and p.status = '`+modelos.StatusPublicada+`' and p.deletadoEm is null and p.ocultadaEm is null
+		order by p.criadaEm desc
+		limit ?`,
+	)
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, usuarioID, autorID, quantidade); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// RemoverAutor tira da linha do tempo de um usuário as publicações de um autor que ele deixou de seguir
+func (repositorio LinhasDoTempo) RemoverAutor(usuarioID, autorID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "LinhasDoTempo.RemoverAutor")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from linha_do_tempo where usuario_id = ? and autor_id = ?")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, usuarioID, autorID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// RemoverPublicacao tira uma publicação de todas as linhas do tempo
+func (repositorio LinhasDoTempo) RemoverPublicacao(publicacaoID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "LinhasDoTempo.RemoverPublicacao")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from linha_do_tempo where publicacao_id = ?")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, publicacaoID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// Buscar traz uma página do feed de um usuário, juntando a sua linha do tempo com as publicações dos
+// autores seguidos que têm mais seguidores do que o limite e por isso não foram distribuídas. Desses autores
+// só são lidas as publicações mais recentes que ainda podem cair na página pedida. As publicações com
+// palavras que o usuário silenciou ficam de fora
+func (repositorio LinhasDoTempo) Buscar(usuarioID uint64, limiteSeguidores int, paginacao modelos.Paginacao) ([]modelos.Publicacao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "LinhasDoTempo.Buscar")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select `+colunasPublicacao+` from (
+			select publicacao_id, publicadaEm from linha_do_tempo where usuario_id = ?
+			union
+			(
+				select p.id, p.criadaEm from seguidores s
+				inner join usuarios u on u.id = s.usuario_id
+				inner join publicacoes p on p.autor_id = s.usuario_id
+				where s.seguidor_id = ? and u.totalSeguidores > ?
+				and `+filtroPublicadas+` and `+filtroSilenciadas+`
+				order by p.criadaEm desc, p.id desc
+				limit ?
+			)
+		) lt
+		inner join publicacoes p on p.id = lt.publicacao_id
+		inner join usuarios u on u.id = p.autor_id
+		where `+filtroPublicadas+` and `+filtroSilenciadas+`
+		order by lt.publicadaEm desc, p.id desc
+		limit ? offset ?`,
+		usuarioID, usuarioID, limiteSeguidores, usuarioID, paginacao.Deslocamento()+paginacao.Limite,
+		usuarioID, paginacao.Limite, paginacao.Deslocamento(),
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	publicacoes := []modelos.Publicacao{}
+	for linhas.Next() {
+		publicacao, erro := escanearPublicacao(linhas)
+		if erro != nil {
+			return nil, erro
+		}
+
+		publicacoes = append(publicacoes, publicacao)
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return nil, erro
+	}
+
+	return publicacoes, nil
+}
+
+// Reconstruir apaga e monta de novo a linha do tempo de um usuário com as suas publicações e as dos
+// autores que ele segue, mantendo as mais recentes até o tamanho informado
+func (repositorio LinhasDoTempo) Reconstruir(usuarioID uint64, limiteSeguidores, tamanho int) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "LinhasDoTempo.Reconstruir")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	if _, erro = transacao.ExecContext(ctx, "delete from linha_do_tempo where usuario_id = ?", usuarioID); erro != nil {
+		return erro
+	}
+
+	if _, erro = transacao.ExecContext(ctx, `
+		insert into linha_do_tempo (usuario_id, publicacao_id, autor_id, publicadaEm)
+		select ?, p.id, p.autor_id, p.criadaEm from publicacoes p
+		where (
+			p.autor_id = ?
+			or p.autor_id in (
+				select s.usuario_id from seguidores s
+				inner join usuarios a on a.id = s.usuario_id
+				where s.seguidor_id = ? and a.totalSeguidores <= ?
+			)
+		)
+		and p.status = '`+modelos.StatusPublicada+`' and p.deletadoEm is null and p.ocultadaEm is null
+		order by p.criadaEm desc
+		limit ?`,
+		usuarioID, usuarioID, usuarioID, limiteSeguidores, tamanho,
+	); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+// BuscarUsuarios traz os IDs dos usuários ativos que têm linha do tempo, em ordem crescente
+func (repositorio LinhasDoTempo) BuscarUsuarios() ([]uint64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "LinhasDoTempo.BuscarUsuarios")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, "select id from usuarios where deletadoEm is null order by id")
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var IDs []uint64
+	for linhas.Next() {
+		var ID uint64
+		if erro = linhas.Scan(&ID); erro != nil {
+			return nil, erro
+		}
+
+		IDs = append(IDs, ID)
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return nil, erro
+	}
+
+	return IDs, nil
+}
@@ -1,31 +1,86 @@
 package repositorios
 
 import (
+	"api/src/antispam"
 	"api/src/modelos"
+	"api/src/rastreamento"
+	"api/src/relevancia"
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 )
 
+var (
+	// ErrPublicacaoNaoEncontradaNaLixeira é retornado quando a publicação a ser restaurada não está na lixeira do usuário
+	ErrPublicacaoNaoEncontradaNaLixeira = errors.New("publicação não encontrada na lixeira")
+
+	// ErrLimiteDeFixadas é retornado quando o usuário tenta fixar mais publicações do que o permitido
+	ErrLimiteDeFixadas = fmt.Errorf("é possível fixar no máximo %d publicações", MaximoFixadas)
+)
+
+// MaximoFixadas é a quantidade máxima de publicações que um usuário pode fixar no seu perfil
+const MaximoFixadas = 3
+
+// filtroPublicadas restringe uma consulta às publicações visíveis para os demais usuários
+const filtroPublicadas = "p.status = '" + modelos.StatusPublicada + "' and p.deletadoEm is null and p.ocultadaEm is null and u.deletadoEm is null"
+
+// filtroSilenciadas tira da consulta as publicações de outros autores que contêm alguma palavra silenciada
+// pelo usuário passado como parâmetro. Como nas regras de filtro, só a palavra inteira conta: silenciar "go"
+// não esconde "google". O padrão de cada palavra é montado por triagem.PadraoPalavraInteira
+const filtroSilenciadas = `not exists (
+	select 1 from palavras_silenciadas ps
+	where ps.usuario_id = ? and p.autor_id <> ps.usuario_id
+	and (regexp_like(p.titulo, ps.padrao) or regexp_like(p.conteudo, ps.padrao))
+)`
+
+// colunasPublicacao são as colunas lidas sempre que uma publicação é trazida do banco
+const colunasPublicacao = "p.id, p.titulo, p.conteudo, p.autor_id, p.curtidas, p.criadaEm, p.deletadoEm, p.status, p.publicarEm, p.fixadaEm is not null, u.nick"
+
+// ajustarTotalPublicacoes soma o valor informado ao contador de publicações do autor, se a publicação
+// estiver publicada, fora da lixeira e não tiver sido ocultada pela moderação. Deve rodar na mesma transação
+// da operação que muda a publicação
+const ajustarTotalPublicacoes = `
+	update usuarios set totalPublicacoes = greatest(totalPublicacoes + ?, 0)
+	where id = (
+		select autor_id from publicacoes
+		where id = ? and status = '` + modelos.StatusPublicada + `' and deletadoEm is null and ocultadaEm is null
+	)`
+
 // Publicacoes representa um repositório de publicações
 type Publicacoes struct {
-	db *sql.DB
+	db  *sql.DB
+	ctx context.Context
 }
 
-// NovoRepositorioDePublicacoes cria um repositório de publicações
-func NovoRepositorioDePublicacoes(db *sql.DB) *Publicacoes {
-	return &Publicacoes{db}
+// NovoRepositorioDePublicacoes cria um repositório de publicações que faz as consultas no contexto informado
+func NovoRepositorioDePublicacoes(ctx context.Context, db *sql.DB) *Publicacoes {
+	return &Publicacoes{db, ctx}
 }
 
 // Criar insere uma publicação no banco de dados
 func (repositorio Publicacoes) Criar(publicacao modelos.Publicacao) (uint64, error) {
-	statement, erro := repositorio.db.Prepare(
-		"insert into publicacoes (titulo, conteudo, autor_id) values (?, ?, ?)",
-	)
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.Criar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
 	if erro != nil {
 		return 0, erro
 	}
-	defer statement.Close()
-
-	resultado, erro := statement.Exec(publicacao.Titulo, publicacao.Conteudo, publicacao.AutorID)
+	defer transacao.Rollback()
+
+	resultado, erro := transacao.ExecContext(ctx,
+		`insert into publicacoes (titulo, conteudo, autor_id, status, publicarEm, ocultadaEm, hashConteudo)
+		values (?, ?, ?, ?, ?, if(?, current_timestamp(), null), ?)`,
+		publicacao.Titulo,
+		publicacao.Conteudo,
+		publicacao.AutorID,
+		publicacao.Status,
+		publicacao.PublicarEm,
+		publicacao.EmRevisao,
+		antispam.Hash(publicacao.Titulo, publicacao.Conteudo),
+	)
 	if erro != nil {
 		return 0, erro
 	}
@@ -35,15 +90,76 @@ func (repositorio Publicacoes) Criar(publicacao modelos.Publicacao) (uint64, err
 		return 0, erro
 	}
 
+	if _, erro = transacao.ExecContext(ctx, ajustarTotalPublicacoes, 1, ultimoIDInserido); erro != nil {
+		return 0, erro
+	}
+
+	if erro = transacao.Commit(); erro != nil {
+		return 0, erro
+	}
+
 	return uint64(ultimoIDInserido), nil
 }
 
+// Ocultar esconde uma publicação até que um moderador a libere
+func (repositorio Publicacoes) Ocultar(publicacaoID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.Ocultar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	if erro = ocultarPublicacao(ctx, transacao, publicacaoID); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+// ocultarPublicacao esconde a publicação e tira ela do contador do autor, na transação informada
+func ocultarPublicacao(ctx context.Context, transacao *sql.Tx, publicacaoID uint64) error {
+	if _, erro := transacao.ExecContext(ctx, ajustarTotalPublicacoes, -1, publicacaoID); erro != nil {
+		return erro
+	}
+
+	_, erro := transacao.ExecContext(ctx,
+		"update publicacoes set ocultadaEm = current_timestamp(), fixadaEm = null where id = ? and ocultadaEm is null",
+		publicacaoID,
+	)
+	return erro
+}
+
+// liberarPublicacao volta a mostrar uma publicação escondida pela moderação, na transação informada
+func liberarPublicacao(ctx context.Context, transacao *sql.Tx, publicacaoID uint64) error {
+	resultado, erro := transacao.ExecContext(ctx,
+		"update publicacoes set ocultadaEm = null where id = ? and ocultadaEm is not null", publicacaoID,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil || linhasAfetadas == 0 {
+		return erro
+	}
+
+	_, erro = transacao.ExecContext(ctx, ajustarTotalPublicacoes, 1, publicacaoID)
+	return erro
+}
+
 // BuscarPorID traz uma única publicação do banco de dados
 func (repositorio Publicacoes) BuscarPorID(publicacaoID uint64) (modelos.Publicacao, error) {
-	linha, erro := repositorio.db.Query(`
-	select p.*, u.nick from 
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.BuscarPorID")
+	defer span.End()
+
+	linha, erro := repositorio.db.QueryContext(ctx, `
+	select `+colunasPublicacao+` from 
 	publicacoes p inner join usuarios u
-	on u.id = p.autor_id where p.id = ?`,
+	on u.id = p.autor_id
+	where p.id = ? and `+filtroPublicadas,
 		publicacaoID,
 	)
 	if erro != nil {
@@ -51,99 +167,217 @@ func (repositorio Publicacoes) BuscarPorID(publicacaoID uint64) (modelos.Publica
 	}
 	defer linha.Close()
 
-	var publicacao modelos.Publicacao
-
 	if linha.Next() {
-		if erro = linha.Scan(
-			&publicacao.ID,
-			&publicacao.Titulo,
-			&publicacao.Conteudo,
-			&publicacao.AutorID,
-			&publicacao.Curtidas,
-			&publicacao.CriadaEm,
-			&publicacao.AutorNick,
-		); erro != nil {
-			return modelos.Publicacao{}, erro
-		}
+		return escanearPublicacao(linha)
 	}
 
-	return publicacao, nil
+	return modelos.Publicacao{}, nil
 }
 
-// Buscar traz as publicações dos usuários seguidos e também do próprio usuário que fez a requisição
-func (repositorio Publicacoes) Buscar(usuarioID uint64) ([]modelos.Publicacao, error) {
-	linhas, erro := repositorio.db.Query(`
-	select distinct p.*, u.nick from publicacoes p 
-	inner join usuarios u on u.id = p.autor_id 
-	inner join seguidores s on p.autor_id = s.usuario_id 
-	where u.id = ? or s.seguidor_id = ?
-	order by 1 desc`,
-		usuarioID, usuarioID,
+// BuscarCandidatosRelevantes traz as publicações recentes dos usuários seguidos e do próprio usuário,
+// junto com os sinais usados para pontuá-las no feed relevante
+func (repositorio Publicacoes) BuscarCandidatosRelevantes(usuarioID uint64, desde time.Time, limite int) ([]relevancia.Candidato, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.BuscarCandidatosRelevantes")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+	select `+colunasPublicacao+`, coalesce(p.publicarEm, p.criadaEm),
+	(select count(*) from reacoes r where r.publicacao_id = p.id),
+	(select count(*) from salvos sv where sv.publicacao_id = p.id),
+	(select count(*) from enquete_votos v inner join enquetes e on e.id = v.enquete_id where e.publicacao_id = p.id),
+	coalesce(a.interacoes, 0)
+	from publicacoes p
+	inner join usuarios u on u.id = p.autor_id
+	left join (
+		select pi.autor_id, count(*) interacoes from (
+			select publicacao_id from reacoes where usuario_id = ?
+			union all
+			select publicacao_id from salvos where usuario_id = ?
+			union all
+			select distinct e.publicacao_id from enquete_votos v
+			inner join enquetes e on e.id = v.enquete_id where v.usuario_id = ?
+		) i inner join publicacoes pi on pi.id = i.publicacao_id
+		group by pi.autor_id
+	) a on a.autor_id = p.autor_id
+	where (p.autor_id = ? or p.autor_id in (select usuario_id from seguidores where seguidor_id = ?))
+	and coalesce(p.publicarEm, p.criadaEm) >= ?
+	and `+filtroPublicadas+`
+	and `+filtroSilenciadas+`
+	order by coalesce(p.publicarEm, p.criadaEm) desc
+	limit ?`,
+		usuarioID, usuarioID, usuarioID, usuarioID, usuarioID, desde, usuarioID, limite,
 	)
 	if erro != nil {
 		return nil, erro
 	}
 	defer linhas.Close()
 
-	var publicacoes []modelos.Publicacao
-
+	var candidatos []relevancia.Candidato
 	for linhas.Next() {
-		var publicacao modelos.Publicacao
-
-		if erro = linhas.Scan(
-			&publicacao.ID,
-			&publicacao.Titulo,
-			&publicacao.Conteudo,
-			&publicacao.AutorID,
-			&publicacao.Curtidas,
-			&publicacao.CriadaEm,
-			&publicacao.AutorNick,
-		); erro != nil {
+		var candidato relevancia.Candidato
+
+		if erro = linhas.Scan(append(
+			destinosPublicacao(&candidato.Publicacao),
+			&candidato.Sinais.PublicadaEm,
+			&candidato.Sinais.Reacoes,
+			&candidato.Sinais.Salvos,
+			&candidato.Sinais.Votos,
+			&candidato.Sinais.Interacoes,
+		)...); erro != nil {
 			return nil, erro
 		}
 
-		publicacoes = append(publicacoes, publicacao)
+		candidato.Sinais.Curtidas = candidato.Publicacao.Curtidas
+		candidatos = append(candidatos, candidato)
 	}
 
-	return publicacoes, nil
+	if erro = linhas.Err(); erro != nil {
+		return nil, erro
+	}
+
+	return candidatos, nil
 }
 
 // Atualizar altera os dados de uma publicação no banco de dados
 func (repositorio Publicacoes) Atualizar(publicacaoID uint64, publicacao modelos.Publicacao) error {
-	statement, erro := repositorio.db.Prepare("update publicacoes set titulo = ?, conteudo = ? where id = ?")
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.Atualizar")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, `
+		update publicacoes set titulo = ?, conteudo = ?, hashConteudo = ?
+		where id = ? and status = '`+modelos.StatusPublicada+`' and deletadoEm is null`,
+	)
 	if erro != nil {
 		return erro
 	}
 	defer statement.Close()
 
-	if _, erro = statement.Exec(publicacao.Titulo, publicacao.Conteudo, publicacaoID); erro != nil {
+	hashConteudo := antispam.Hash(publicacao.Titulo, publicacao.Conteudo)
+	if _, erro = statement.ExecContext(ctx, publicacao.Titulo, publicacao.Conteudo, hashConteudo, publicacaoID); erro != nil {
 		return erro
 	}
 
 	return nil
 }
 
-// Deletar exclui uma publicação do banco de dados
+// Deletar move uma publicação para a lixeira, de onde ela ainda pode ser restaurada
 func (repositorio Publicacoes) Deletar(publicacaoID uint64) error {
-	statement, erro := repositorio.db.Prepare("delete from publicacoes where id = ?")
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.Deletar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	if _, erro = transacao.ExecContext(ctx, ajustarTotalPublicacoes, -1, publicacaoID); erro != nil {
+		return erro
+	}
+
+	if _, erro = transacao.ExecContext(ctx,
+		"update publicacoes set deletadoEm = current_timestamp(), fixadaEm = null where id = ? and deletadoEm is null",
+		publicacaoID,
+	); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+// BuscarPorUsuario traz as publicações de um usuário específico, das mais novas para as mais antigas.
+// As publicações fixadas vêm antes das demais na primeira página e não contam na paginação
+func (repositorio Publicacoes) BuscarPorUsuario(usuarioID uint64, paginacao modelos.Paginacao) ([]modelos.Publicacao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.BuscarPorUsuario")
+	defer span.End()
+
+	var publicacoes []modelos.Publicacao
+
+	if paginacao.Pagina <= 1 {
+		fixadas, erro := repositorio.consultar(ctx, `
+			select `+colunasPublicacao+` from publicacoes p
+			join usuarios u on u.id = p.autor_id
+			where p.autor_id = ? and p.fixadaEm is not null and `+filtroPublicadas+`
+			order by p.fixadaEm desc`,
+			usuarioID,
+		)
+		if erro != nil {
+			return nil, erro
+		}
+
+		publicacoes = append(publicacoes, fixadas...)
+	}
+
+	demais, erro := repositorio.consultar(ctx, `
+		select `+colunasPublicacao+` from publicacoes p
+		join usuarios u on u.id = p.autor_id
+		where p.autor_id = ? and p.fixadaEm is null and `+filtroPublicadas+`
+		order by p.criadaEm desc, p.id desc
+		limit ? offset ?`,
+		usuarioID, paginacao.Limite, paginacao.Deslocamento(),
+	)
+	if erro != nil {
+		return nil, erro
+	}
+
+	return append(publicacoes, demais...), nil
+}
+
+// Curtir adiciona uma curtida na publicação
+func (repositorio Publicacoes) Curtir(publicacaoID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.Curtir")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, `
+		update publicacoes set curtidas = curtidas + 1
+		where id = ? and status = '`+modelos.StatusPublicada+`' and deletadoEm is null`,
+	)
 	if erro != nil {
 		return erro
 	}
 	defer statement.Close()
 
-	if _, erro = statement.Exec(publicacaoID); erro != nil {
+	if _, erro = statement.ExecContext(ctx, publicacaoID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// Descurtir subtrai uma curtida na publicação
+func (repositorio Publicacoes) Descurtir(publicacaoID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.Descurtir")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, `
+		update publicacoes set curtidas = 
+		CASE 
+			WHEN curtidas > 0 THEN curtidas - 1
+			ELSE 0 
+		END
+		where id = ? and status = '`+modelos.StatusPublicada+`' and deletadoEm is null
+	`)
+	if erro != nil {
+		return erro
+	}
+
+	if _, erro = statement.ExecContext(ctx, publicacaoID); erro != nil {
 		return erro
 	}
 
 	return nil
 }
 
-// BuscarPorUsuario traz todas as publicações de um usuário específico
-func (repositorio Publicacoes) BuscarPorUsuario(usuarioID uint64) ([]modelos.Publicacao, error) {
-	linhas, erro := repositorio.db.Query(`
-		select p.*, u.nick from publicacoes p
+// BuscarLixeira traz as publicações de um usuário que foram deletadas e ainda não foram removidas definitivamente
+func (repositorio Publicacoes) BuscarLixeira(usuarioID uint64) ([]modelos.Publicacao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.BuscarLixeira")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select `+colunasPublicacao+` from publicacoes p
 		join usuarios u on u.id = p.autor_id
-		where p.autor_id = ?`,
+		where p.autor_id = ? and p.deletadoEm is not null
+		order by p.deletadoEm desc`,
 		usuarioID,
 	)
 	if erro != nil {
@@ -154,17 +388,8 @@ func (repositorio Publicacoes) BuscarPorUsuario(usuarioID uint64) ([]modelos.Pub
 	var publicacoes []modelos.Publicacao
 
 	for linhas.Next() {
-		var publicacao modelos.Publicacao
-
-		if erro = linhas.Scan(
-			&publicacao.ID,
-			&publicacao.Titulo,
-			&publicacao.Conteudo,
-			&publicacao.AutorID,
-			&publicacao.Curtidas,
-			&publicacao.CriadaEm,
-			&publicacao.AutorNick,
-		); erro != nil {
+		publicacao, erro := escanearPublicacao(linhas)
+		if erro != nil {
 			return nil, erro
 		}
 
@@ -174,38 +399,322 @@ func (repositorio Publicacoes) BuscarPorUsuario(usuarioID uint64) ([]modelos.Pub
 	return publicacoes, nil
 }
 
-// Curtir adiciona uma curtida na publicação
-func (repositorio Publicacoes) Curtir(publicacaoID uint64) error {
-	statement, erro := repositorio.db.Prepare("update publicacoes set curtidas = curtidas + 1 where id = ?")
+// Restaurar tira da lixeira uma publicação deletada pelo seu autor
+func (repositorio Publicacoes) Restaurar(publicacaoID, usuarioID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.Restaurar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
 	if erro != nil {
 		return erro
 	}
+	defer transacao.Rollback()
+
+	resultado, erro := transacao.ExecContext(ctx,
+		"update publicacoes set deletadoEm = null where id = ? and autor_id = ? and deletadoEm is not null",
+		publicacaoID, usuarioID,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
+		return erro
+	}
+
+	if linhasAfetadas == 0 {
+		return ErrPublicacaoNaoEncontradaNaLixeira
+	}
+
+	if _, erro = transacao.ExecContext(ctx, ajustarTotalPublicacoes, 1, publicacaoID); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+// Purgar remove definitivamente as publicações que estão na lixeira desde antes do limite informado
+func (repositorio Publicacoes) Purgar(limite time.Time) (int64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.Purgar")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from publicacoes where deletadoEm < ?")
+	if erro != nil {
+		return 0, erro
+	}
 	defer statement.Close()
 
-	if _, erro = statement.Exec(publicacaoID); erro != nil {
+	resultado, erro := statement.ExecContext(ctx, limite)
+	if erro != nil {
+		return 0, erro
+	}
+
+	return resultado.RowsAffected()
+}
+
+// BuscarRascunhos traz os rascunhos e as publicações agendadas de um usuário
+func (repositorio Publicacoes) BuscarRascunhos(usuarioID uint64) ([]modelos.Publicacao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.BuscarRascunhos")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select `+colunasPublicacao+` from publicacoes p
+		join usuarios u on u.id = p.autor_id
+		where p.autor_id = ? and p.status <> '`+modelos.StatusPublicada+`' and p.deletadoEm is null
+		order by p.id desc`,
+		usuarioID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var publicacoes []modelos.Publicacao
+
+	for linhas.Next() {
+		publicacao, erro := escanearPublicacao(linhas)
+		if erro != nil {
+			return nil, erro
+		}
+
+		publicacoes = append(publicacoes, publicacao)
+	}
+
+	return publicacoes, nil
+}
+
+// BuscarRascunhoPorID traz um rascunho ou uma publicação agendada do banco de dados
+func (repositorio Publicacoes) BuscarRascunhoPorID(publicacaoID uint64) (modelos.Publicacao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.BuscarRascunhoPorID")
+	defer span.End()
+
+	linha, erro := repositorio.db.QueryContext(ctx, `
+		select `+colunasPublicacao+` from publicacoes p
+		join usuarios u on u.id = p.autor_id
+		where p.id = ? and p.status <> '`+modelos.StatusPublicada+`' and p.deletadoEm is null`,
+		publicacaoID,
+	)
+	if erro != nil {
+		return modelos.Publicacao{}, erro
+	}
+	defer linha.Close()
+
+	if linha.Next() {
+		return escanearPublicacao(linha)
+	}
+
+	return modelos.Publicacao{}, nil
+}
+
+// AtualizarRascunho altera um rascunho ou publicação agendada, podendo também publicá-lo
+func (repositorio Publicacoes) AtualizarRascunho(publicacaoID uint64, publicacao modelos.Publicacao) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.AtualizarRascunho")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	resultado, erro := transacao.ExecContext(ctx, `
+		update publicacoes set titulo = ?, conteudo = ?, hashConteudo = ?, status = ?, publicarEm = ?,
+		criadaEm = if(? = '`+modelos.StatusPublicada+`', current_timestamp(), criadaEm)
+		where id = ? and status <> '`+modelos.StatusPublicada+`' and deletadoEm is null`,
+		publicacao.Titulo,
+		publicacao.Conteudo,
+		antispam.Hash(publicacao.Titulo, publicacao.Conteudo),
+		publicacao.Status,
+		publicacao.PublicarEm,
+		publicacao.Status,
+		publicacaoID,
+	)
+	if erro != nil {
 		return erro
 	}
 
-	return nil
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
+		return erro
+	}
+
+	if linhasAfetadas > 0 && publicacao.Status == modelos.StatusPublicada {
+		if _, erro = transacao.ExecContext(ctx, ajustarTotalPublicacoes, 1, publicacaoID); erro != nil {
+			return erro
+		}
+	}
+
+	return transacao.Commit()
 }
 
-// Descurtir subtrai uma curtida na publicação
-func (repositorio Publicacoes) Descurtir(publicacaoID uint64) error {
-	statement, erro := repositorio.db.Prepare(`
-		update publicacoes set curtidas = 
-		CASE 
-			WHEN curtidas > 0 THEN curtidas - 1
-			ELSE 0 
-		END
-		where id = ?
-	`)
+// PublicarAgendadas publica as publicações cuja data agendada já chegou e retorna os IDs publicados.
+// As linhas são travadas com skip locked, então várias instâncias da API podem rodar isso ao mesmo tempo
+// sem publicar a mesma publicação duas vezes. As de autores em quarentena esperam até a conta ser liberada
+func (repositorio Publicacoes) PublicarAgendadas(agora time.Time) ([]uint64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.PublicarAgendadas")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return nil, erro
+	}
+	defer transacao.Rollback()
+
+	linhas, erro := transacao.QueryContext(ctx, `
+		select p.id from publicacoes p
+		join usuarios u on u.id = p.autor_id
+		where p.status = '`+modelos.StatusAgendada+`' and p.publicarEm <= ? and p.deletadoEm is null
+		and u.quarentenaEm is null
+		for update of p skip locked`,
+		agora,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+
+	var IDs []uint64
+	for linhas.Next() {
+		var ID uint64
+		if erro = linhas.Scan(&ID); erro != nil {
+			linhas.Close()
+			return nil, erro
+		}
+
+		IDs = append(IDs, ID)
+	}
+	linhas.Close()
+
+	if erro = linhas.Err(); erro != nil {
+		return nil, erro
+	}
+
+	for _, ID := range IDs {
+		if _, erro = transacao.ExecContext(ctx,
+			"update publicacoes set status = ?, criadaEm = publicarEm where id = ?",
+			modelos.StatusPublicada, ID,
+		); erro != nil {
+			return nil, erro
+		}
+
+		if _, erro = transacao.ExecContext(ctx, ajustarTotalPublicacoes, 1, ID); erro != nil {
+			return nil, erro
+		}
+	}
+
+	if erro = transacao.Commit(); erro != nil {
+		return nil, erro
+	}
+
+	return IDs, nil
+}
+
+// Fixar fixa uma publicação publicada no perfil do seu autor. A linha do autor é travada durante a
+// contagem para que requisições simultâneas não passem do limite de MaximoFixadas
+func (repositorio Publicacoes) Fixar(publicacaoID, autorID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.Fixar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	var travado uint64
+	if erro = transacao.QueryRowContext(ctx, "select id from usuarios where id = ? for update", autorID).Scan(&travado); erro != nil {
+		return erro
+	}
+
+	var fixadas int
+	if erro = transacao.QueryRowContext(ctx, `
+		select count(*) from publicacoes
+		where autor_id = ? and fixadaEm is not null and id <> ? and deletadoEm is null`,
+		autorID, publicacaoID,
+	).Scan(&fixadas); erro != nil {
+		return erro
+	}
+
+	if fixadas >= MaximoFixadas {
+		return ErrLimiteDeFixadas
+	}
+
+	if _, erro = transacao.ExecContext(ctx, `
+		update publicacoes set fixadaEm = coalesce(fixadaEm, current_timestamp())
+		where id = ? and autor_id = ? and status = ? and deletadoEm is null`,
+		publicacaoID, autorID, modelos.StatusPublicada,
+	); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+// Desafixar tira uma publicação dos destaques do perfil do seu autor
+func (repositorio Publicacoes) Desafixar(publicacaoID, autorID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Publicacoes.Desafixar")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "update publicacoes set fixadaEm = null where id = ? and autor_id = ?")
 	if erro != nil {
 		return erro
 	}
+	defer statement.Close()
 
-	if _, erro = statement.Exec(publicacaoID); erro != nil {
+	if _, erro = statement.ExecContext(ctx, publicacaoID, autorID); erro != nil {
 		return erro
 	}
 
 	return nil
 }
+
+// consultar executa uma consulta que traz as colunas de colunasPublicacao e lê todas as publicações retornadas
+func (repositorio Publicacoes) consultar(ctx context.Context, consulta string, parametros ...interface{}) ([]modelos.Publicacao, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, consulta, parametros...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var publicacoes []modelos.Publicacao
+
+	for linhas.Next() {
+		publicacao, erro := escanearPublicacao(linhas)
+		if erro != nil {
+			return nil, erro
+		}
+
+		publicacoes = append(publicacoes, publicacao)
+	}
+
+	return publicacoes, nil
+}
+
+// escanearPublicacao lê uma linha contendo as colunas de colunasPublicacao
+func escanearPublicacao(linhas *sql.Rows) (modelos.Publicacao, error) {
+	var publicacao modelos.Publicacao
+
+	if erro := linhas.Scan(destinosPublicacao(&publicacao)...); erro != nil {
+		return modelos.Publicacao{}, erro
+	}
+
+	return publicacao, nil
+}
+
+// destinosPublicacao retorna os campos da publicação na mesma ordem de colunasPublicacao, para consultas
+// que trazem outras colunas além das da publicação
+func destinosPublicacao(publicacao *modelos.Publicacao) []interface{} {
+	return []interface{}{
+		&publicacao.ID,
+		&publicacao.Titulo,
+		&publicacao.Conteudo,
+		&publicacao.AutorID,
+		&publicacao.Curtidas,
+		&publicacao.CriadaEm,
+		&publicacao.DeletadoEm,
+		&publicacao.Status,
+		&publicacao.PublicarEm,
+		&publicacao.Fixada,
+		&publicacao.AutorNick,
+	}
+}
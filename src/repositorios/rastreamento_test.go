@@ -0,0 +1,130 @@
+package repositorios_test
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"api/src/repositorios"
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// traceparentRecebido é o cabeçalho de uma requisição que chega já fazendo parte de um trace
+const traceparentRecebido = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// configurarExportador instala um provedor que guarda os spans em memória e o tira no fim do teste. A função
+// retornada envia os spans pendentes para o exportador e os retorna
+func configurarExportador(t *testing.T) func() tracetest.SpanStubs {
+	t.Helper()
+
+	exportador := tracetest.NewInMemoryExporter()
+	provedor, erro := rastreamento.NovoProvedor(exportador, 1)
+	if erro != nil {
+		t.Fatal(erro)
+	}
+
+	anterior := otel.GetTracerProvider()
+	otel.SetTracerProvider(provedor)
+	rastreamento.Configurar(context.Background(), "", false, 1)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(anterior)
+		provedor.Shutdown(context.Background())
+	})
+
+	return func() tracetest.SpanStubs {
+		if erro := provedor.ForceFlush(context.Background()); erro != nil {
+			t.Fatal(erro)
+		}
+
+		return exportador.GetSpans()
+	}
+}
+
+// bancoInacessivel abre um banco que recusa as conexões, para que os métodos dos repositórios rodem até a
+// consulta sem precisar de um MySQL
+func bancoInacessivel(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, erro := sql.Open("mysql", "devbook:devbook@tcp(127.0.0.1:1)/devbook?timeout=1s")
+	if erro != nil {
+		t.Fatal(erro)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestRepositoriosRegistramSpansNoTraceDaRequisicao(t *testing.T) {
+	exportados := configurarExportador(t)
+	db := bancoInacessivel(t)
+
+	r := httptest.NewRequest("GET", "/publicacoes", nil)
+	r.Header.Set("traceparent", traceparentRecebido)
+	r, encerrar := rastreamento.IniciarRequisicao(r, "/publicacoes")
+
+	repos := repositorios.NovoRepositories(r.Context(), db)
+	paginacao := modelos.Paginacao{Pagina: 1, Limite: 20}
+	if _, erro := repos.LinhaDoTempo.Buscar(1, 1000, paginacao); erro == nil {
+		t.Fatal("a consulta deveria falhar sem banco")
+	}
+	if _, erro := repos.Publicacao.BuscarPorID(1); erro == nil {
+		t.Fatal("a consulta deveria falhar sem banco")
+	}
+	encerrar(500)
+
+	spans := exportados()
+	porNome := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		if span.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("o span %q saiu do trace da requisição: %s", span.Name, span.SpanContext.TraceID())
+		}
+		porNome[span.Name] = span
+	}
+
+	requisicao, ok := porNome["GET /publicacoes"]
+	if !ok {
+		t.Fatalf("o span da requisição não foi exportado; spans: %v", nomes(spans))
+	}
+	if requisicao.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("o span da requisição deveria continuar o traceparent recebido, mas o pai é %s", requisicao.Parent.SpanID())
+	}
+
+	for _, nome := range []string{"LinhasDoTempo.Buscar", "Publicacoes.BuscarPorID"} {
+		span, ok := porNome[nome]
+		if !ok {
+			t.Errorf("o span %q não foi exportado; spans: %v", nome, nomes(spans))
+			continue
+		}
+		if span.Parent.SpanID() != requisicao.SpanContext.SpanID() {
+			t.Errorf("o span %q deveria ser filho do span da requisição", nome)
+		}
+	}
+}
+
+func TestRepositoriosForaDeTraceNaoRegistramSpans(t *testing.T) {
+	exportados := configurarExportador(t)
+	db := bancoInacessivel(t)
+
+	repos := repositorios.NovoRepositories(context.Background(), db)
+	repos.LinhaDoTempo.BuscarUsuarios()
+	repos.Exportacao.Devolver(1)
+
+	if spans := exportados(); len(spans) > 0 {
+		t.Errorf("as tarefas não deveriam abrir traces; spans: %v", nomes(spans))
+	}
+}
+
+// nomes lista os nomes dos spans exportados, para as mensagens de erro
+func nomes(spans tracetest.SpanStubs) []string {
+	var resultado []string
+	for _, span := range spans {
+		resultado = append(resultado, span.Name)
+	}
+
+	return resultado
+}
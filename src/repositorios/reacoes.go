@@ -0,0 +1,155 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+)
+
+// Reacoes representa um repositório de reações às publicações
+type Reacoes struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeReacoes cria um repositório de reações que faz as consultas no contexto informado
+func NovoRepositorioDeReacoes(ctx context.Context, db *sql.DB) *Reacoes {
+	return &Reacoes{db, ctx}
+}
+
+// Reagir registra a reação de um usuário a uma publicação. Cada usuário tem no máximo uma reação
+// por publicação, então reagir de novo apenas troca o tipo
+func (repositorio Reacoes) Reagir(publicacaoID, usuarioID uint64, tipo string) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Reacoes.Reagir")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, `
+		insert into reacoes (publicacao_id, usuario_id, tipo) values (?, ?, ?)
+		on duplicate key update tipo = values(tipo), reagidoEm = current_timestamp()`,
+	)
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, publicacaoID, usuarioID, tipo); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// RemoverReacao apaga a reação de um usuário a uma publicação
+func (repositorio Reacoes) RemoverReacao(publicacaoID, usuarioID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Reacoes.RemoverReacao")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from reacoes where publicacao_id = ? and usuario_id = ?")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, publicacaoID, usuarioID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// ContarPorPublicacoes traz a quantidade de reações de cada tipo em várias publicações e
+// a reação que o usuário informado deixou em cada uma delas
+func (repositorio Reacoes) ContarPorPublicacoes(
+	publicacaoIDs []uint64,
+	usuarioID uint64,
+) (map[uint64]map[string]uint64, map[uint64]string, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Reacoes.ContarPorPublicacoes")
+	defer span.End()
+
+	contagens := make(map[uint64]map[string]uint64)
+	minhas := make(map[uint64]string)
+	if len(publicacaoIDs) == 0 {
+		return contagens, minhas, nil
+	}
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select r.publicacao_id, r.tipo, count(*), sum(r.usuario_id = ?) from reacoes r
+		inner join usuarios u on u.id = r.usuario_id
+		where r.publicacao_id in (`+marcadores(len(publicacaoIDs))+`) and u.deletadoEm is null
+		group by r.publicacao_id, r.tipo`,
+		append([]interface{}{usuarioID}, argumentos(publicacaoIDs)...)...,
+	)
+	if erro != nil {
+		return nil, nil, erro
+	}
+	defer linhas.Close()
+
+	for linhas.Next() {
+		var publicacaoID, quantidade, doUsuario uint64
+		var tipo string
+
+		if erro = linhas.Scan(&publicacaoID, &tipo, &quantidade, &doUsuario); erro != nil {
+			return nil, nil, erro
+		}
+
+		if contagens[publicacaoID] == nil {
+			contagens[publicacaoID] = make(map[string]uint64)
+		}
+		contagens[publicacaoID][tipo] = quantidade
+
+		if doUsuario > 0 {
+			minhas[publicacaoID] = tipo
+		}
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return nil, nil, erro
+	}
+
+	return contagens, minhas, nil
+}
+
+// BuscarPorPublicacao traz uma página das reações a uma publicação, das mais recentes para as mais antigas,
+// opcionalmente de um único tipo
+func (repositorio Reacoes) BuscarPorPublicacao(publicacaoID uint64, tipo string, paginacao modelos.Paginacao) ([]modelos.Reacao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Reacoes.BuscarPorPublicacao")
+	defer span.End()
+
+	consulta := `
+		select r.tipo, u.id, u.nick, r.reagidoEm from reacoes r
+		inner join usuarios u on u.id = r.usuario_id
+		where r.publicacao_id = ? and u.deletadoEm is null`
+	parametros := []interface{}{publicacaoID}
+
+	if tipo != "" {
+		consulta += " and r.tipo = ?"
+		parametros = append(parametros, tipo)
+	}
+
+	consulta += " order by r.reagidoEm desc, u.id desc limit ? offset ?"
+	parametros = append(parametros, paginacao.Limite, paginacao.Deslocamento())
+
+	linhas, erro := repositorio.db.QueryContext(ctx, consulta, parametros...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	reacoes := []modelos.Reacao{}
+	for linhas.Next() {
+		var reacao modelos.Reacao
+
+		if erro = linhas.Scan(&reacao.Tipo, &reacao.UsuarioID, &reacao.Nick, &reacao.ReagidoEm); erro != nil {
+			return nil, erro
+		}
+
+		reacoes = append(reacoes, reacao)
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return nil, erro
+	}
+
+	return reacoes, nil
+}
@@ -1,17 +1,54 @@
 package repositorios
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+)
 
 // Repositories contém todos os repositórios da aplicação
 type Repositories struct {
-	Usuario    IUsuarioRepository
-	Publicacao IPublicacaoRepository
+	Usuario      IUsuarioRepository
+	Publicacao   IPublicacaoRepository
+	Anexo        IAnexoRepository
+	Trecho       ITrechoRepository
+	Enquete      IEnqueteRepository
+	Salvo        ISalvoRepository
+	Reacao       IReacaoRepository
+	LinhaDoTempo ILinhaDoTempoRepository
+	Sugestao     ISugestaoRepository
+	Tendencia    ITendenciaRepository
+	Exportacao   IExportacaoRepository
+	Denuncia     IDenunciaRepository
+	Filtro       IFiltroRepository
+	Antispam     IAntispamRepository
+	Auditoria    IAuditoriaRepository
+	Saude        ISaudeRepository
+
+	// Logger é o logger da requisição ou da tarefa que está usando os repositórios
+	Logger *slog.Logger
 }
 
-// NovoRepositories cria uma nova instância de Repositories
-func NovoRepositories(db *sql.DB) *Repositories {
+// NovoRepositories cria uma nova instância de Repositories. Os repositórios registram os métodos e as
+// consultas no trace do contexto informado
+func NovoRepositories(ctx context.Context, db *sql.DB) *Repositories {
 	return &Repositories{
-		Usuario:    NovoRepositorioDeUsuarios(db),
-		Publicacao: NovoRepositorioDePublicacoes(db),
+		Usuario:      NovoRepositorioDeUsuarios(ctx, db),
+		Publicacao:   NovoRepositorioDePublicacoes(ctx, db),
+		Anexo:        NovoRepositorioDeAnexos(ctx, db),
+		Trecho:       NovoRepositorioDeTrechos(ctx, db),
+		Enquete:      NovoRepositorioDeEnquetes(ctx, db),
+		Salvo:        NovoRepositorioDeSalvos(ctx, db),
+		Reacao:       NovoRepositorioDeReacoes(ctx, db),
+		LinhaDoTempo: NovoRepositorioDeLinhasDoTempo(ctx, db),
+		Sugestao:     NovoRepositorioDeSugestoes(ctx, db),
+		Tendencia:    NovoRepositorioDeTendencias(ctx, db),
+		Exportacao:   NovoRepositorioDeExportacoes(ctx, db),
+		Denuncia:     NovoRepositorioDeDenuncias(ctx, db),
+		Filtro:       NovoRepositorioDeFiltros(ctx, db),
+		Antispam:     NovoRepositorioDeAntispam(ctx, db),
+		Auditoria:    NovoRepositorioDeAuditoria(ctx, db),
+		Saude:        NovoRepositorioDeSaude(db),
+		Logger:       slog.Default(),
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,250 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	// ErrColecaoDuplicada é retornado quando o usuário já tem uma coleção com o mesmo nome
+	ErrColecaoDuplicada = errors.New("você já tem uma coleção com esse nome")
+)
+
+// Salvos representa um repositório de publicações salvas e das coleções em que elas são organizadas
+type Salvos struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeSalvos cria um repositório de publicações salvas que faz as consultas no contexto informado
+func NovoRepositorioDeSalvos(ctx context.Context, db *sql.DB) *Salvos {
+	return &Salvos{db, ctx}
+}
+
+// Salvar guarda uma publicação entre as salvas do usuário. Se ela já estiver salva, só a coleção é alterada
+func (repositorio Salvos) Salvar(usuarioID, publicacaoID uint64, colecaoID *uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Salvos.Salvar")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, `
+		insert into salvos (usuario_id, publicacao_id, colecao_id) values (?, ?, ?)
+		on duplicate key update colecao_id = values(colecao_id)`,
+	)
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, usuarioID, publicacaoID, colecaoID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// RemoverSalvo tira uma publicação das salvas do usuário
+func (repositorio Salvos) RemoverSalvo(usuarioID, publicacaoID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Salvos.RemoverSalvo")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from salvos where usuario_id = ? and publicacao_id = ?")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, usuarioID, publicacaoID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// BuscarSalvos traz uma página das publicações salvas pelo usuário, opcionalmente de uma única coleção.
+// Publicações que foram deletadas ou deixaram de estar visíveis não aparecem
+func (repositorio Salvos) BuscarSalvos(usuarioID uint64, colecaoID *uint64, paginacao modelos.Paginacao) ([]modelos.Salvo, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Salvos.BuscarSalvos")
+	defer span.End()
+
+	consulta := `
+		select ` + colunasPublicacao + `, sv.colecao_id, sv.salvoEm from salvos sv
+		inner join publicacoes p on p.id = sv.publicacao_id
+		inner join usuarios u on u.id = p.autor_id
+		where sv.usuario_id = ? and ` + filtroPublicadas
+	parametros := []interface{}{usuarioID}
+
+	if colecaoID != nil {
+		consulta += " and sv.colecao_id = ?"
+		parametros = append(parametros, *colecaoID)
+	}
+
+	consulta += " order by sv.salvoEm desc, p.id desc limit ? offset ?"
+	parametros = append(parametros, paginacao.Limite, paginacao.Deslocamento())
+
+	linhas, erro := repositorio.db.QueryContext(ctx, consulta, parametros...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var salvos []modelos.Salvo
+
+	for linhas.Next() {
+		var salvo modelos.Salvo
+
+		if erro = linhas.Scan(append(destinosPublicacao(&salvo.Publicacao), &salvo.ColecaoID, &salvo.SalvoEm)...); erro != nil {
+			return nil, erro
+		}
+
+		salvos = append(salvos, salvo)
+	}
+
+	return salvos, nil
+}
+
+// CriarColecao insere uma coleção no banco de dados
+func (repositorio Salvos) CriarColecao(colecao modelos.Colecao) (uint64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Salvos.CriarColecao")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "insert into colecoes (usuario_id, nome) values (?, ?)")
+	if erro != nil {
+		return 0, erro
+	}
+	defer statement.Close()
+
+	resultado, erro := statement.ExecContext(ctx, colecao.UsuarioID, colecao.Nome)
+	if erro != nil {
+		return 0, traduzirErroColecao(erro)
+	}
+
+	ultimoIDInserido, erro := resultado.LastInsertId()
+	if erro != nil {
+		return 0, erro
+	}
+
+	return uint64(ultimoIDInserido), nil
+}
+
+// BuscarColecoes traz as coleções de um usuário com a quantidade de publicações visíveis em cada uma
+func (repositorio Salvos) BuscarColecoes(usuarioID uint64) ([]modelos.Colecao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Salvos.BuscarColecoes")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select c.id, c.nome, c.usuario_id, c.criadaEm, (
+			select count(*) from salvos sv
+			inner join publicacoes p on p.id = sv.publicacao_id
+			inner join usuarios u on u.id = p.autor_id
+			where sv.colecao_id = c.id and `+filtroPublicadas+`
+		) from colecoes c
+		where c.usuario_id = ?
+		order by c.nome`,
+		usuarioID,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var colecoes []modelos.Colecao
+
+	for linhas.Next() {
+		var colecao modelos.Colecao
+
+		if erro = linhas.Scan(
+			&colecao.ID,
+			&colecao.Nome,
+			&colecao.UsuarioID,
+			&colecao.CriadaEm,
+			&colecao.Salvos,
+		); erro != nil {
+			return nil, erro
+		}
+
+		colecoes = append(colecoes, colecao)
+	}
+
+	return colecoes, nil
+}
+
+// BuscarColecaoPorID traz uma coleção do banco de dados
+func (repositorio Salvos) BuscarColecaoPorID(colecaoID uint64) (modelos.Colecao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Salvos.BuscarColecaoPorID")
+	defer span.End()
+
+	linha, erro := repositorio.db.QueryContext(ctx,
+		"select id, nome, usuario_id, criadaEm from colecoes where id = ?",
+		colecaoID,
+	)
+	if erro != nil {
+		return modelos.Colecao{}, erro
+	}
+	defer linha.Close()
+
+	var colecao modelos.Colecao
+
+	if linha.Next() {
+		if erro = linha.Scan(
+			&colecao.ID,
+			&colecao.Nome,
+			&colecao.UsuarioID,
+			&colecao.CriadaEm,
+		); erro != nil {
+			return modelos.Colecao{}, erro
+		}
+	}
+
+	return colecao, nil
+}
+
+// AtualizarColecao renomeia uma coleção
+func (repositorio Salvos) AtualizarColecao(colecaoID uint64, colecao modelos.Colecao) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Salvos.AtualizarColecao")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "update colecoes set nome = ? where id = ?")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, colecao.Nome, colecaoID); erro != nil {
+		return traduzirErroColecao(erro)
+	}
+
+	return nil
+}
+
+// DeletarColecao exclui uma coleção. As publicações que estavam nela continuam salvas, mas sem coleção
+func (repositorio Salvos) DeletarColecao(colecaoID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Salvos.DeletarColecao")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from colecoes where id = ?")
+	if erro != nil {
+		return erro
+	}
+	defer statement.Close()
+
+	if _, erro = statement.ExecContext(ctx, colecaoID); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// traduzirErroColecao converte a violação da chave única de nome em ErrColecaoDuplicada
+func traduzirErroColecao(erro error) error {
+	var erroMySQL *mysql.MySQLError
+	if errors.As(erro, &erroMySQL) && erroMySQL.Number == codigoErroChaveDuplicada {
+		return ErrColecaoDuplicada
+	}
+
+	return erro
+}
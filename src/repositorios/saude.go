@@ -0,0 +1,33 @@
+package repositorios
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Saude representa um repositório com as verificações do banco usadas pelo /readyz
+type Saude struct {
+	db *sql.DB
+}
+
+// NovoRepositorioDeSaude cria um repositório de verificações de saúde
+func NovoRepositorioDeSaude(db *sql.DB) *Saude {
+	return &Saude{db}
+}
+
+// Pingar verifica se o banco está respondendo dentro do prazo do contexto
+func (repositorio Saude) Pingar(ctx context.Context) error {
+	return repositorio.db.PingContext(ctx)
+}
+
+// BuscarVersaoEsquema retorna a versão mais recente do esquema aplicada ao banco, ou zero se nenhuma foi
+func (repositorio Saude) BuscarVersaoEsquema(ctx context.Context) (int, error) {
+	var versao int
+	if erro := repositorio.db.QueryRowContext(ctx,
+		"select coalesce(max(versao), 0) from versao_esquema",
+	).Scan(&versao); erro != nil {
+		return 0, erro
+	}
+
+	return versao, nil
+}
@@ -0,0 +1,213 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Sugestoes representa um repositório das sugestões de quem seguir. As sugestões são calculadas a partir
+// do grafo de seguidores e guardadas por usuário, para não repetir o cálculo a cada requisição
+type Sugestoes struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeSugestoes cria um repositório de sugestões que faz as consultas no contexto informado
+func NovoRepositorioDeSugestoes(ctx context.Context, db *sql.DB) *Sugestoes {
+	return &Sugestoes{db, ctx}
+}
+
+// BuscarEmCache traz as sugestões guardadas para o usuário e quando elas foram calculadas. Se elas nunca
+// foram calculadas, a data retornada é zero. Usuários que ele passou a seguir depois do cálculo não aparecem
+func (repositorio Sugestoes) BuscarEmCache(usuarioID uint64) ([]modelos.Sugestao, time.Time, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Sugestoes.BuscarEmCache")
+	defer span.End()
+
+	var calculadasEm time.Time
+	erro := repositorio.db.QueryRowContext(ctx,
+		"select calculadasEm from sugestoes_calculadas where usuario_id = ?", usuarioID,
+	).Scan(&calculadasEm)
+	if erro == sql.ErrNoRows {
+		return nil, time.Time{}, nil
+	}
+	if erro != nil {
+		return nil, time.Time{}, erro
+	}
+
+	sugestoes, erro := repositorio.consultar(ctx, `
+		select u.id, u.nome, u.nick, sg.conexoesEmComum from sugestoes sg
+		inner join usuarios u on u.id = sg.sugerido_id
+		where sg.usuario_id = ? and u.deletadoEm is null
+		and sg.sugerido_id not in (select usuario_id from seguidores where seguidor_id = ?)
+		order by sg.posicao`,
+		usuarioID, usuarioID,
+	)
+	if erro != nil {
+		return nil, time.Time{}, erro
+	}
+
+	return sugestoes, calculadasEm, nil
+}
+
+// Recalcular calcula as sugestões do usuário e substitui as que estavam guardadas. São sugeridos primeiro
+// os usuários seguidos por quem ele segue, dos que têm mais conexões em comum para os que têm menos. Se isso
+// não bastar, como acontece com quem ainda não segue ninguém, a lista é completada com os usuários mais seguidos
+func (repositorio Sugestoes) Recalcular(usuarioID uint64, quantidade int) ([]modelos.Sugestao, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Sugestoes.Recalcular")
+	defer span.End()
+
+	sugestoes, erro := repositorio.consultar(ctx, `
+		select u.id, u.nome, u.nick, count(distinct s1.usuario_id) conexoes
+		from seguidores s1
+		inner join seguidores s2 on s2.seguidor_id = s1.usuario_id
+		inner join usuarios u on u.id = s2.usuario_id
+		where s1.seguidor_id = ? and s2.usuario_id <> ? and u.deletadoEm is null
+		and s2.usuario_id not in (select usuario_id from seguidores where seguidor_id = ?)
+		group by u.id, u.nome, u.nick
+		order by conexoes desc, u.id
+		limit ?`,
+		usuarioID, usuarioID, usuarioID, quantidade,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+
+	if faltando := quantidade - len(sugestoes); faltando > 0 {
+		populares, erro := repositorio.consultar(ctx, `
+			select u.id, u.nome, u.nick, 0 from usuarios u
+			left join seguidores s on s.usuario_id = u.id
+			where u.id <> ? and u.deletadoEm is null
+			and u.id not in (select usuario_id from seguidores where seguidor_id = ?)
+			group by u.id, u.nome, u.nick
+			order by count(s.seguidor_id) desc, u.id
+			limit ?`,
+			usuarioID, usuarioID, quantidade,
+		)
+		if erro != nil {
+			return nil, erro
+		}
+
+		jaSugeridos := make(map[uint64]bool)
+		for _, sugestao := range sugestoes {
+			jaSugeridos[sugestao.Usuario.ID] = true
+		}
+
+		for _, popular := range populares {
+			if faltando == 0 {
+				break
+			}
+			if !jaSugeridos[popular.Usuario.ID] {
+				sugestoes = append(sugestoes, popular)
+				faltando--
+			}
+		}
+	}
+
+	if erro = repositorio.salvar(ctx, usuarioID, sugestoes); erro != nil {
+		return nil, erro
+	}
+
+	return sugestoes, nil
+}
+
+// BuscarDesatualizados traz os usuários cujas sugestões foram calculadas antes do limite informado,
+// dos mais desatualizados para os menos
+func (repositorio Sugestoes) BuscarDesatualizados(limite time.Time, quantidade int) ([]uint64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Sugestoes.BuscarDesatualizados")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select sc.usuario_id from sugestoes_calculadas sc
+		inner join usuarios u on u.id = sc.usuario_id
+		where sc.calculadasEm < ? and u.deletadoEm is null
+		order by sc.calculadasEm
+		limit ?`,
+		limite, quantidade,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var IDs []uint64
+	for linhas.Next() {
+		var ID uint64
+		if erro = linhas.Scan(&ID); erro != nil {
+			return nil, erro
+		}
+
+		IDs = append(IDs, ID)
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return nil, erro
+	}
+
+	return IDs, nil
+}
+
+// salvar substitui as sugestões guardadas de um usuário, mantendo a ordem recebida
+func (repositorio Sugestoes) salvar(ctx context.Context, usuarioID uint64, sugestoes []modelos.Sugestao) error {
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	if _, erro = transacao.ExecContext(ctx, "delete from sugestoes where usuario_id = ?", usuarioID); erro != nil {
+		return erro
+	}
+
+	for posicao, sugestao := range sugestoes {
+		if _, erro = transacao.ExecContext(ctx,
+			"insert into sugestoes (usuario_id, sugerido_id, conexoesEmComum, posicao) values (?, ?, ?, ?)",
+			usuarioID, sugestao.Usuario.ID, sugestao.ConexoesEmComum, posicao,
+		); erro != nil {
+			return erro
+		}
+	}
+
+	if _, erro = transacao.ExecContext(ctx, `
+		insert into sugestoes_calculadas (usuario_id, calculadasEm) values (?, current_timestamp())
+		on duplicate key update calculadasEm = values(calculadasEm)`,
+		usuarioID,
+	); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+// consultar executa uma consulta que traz o id, o nome, o nick e as conexões em comum dos usuários sugeridos
+func (repositorio Sugestoes) consultar(ctx context.Context, consulta string, parametros ...interface{}) ([]modelos.Sugestao, error) {
+	linhas, erro := repositorio.db.QueryContext(ctx, consulta, parametros...)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	sugestoes := []modelos.Sugestao{}
+	for linhas.Next() {
+		var sugestao modelos.Sugestao
+
+		if erro = linhas.Scan(
+			&sugestao.Usuario.ID,
+			&sugestao.Usuario.Nome,
+			&sugestao.Usuario.Nick,
+			&sugestao.ConexoesEmComum,
+		); erro != nil {
+			return nil, erro
+		}
+
+		sugestoes = append(sugestoes, sugestao)
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return nil, erro
+	}
+
+	return sugestoes, nil
+}
@@ -0,0 +1,197 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"api/src/relevancia"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Tendencias representa um repositório das publicações e hashtags em alta. Elas são calculadas
+// periodicamente e guardadas por janela de tempo, para não serem calculadas a cada requisição
+type Tendencias struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeTendencias cria um repositório de tendências que faz as consultas no contexto informado
+func NovoRepositorioDeTendencias(ctx context.Context, db *sql.DB) *Tendencias {
+	return &Tendencias{db, ctx}
+}
+
+// BuscarEngajamento traz as publicações criadas desde a data informada ou que receberam interações
+// depois dela, com a soma ponderada dessas interações, das mais engajadas para as menos
+func (repositorio Tendencias) BuscarEngajamento(desde time.Time, pesos relevancia.Pesos, limite int) ([]modelos.Engajamento, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Tendencias.BuscarEngajamento")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select p.id, p.conteudo, p.criadaEm, coalesce(e.pontuacao, 0) from publicacoes p
+		inner join usuarios u on u.id = p.autor_id
+		left join (
+			select publicacao_id, sum(peso) pontuacao from (
+				select publicacao_id, ? peso from reacoes where reagidoEm >= ?
+				union all
+				select publicacao_id, ? from salvos where salvoEm >= ?
+				union all
+				select e.publicacao_id, ? from enquete_votos v
+				inner join enquetes e on e.id = v.enquete_id where v.votadoEm >= ?
+			) eventos
+			group by publicacao_id
+		) e on e.publicacao_id = p.id
+		where (p.criadaEm >= ? or e.publicacao_id is not null)
+		and `+filtroPublicadas+`
+		order by coalesce(e.pontuacao, 0) desc, p.criadaEm desc
+		limit ?`,
+		pesos.Reacao, desde, pesos.Salvo, desde, pesos.Voto, desde, desde, limite,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var engajamentos []modelos.Engajamento
+	for linhas.Next() {
+		var engajamento modelos.Engajamento
+
+		if erro = linhas.Scan(
+			&engajamento.PublicacaoID,
+			&engajamento.Conteudo,
+			&engajamento.CriadaEm,
+			&engajamento.Pontuacao,
+		); erro != nil {
+			return nil, erro
+		}
+
+		engajamentos = append(engajamentos, engajamento)
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return nil, erro
+	}
+
+	return engajamentos, nil
+}
+
+// Salvar substitui as tendências guardadas de uma janela de tempo, mantendo a ordem recebida
+func (repositorio Tendencias) Salvar(janela string, publicacoes []modelos.Engajamento, hashtags []modelos.Hashtag) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Tendencias.Salvar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	if _, erro = transacao.ExecContext(ctx, "delete from tendencias_publicacoes where janela = ?", janela); erro != nil {
+		return erro
+	}
+
+	if _, erro = transacao.ExecContext(ctx, "delete from tendencias_hashtags where janela = ?", janela); erro != nil {
+		return erro
+	}
+
+	for posicao, publicacao := range publicacoes {
+		if _, erro = transacao.ExecContext(ctx,
+			"insert into tendencias_publicacoes (janela, posicao, publicacao_id, pontuacao) values (?, ?, ?, ?)",
+			janela, posicao, publicacao.PublicacaoID, publicacao.Pontuacao,
+		); erro != nil {
+			return erro
+		}
+	}
+
+	for posicao, hashtag := range hashtags {
+		if _, erro = transacao.ExecContext(ctx,
+			"insert into tendencias_hashtags (janela, posicao, hashtag, publicacoes, pontuacao) values (?, ?, ?, ?, ?)",
+			janela, posicao, hashtag.Nome, hashtag.Publicacoes, hashtag.Pontuacao,
+		); erro != nil {
+			return erro
+		}
+	}
+
+	if _, erro = transacao.ExecContext(ctx, `
+		insert into tendencias_calculadas (janela, calculadasEm) values (?, current_timestamp())
+		on duplicate key update calculadasEm = values(calculadasEm)`,
+		janela,
+	); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+// Buscar traz as tendências guardadas de uma janela de tempo. Publicações que foram deletadas ou deixaram
+// de estar visíveis depois do cálculo não aparecem
+func (repositorio Tendencias) Buscar(janela string) (modelos.Tendencias, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Tendencias.Buscar")
+	defer span.End()
+
+	tendencias := modelos.Tendencias{
+		Janela:      janela,
+		Publicacoes: []modelos.Publicacao{},
+		Hashtags:    []modelos.Hashtag{},
+	}
+
+	erro := repositorio.db.QueryRowContext(ctx,
+		"select calculadasEm from tendencias_calculadas where janela = ?", janela,
+	).Scan(&tendencias.CalculadasEm)
+	if erro == sql.ErrNoRows {
+		return tendencias, nil
+	}
+	if erro != nil {
+		return modelos.Tendencias{}, erro
+	}
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select `+colunasPublicacao+` from tendencias_publicacoes t
+		inner join publicacoes p on p.id = t.publicacao_id
+		inner join usuarios u on u.id = p.autor_id
+		where t.janela = ? and `+filtroPublicadas+`
+		order by t.posicao`,
+		janela,
+	)
+	if erro != nil {
+		return modelos.Tendencias{}, erro
+	}
+	defer linhas.Close()
+
+	for linhas.Next() {
+		publicacao, erro := escanearPublicacao(linhas)
+		if erro != nil {
+			return modelos.Tendencias{}, erro
+		}
+
+		tendencias.Publicacoes = append(tendencias.Publicacoes, publicacao)
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return modelos.Tendencias{}, erro
+	}
+
+	linhasHashtags, erro := repositorio.db.QueryContext(ctx,
+		"select hashtag, publicacoes, pontuacao from tendencias_hashtags where janela = ? order by posicao",
+		janela,
+	)
+	if erro != nil {
+		return modelos.Tendencias{}, erro
+	}
+	defer linhasHashtags.Close()
+
+	for linhasHashtags.Next() {
+		var hashtag modelos.Hashtag
+		if erro = linhasHashtags.Scan(&hashtag.Nome, &hashtag.Publicacoes, &hashtag.Pontuacao); erro != nil {
+			return modelos.Tendencias{}, erro
+		}
+
+		tendencias.Hashtags = append(tendencias.Hashtags, hashtag)
+	}
+
+	if erro = linhasHashtags.Err(); erro != nil {
+		return modelos.Tendencias{}, erro
+	}
+
+	return tendencias, nil
+}
@@ -0,0 +1,106 @@
+package repositorios
+
+import (
+	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
+	"database/sql"
+)
+
+// Trechos representa um repositório de trechos de código das publicações
+type Trechos struct {
+	db  *sql.DB
+	ctx context.Context
+}
+
+// NovoRepositorioDeTrechos cria um repositório de trechos de código que faz as consultas no contexto informado
+func NovoRepositorioDeTrechos(ctx context.Context, db *sql.DB) *Trechos {
+	return &Trechos{db, ctx}
+}
+
+// Substituir troca todos os trechos de código de uma publicação pelos informados e os retorna com seus IDs
+func (repositorio Trechos) Substituir(publicacaoID uint64, trechos []modelos.Trecho) ([]modelos.Trecho, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Trechos.Substituir")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return nil, erro
+	}
+	defer transacao.Rollback()
+
+	if _, erro = transacao.ExecContext(ctx, "delete from trechos where publicacao_id = ?", publicacaoID); erro != nil {
+		return nil, erro
+	}
+
+	statement, erro := transacao.PrepareContext(ctx,
+		"insert into trechos (publicacao_id, linguagem, nomeArquivo, codigo) values (?, ?, ?, ?)",
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer statement.Close()
+
+	salvos := make([]modelos.Trecho, 0, len(trechos))
+	for _, trecho := range trechos {
+		resultado, erro := statement.ExecContext(ctx, publicacaoID, trecho.Linguagem, trecho.NomeArquivo, trecho.Codigo)
+		if erro != nil {
+			return nil, erro
+		}
+
+		ultimoIDInserido, erro := resultado.LastInsertId()
+		if erro != nil {
+			return nil, erro
+		}
+
+		trecho.ID = uint64(ultimoIDInserido)
+		trecho.PublicacaoID = publicacaoID
+		salvos = append(salvos, trecho)
+	}
+
+	if erro = transacao.Commit(); erro != nil {
+		return nil, erro
+	}
+
+	return salvos, nil
+}
+
+// BuscarPorPublicacoes traz os trechos de código de várias publicações, agrupados pelo ID da publicação
+func (repositorio Trechos) BuscarPorPublicacoes(publicacaoIDs []uint64) (map[uint64][]modelos.Trecho, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Trechos.BuscarPorPublicacoes")
+	defer span.End()
+
+	trechos := make(map[uint64][]modelos.Trecho)
+	if len(publicacaoIDs) == 0 {
+		return trechos, nil
+	}
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select id, publicacao_id, linguagem, nomeArquivo, codigo
+		from trechos where publicacao_id in (`+marcadores(len(publicacaoIDs))+`)
+		order by id`,
+		argumentos(publicacaoIDs)...,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	for linhas.Next() {
+		var trecho modelos.Trecho
+
+		if erro = linhas.Scan(
+			&trecho.ID,
+			&trecho.PublicacaoID,
+			&trecho.Linguagem,
+			&trecho.NomeArquivo,
+			&trecho.Codigo,
+		); erro != nil {
+			return nil, erro
+		}
+
+		trechos[trecho.PublicacaoID] = append(trechos[trecho.PublicacaoID], trecho)
+	}
+
+	return trechos, linhas.Err()
+}
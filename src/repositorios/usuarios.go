@@ -2,23 +2,52 @@ package repositorios
 
 import (
 	"api/src/modelos"
+	"api/src/rastreamento"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+)
+
+var (
+	// ErrNickReservado é retornado quando o nick pertenceu a outro usuário e ainda está no período de reserva
+	ErrNickReservado = errors.New("este nick pertenceu a outro usuário recentemente e ainda está reservado")
+
+	// ErrTrocaDeNickMuitoRecente é retornado quando o usuário tenta trocar o nick antes do intervalo mínimo entre trocas
+	ErrTrocaDeNickMuitoRecente = errors.New("o nick foi alterado recentemente e ainda não pode ser trocado de novo")
+
+	// ErrUsuarioNaoEncontrado é retornado quando o usuário pedido não existe ou está desativado
+	ErrUsuarioNaoEncontrado = errors.New("usuário não encontrado")
 )
 
 // Usuarios representa um repositório de usuarios
 type Usuarios struct {
-	db *sql.DB
+	db  *sql.DB
+	ctx context.Context
 }
 
-// NovoRepositorioDeUsuarios cria um repositório de usuários
-func NovoRepositorioDeUsuarios(db *sql.DB) *Usuarios {
-	return &Usuarios{db}
+// NovoRepositorioDeUsuarios cria um repositório de usuários. O contexto é o da requisição que usa o repositório,
+// para que as consultas apareçam no trace dela
+func NovoRepositorioDeUsuarios(ctx context.Context, db *sql.DB) *Usuarios {
+	return &Usuarios{db, ctx}
 }
 
 // Criar insere um usuário no banco de dados
-func (repositorio Usuarios) Criar(usuario modelos.Usuario) (uint64, error) {
-	statement, erro := repositorio.db.Prepare(
+func (repositorio Usuarios) Criar(usuario modelos.Usuario, reservaNick time.Duration) (uint64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.Criar")
+	defer span.End()
+
+	reservado, erro := repositorio.nickReservado(ctx, usuario.Nick, 0, reservaNick)
+	if erro != nil {
+		return 0, erro
+	}
+
+	if reservado {
+		return 0, ErrNickReservado
+	}
+
+	statement, erro := repositorio.db.PrepareContext(ctx,
 		"insert into usuarios (nome, nick, email, senha) values(?, ?, ?, ?)",
 	)
 	if erro != nil {
@@ -26,7 +55,7 @@ func (repositorio Usuarios) Criar(usuario modelos.Usuario) (uint64, error) {
 	}
 	defer statement.Close()
 
-	resultado, erro := statement.Exec(usuario.Nome, usuario.Nick, usuario.Email, usuario.Senha)
+	resultado, erro := statement.ExecContext(ctx, usuario.Nome, usuario.Nick, usuario.Email, usuario.Senha)
 	if erro != nil {
 		return 0, erro
 	}
@@ -42,10 +71,13 @@ func (repositorio Usuarios) Criar(usuario modelos.Usuario) (uint64, error) {
 
 // Buscar traz todos os usuários que atendem um filtro de nome ou nick
 func (repositorio Usuarios) Buscar(nomeOuNick string) ([]modelos.Usuario, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.Buscar")
+	defer span.End()
+
 	nomeOuNick = fmt.Sprintf("%%%s%%", nomeOuNick) // %nomeOuNick%
 
-	linhas, erro := repositorio.db.Query(
-		"select id, nome, nick, email, criadoEm from usuarios where nome LIKE ? or nick LIKE ?",
+	linhas, erro := repositorio.db.QueryContext(ctx,
+		"select id, nome, nick, email, criadoEm from usuarios where (nome LIKE ? or nick LIKE ?) and deletadoEm is null",
 		nomeOuNick, nomeOuNick,
 	)
 
@@ -77,8 +109,11 @@ func (repositorio Usuarios) Buscar(nomeOuNick string) ([]modelos.Usuario, error)
 
 // BuscarPorID traz um usuário do banco de dados
 func (repositorio Usuarios) BuscarPorID(ID uint64) (modelos.Usuario, error) {
-	linhas, erro := repositorio.db.Query(
-		"select id, nome, nick, email, criadoEm from usuarios where id = ?",
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.BuscarPorID")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx,
+		"select id, nome, nick, email, criadoEm from usuarios where id = ? and deletadoEm is null",
 		ID,
 	)
 	if erro != nil {
@@ -104,40 +139,148 @@ func (repositorio Usuarios) BuscarPorID(ID uint64) (modelos.Usuario, error) {
 }
 
 // Atualizar altera as informações de um usuário no banco de dados
-func (repositorio Usuarios) Atualizar(ID uint64, usuario modelos.Usuario) error {
-	statement, erro := repositorio.db.Prepare(
+func (repositorio Usuarios) Atualizar(ID uint64, usuario modelos.Usuario, intervaloTrocaNick, reservaNick time.Duration) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.Atualizar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	var nickAtual string
+	var nickAlteradoEm *time.Time
+	erro = transacao.QueryRowContext(ctx,
+		"select nick, nickAlteradoEm from usuarios where id = ? and deletadoEm is null for update", ID,
+	).Scan(&nickAtual, &nickAlteradoEm)
+	if erro == sql.ErrNoRows {
+		return nil
+	}
+	if erro != nil {
+		return erro
+	}
+
+	if usuario.Nick != nickAtual {
+		if nickAlteradoEm != nil && time.Since(*nickAlteradoEm) < intervaloTrocaNick {
+			return ErrTrocaDeNickMuitoRecente
+		}
+
+		reservado, erro := repositorio.nickReservado(ctx, usuario.Nick, ID, reservaNick)
+		if erro != nil {
+			return erro
+		}
+
+		if reservado {
+			return ErrNickReservado
+		}
+
+		if _, erro = transacao.ExecContext(ctx,
+			"insert into nicks_anteriores (usuario_id, nick) values (?, ?)", ID, nickAtual,
+		); erro != nil {
+			return erro
+		}
+
+		if _, erro = transacao.ExecContext(ctx,
+			"update usuarios set nickAlteradoEm = current_timestamp() where id = ?", ID,
+		); erro != nil {
+			return erro
+		}
+	}
+
+	if _, erro = transacao.ExecContext(ctx,
 		"update usuarios set nome = ?, nick = ?, email = ? where id = ?",
-	)
+		usuario.Nome, usuario.Nick, usuario.Email, ID,
+	); erro != nil {
+		return erro
+	}
+
+	return transacao.Commit()
+}
+
+// Desativar esconde a conta de um usuário junto com as suas publicações. A conta volta a ficar visível
+// quando o usuário entra de novo
+func (repositorio Usuarios) Desativar(ID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.Desativar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
 	if erro != nil {
 		return erro
 	}
-	defer statement.Close()
+	defer transacao.Rollback()
 
-	if _, erro = statement.Exec(usuario.Nome, usuario.Nick, usuario.Email, ID); erro != nil {
+	if erro = desativar(ctx, transacao, ID); erro != nil {
 		return erro
 	}
 
-	return nil
+	return transacao.Commit()
+}
+
+// AgendarExclusao desativa a conta de um usuário e marca a data em que ela vai ser excluída definitivamente.
+// Se a exclusão já estava agendada, a data original é mantida. Retorna a data em que a conta vai ser excluída
+func (repositorio Usuarios) AgendarExclusao(ID uint64, exclusaoEm time.Time) (time.Time, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.AgendarExclusao")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return time.Time{}, erro
+	}
+	defer transacao.Rollback()
+
+	if erro = desativar(ctx, transacao, ID); erro != nil {
+		return time.Time{}, erro
+	}
+
+	if _, erro = transacao.ExecContext(ctx,
+		"update usuarios set exclusaoAgendadaEm = ? where id = ? and exclusaoAgendadaEm is null", exclusaoEm, ID,
+	); erro != nil {
+		return time.Time{}, erro
+	}
+
+	var agendadaEm time.Time
+	if erro = transacao.QueryRowContext(ctx, "select exclusaoAgendadaEm from usuarios where id = ?", ID).Scan(&agendadaEm); erro != nil {
+		return time.Time{}, erro
+	}
+
+	if erro = transacao.Commit(); erro != nil {
+		return time.Time{}, erro
+	}
+
+	return agendadaEm, nil
 }
 
-// Deletar exclui as informações de um usuário no banco de dados
-func (repositorio Usuarios) Deletar(ID uint64) error {
-	statement, erro := repositorio.db.Prepare("delete from usuarios where id = ?")
+// desativar marca a conta como desativada, se ela ainda não estiver, e tira ela dos contadores dos vizinhos
+func desativar(ctx context.Context, transacao *sql.Tx, ID uint64) error {
+	resultado, erro := transacao.ExecContext(ctx,
+		"update usuarios set deletadoEm = current_timestamp() where id = ? and deletadoEm is null", ID,
+	)
 	if erro != nil {
 		return erro
 	}
-	defer statement.Close()
 
-	if _, erro = statement.Exec(ID); erro != nil {
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
 		return erro
 	}
 
+	if linhasAfetadas > 0 {
+		return ajustarContadoresDosVizinhos(ctx, transacao, ID, -1)
+	}
+
 	return nil
 }
 
-// BuscarPorEmail busca um usuário por email e retorna o seu id e senha com hash
+// BuscarPorEmail busca um usuário por email e retorna o seu id, senha com hash, a data em que foi desativado,
+// a data marcada para a exclusão da conta e até quando ele está suspenso, se houver
 func (repositorio Usuarios) BuscarPorEmail(email string) (modelos.Usuario, error) {
-	linha, erro := repositorio.db.Query("select id, senha from usuarios where email = ?", email)
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.BuscarPorEmail")
+	defer span.End()
+
+	linha, erro := repositorio.db.QueryContext(ctx,
+		"select id, senha, deletadoEm, exclusaoAgendadaEm, suspensoAte from usuarios where email = ?", email,
+	)
 	if erro != nil {
 		return modelos.Usuario{}, erro
 	}
@@ -146,7 +289,7 @@ func (repositorio Usuarios) BuscarPorEmail(email string) (modelos.Usuario, error
 	var usuario modelos.Usuario
 
 	if linha.Next() {
-		if erro = linha.Scan(&usuario.ID, &usuario.Senha); erro != nil {
+		if erro = linha.Scan(&usuario.ID, &usuario.Senha, &usuario.DeletadoEm, &usuario.ExclusaoAgendadaEm, &usuario.SuspensoAte); erro != nil {
 			return modelos.Usuario{}, erro
 		}
 	}
@@ -155,47 +298,98 @@ func (repositorio Usuarios) BuscarPorEmail(email string) (modelos.Usuario, error
 
 }
 
+// BuscarSituacao traz as datas que dizem se a conta pode ser usada: desativação, exclusão agendada e suspensão
+func (repositorio Usuarios) BuscarSituacao(usuarioID uint64) (modelos.Usuario, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.BuscarSituacao")
+	defer span.End()
+
+	usuario := modelos.Usuario{ID: usuarioID}
+	erro := repositorio.db.QueryRowContext(ctx,
+		"select deletadoEm, exclusaoAgendadaEm, suspensoAte from usuarios where id = ?", usuarioID,
+	).Scan(&usuario.DeletadoEm, &usuario.ExclusaoAgendadaEm, &usuario.SuspensoAte)
+	if erro == sql.ErrNoRows {
+		return modelos.Usuario{}, ErrUsuarioNaoEncontrado
+	}
+	if erro != nil {
+		return modelos.Usuario{}, erro
+	}
+
+	return usuario, nil
+}
+
 // Seguir permite que um usuário siga outro
 func (repositorio Usuarios) Seguir(usuarioID, seguidorID uint64) error {
-	statement, erro := repositorio.db.Prepare(
-		"insert ignore into seguidores (usuario_id, seguidor_id) values (?, ?)",
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.Seguir")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	resultado, erro := transacao.ExecContext(ctx,
+		"insert ignore into seguidores (usuario_id, seguidor_id) values (?, ?)", usuarioID, seguidorID,
 	)
 	if erro != nil {
 		return erro
 	}
-	defer statement.Close()
 
-	if _, erro = statement.Exec(usuarioID, seguidorID); erro != nil {
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
 		return erro
 	}
 
-	return nil
+	if linhasAfetadas > 0 {
+		if erro = ajustarContadoresDeSeguidores(ctx, transacao, usuarioID, seguidorID, 1); erro != nil {
+			return erro
+		}
+	}
 
+	return transacao.Commit()
 }
 
 // PararDeSeguir permite que um usuário pare de seguir o outro
 func (repositorio Usuarios) PararDeSeguir(usuarioID, seguidorID uint64) error {
-	statement, erro := repositorio.db.Prepare(
-		"delete from seguidores where usuario_id = ? and seguidor_id = ?",
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.PararDeSeguir")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	resultado, erro := transacao.ExecContext(ctx,
+		"delete from seguidores where usuario_id = ? and seguidor_id = ?", usuarioID, seguidorID,
 	)
 	if erro != nil {
 		return erro
 	}
-	defer statement.Close()
 
-	if _, erro = statement.Exec(usuarioID, seguidorID); erro != nil {
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
 		return erro
 	}
 
-	return nil
+	if linhasAfetadas > 0 {
+		if erro = ajustarContadoresDeSeguidores(ctx, transacao, usuarioID, seguidorID, -1); erro != nil {
+			return erro
+		}
+	}
 
+	return transacao.Commit()
 }
 
 // BuscarSeguidores traz todos os seguidores de um usuário
 func (repositorio Usuarios) BuscarSeguidores(usuarioID uint64) ([]modelos.Usuario, error) {
-	linhas, erro := repositorio.db.Query(`
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.BuscarSeguidores")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
 		select u.id, u.nome, u.nick, u.email, u.criadoEm
-		from usuarios u inner join seguidores s on u.id = s.seguidor_id where s.usuario_id = ?`,
+		from usuarios u inner join seguidores s on u.id = s.seguidor_id
+		where s.usuario_id = ? and u.deletadoEm is null`,
 		usuarioID,
 	)
 	if erro != nil {
@@ -226,9 +420,13 @@ func (repositorio Usuarios) BuscarSeguidores(usuarioID uint64) ([]modelos.Usuari
 
 // BuscarSeguindo traz todos os usuários que um determinado usuário está seguindo
 func (repositorio Usuarios) BuscarSeguindo(usuarioID uint64) ([]modelos.Usuario, error) {
-	linhas, erro := repositorio.db.Query(`
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.BuscarSeguindo")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
 		select u.id, u.nome, u.nick, u.email, u.criadoEm
-		from usuarios u inner join seguidores s on u.id = s.usuario_id where s.seguidor_id = ?`,
+		from usuarios u inner join seguidores s on u.id = s.usuario_id
+		where s.seguidor_id = ? and u.deletadoEm is null`,
 		usuarioID,
 	)
 	if erro != nil {
@@ -259,7 +457,10 @@ func (repositorio Usuarios) BuscarSeguindo(usuarioID uint64) ([]modelos.Usuario,
 
 // BuscarSenha traz a senha de um usuário pelo ID
 func (repositorio Usuarios) BuscarSenha(usuarioID uint64) (string, error) {
-	linha, erro := repositorio.db.Query("select senha from usuarios where id = ?", usuarioID)
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.BuscarSenha")
+	defer span.End()
+
+	linha, erro := repositorio.db.QueryContext(ctx, "select senha from usuarios where id = ?", usuarioID)
 	if erro != nil {
 		return "", erro
 	}
@@ -278,15 +479,329 @@ func (repositorio Usuarios) BuscarSenha(usuarioID uint64) (string, error) {
 
 // AtualizarSenha altera a senha de um usuário
 func (repositorio Usuarios) AtualizarSenha(usuarioID uint64, senha string) error {
-	statement, erro := repositorio.db.Prepare("update usuarios set senha = ? where id = ?")
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.AtualizarSenha")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "update usuarios set senha = ? where id = ?")
 	if erro != nil {
 		return erro
 	}
 	defer statement.Close()
 
-	if _, erro = statement.Exec(senha, usuarioID); erro != nil {
+	if _, erro = statement.ExecContext(ctx, senha, usuarioID); erro != nil {
 		return erro
 	}
 
 	return nil
 }
+
+// Reativar volta a mostrar a conta de um usuário e cancela a exclusão dela, se estiver agendada
+func (repositorio Usuarios) Reativar(ID uint64) error {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.Reativar")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return erro
+	}
+	defer transacao.Rollback()
+
+	resultado, erro := transacao.ExecContext(ctx,
+		"update usuarios set deletadoEm = null, exclusaoAgendadaEm = null where id = ? and deletadoEm is not null", ID,
+	)
+	if erro != nil {
+		return erro
+	}
+
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
+		return erro
+	}
+
+	if linhasAfetadas > 0 {
+		if erro = ajustarContadoresDosVizinhos(ctx, transacao, ID, 1); erro != nil {
+			return erro
+		}
+	}
+
+	return transacao.Commit()
+}
+
+// BuscarExclusoesVencidas traz os IDs das contas cuja data de exclusão já chegou
+func (repositorio Usuarios) BuscarExclusoesVencidas(agora time.Time) ([]uint64, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.BuscarExclusoesVencidas")
+	defer span.End()
+
+	linhas, erro := repositorio.db.QueryContext(ctx,
+		"select id from usuarios where exclusaoAgendadaEm <= ? order by exclusaoAgendadaEm", agora,
+	)
+	if erro != nil {
+		return nil, erro
+	}
+	defer linhas.Close()
+
+	var IDs []uint64
+	for linhas.Next() {
+		var ID uint64
+		if erro = linhas.Scan(&ID); erro != nil {
+			return nil, erro
+		}
+		IDs = append(IDs, ID)
+	}
+
+	return IDs, linhas.Err()
+}
+
+// Excluir remove definitivamente uma conta com exclusão agendada, junto com tudo o que pertence a ela.
+// Retorna false se a exclusão foi cancelada nesse meio tempo
+func (repositorio Usuarios) Excluir(ID uint64) (bool, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.Excluir")
+	defer span.End()
+
+	statement, erro := repositorio.db.PrepareContext(ctx, "delete from usuarios where id = ? and exclusaoAgendadaEm is not null")
+	if erro != nil {
+		return false, erro
+	}
+	defer statement.Close()
+
+	resultado, erro := statement.ExecContext(ctx, ID)
+	if erro != nil {
+		return false, erro
+	}
+
+	linhasAfetadas, erro := resultado.RowsAffected()
+	if erro != nil {
+		return false, erro
+	}
+
+	return linhasAfetadas > 0, nil
+}
+
+// BuscarPerfil traz os dados do perfil de um usuário, com as contagens e a relação dele com o visitante.
+// Se o usuário não existir ou estiver na lixeira, o perfil retornado tem ID zero
+func (repositorio Usuarios) BuscarPerfil(usuarioID, visitanteID uint64, quantidadeEmComum int) (modelos.Perfil, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.BuscarPerfil")
+	defer span.End()
+
+	var perfil modelos.Perfil
+
+	erro := repositorio.db.QueryRowContext(ctx, `
+		select u.id, u.nome, u.nick, u.email, u.criadoEm, u.totalSeguidores, u.totalSeguindo, u.totalPublicacoes,
+		exists(select 1 from seguidores where usuario_id = u.id and seguidor_id = ?),
+		exists(select 1 from seguidores where usuario_id = ? and seguidor_id = u.id)
+		from usuarios u where u.id = ? and u.deletadoEm is null`,
+		visitanteID, visitanteID, usuarioID,
+	).Scan(
+		&perfil.ID,
+		&perfil.Nome,
+		&perfil.Nick,
+		&perfil.Email,
+		&perfil.CriadoEm,
+		&perfil.Seguidores,
+		&perfil.Seguindo,
+		&perfil.Publicacoes,
+		&perfil.EuSigo,
+		&perfil.MeSegue,
+	)
+	if erro == sql.ErrNoRows {
+		return modelos.Perfil{}, nil
+	}
+	if erro != nil {
+		return modelos.Perfil{}, erro
+	}
+
+	if erro = repositorio.db.QueryRowContext(ctx, `
+		select count(*) from seguidores s
+		inner join seguidores v on v.usuario_id = s.seguidor_id and v.seguidor_id = ?
+		inner join usuarios u on u.id = s.seguidor_id
+		where s.usuario_id = ? and u.deletadoEm is null`,
+		visitanteID, usuarioID,
+	).Scan(&perfil.TotalSeguidoresEmComum); erro != nil {
+		return modelos.Perfil{}, erro
+	}
+
+	linhas, erro := repositorio.db.QueryContext(ctx, `
+		select u.id, u.nome, u.nick from seguidores s
+		inner join seguidores v on v.usuario_id = s.seguidor_id and v.seguidor_id = ?
+		inner join usuarios u on u.id = s.seguidor_id
+		where s.usuario_id = ? and u.deletadoEm is null
+		order by u.totalSeguidores desc, u.id
+		limit ?`,
+		visitanteID, usuarioID, quantidadeEmComum,
+	)
+	if erro != nil {
+		return modelos.Perfil{}, erro
+	}
+	defer linhas.Close()
+
+	perfil.SeguidoresEmComum = []modelos.Usuario{}
+	for linhas.Next() {
+		var usuario modelos.Usuario
+		if erro = linhas.Scan(&usuario.ID, &usuario.Nome, &usuario.Nick); erro != nil {
+			return modelos.Perfil{}, erro
+		}
+
+		perfil.SeguidoresEmComum = append(perfil.SeguidoresEmComum, usuario)
+	}
+
+	if erro = linhas.Err(); erro != nil {
+		return modelos.Perfil{}, erro
+	}
+
+	return perfil, nil
+}
+
+// ajustarContadoresDeSeguidores soma o valor informado aos contadores de quem segue e de quem é seguido.
+// Os contadores só contam relações com contas fora da lixeira, então o contador de um dos lados só muda
+// se o outro estiver ativo
+func ajustarContadoresDeSeguidores(ctx context.Context, transacao *sql.Tx, usuarioID, seguidorID uint64, valor int) error {
+	if _, erro := transacao.ExecContext(ctx, `
+		update usuarios u
+		inner join usuarios seguidor on seguidor.id = ? and seguidor.deletadoEm is null
+		set u.totalSeguidores = greatest(u.totalSeguidores + ?, 0)
+		where u.id = ?`,
+		seguidorID, valor, usuarioID,
+	); erro != nil {
+		return erro
+	}
+
+	if _, erro := transacao.ExecContext(ctx, `
+		update usuarios u
+		inner join usuarios seguido on seguido.id = ? and seguido.deletadoEm is null
+		set u.totalSeguindo = greatest(u.totalSeguindo + ?, 0)
+		where u.id = ?`,
+		usuarioID, valor, seguidorID,
+	); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// ajustarContadoresDosVizinhos soma o valor informado aos contadores de todos que seguem ou são seguidos
+// pelo usuário, quando ele vai para a lixeira ou sai dela
+func ajustarContadoresDosVizinhos(ctx context.Context, transacao *sql.Tx, usuarioID uint64, valor int) error {
+	if _, erro := transacao.ExecContext(ctx, `
+		update usuarios set totalSeguidores = greatest(totalSeguidores + ?, 0)
+		where id in (select usuario_id from seguidores where seguidor_id = ?)`,
+		valor, usuarioID,
+	); erro != nil {
+		return erro
+	}
+
+	if _, erro := transacao.ExecContext(ctx, `
+		update usuarios set totalSeguindo = greatest(totalSeguindo + ?, 0)
+		where id in (select seguidor_id from seguidores where usuario_id = ?)`,
+		valor, usuarioID,
+	); erro != nil {
+		return erro
+	}
+
+	return nil
+}
+
+// BuscarPorNick traz o ID e o nick atual do usuário que usa ou já usou o nick informado. Quem usa o nick
+// agora tem preferência; se ninguém o usa, o último usuário que o deixou é retornado. Se nenhum usuário
+// ativo for encontrado, o ID retornado é zero
+func (repositorio Usuarios) BuscarPorNick(nick string) (uint64, string, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.BuscarPorNick")
+	defer span.End()
+
+	var usuarioID uint64
+	var nickAtual string
+
+	erro := repositorio.db.QueryRowContext(ctx, `
+		select u.id, u.nick from (
+			select id usuario_id, 0 ordem, current_timestamp() trocadoEm from usuarios where nick = ?
+			union all
+			select usuario_id, 1, trocadoEm from nicks_anteriores where nick = ?
+		) n
+		inner join usuarios u on u.id = n.usuario_id
+		where u.deletadoEm is null
+		order by n.ordem, n.trocadoEm desc
+		limit 1`,
+		nick, nick,
+	).Scan(&usuarioID, &nickAtual)
+	if erro == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	if erro != nil {
+		return 0, "", erro
+	}
+
+	return usuarioID, nickAtual, nil
+}
+
+// nickReservado verifica se o nick foi deixado por outro usuário há menos tempo do que o período de reserva
+func (repositorio Usuarios) nickReservado(ctx context.Context, nick string, usuarioID uint64, reserva time.Duration) (bool, error) {
+	var reservado bool
+
+	erro := repositorio.db.QueryRowContext(ctx, `
+		select exists(
+			select 1 from nicks_anteriores where nick = ? and usuario_id <> ? and trocadoEm > ?
+		)`,
+		nick, usuarioID, time.Now().Add(-reserva),
+	).Scan(&reservado)
+
+	return reservado, erro
+}
+
+// EhModerador indica se o usuário pode atuar na fila de moderação
+func (repositorio Usuarios) EhModerador(usuarioID uint64) (bool, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.EhModerador")
+	defer span.End()
+
+	var moderador bool
+	erro := repositorio.db.QueryRowContext(ctx,
+		"select moderador from usuarios where id = ? and deletadoEm is null", usuarioID,
+	).Scan(&moderador)
+	if erro == sql.ErrNoRows {
+		return false, nil
+	}
+
+	return moderador, erro
+}
+
+// EhAdministrador indica se o usuário pode consultar a auditoria e definir os moderadores
+func (repositorio Usuarios) EhAdministrador(usuarioID uint64) (bool, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.EhAdministrador")
+	defer span.End()
+
+	var administrador bool
+	erro := repositorio.db.QueryRowContext(ctx,
+		"select administrador from usuarios where id = ? and deletadoEm is null", usuarioID,
+	).Scan(&administrador)
+	if erro == sql.ErrNoRows {
+		return false, nil
+	}
+
+	return administrador, erro
+}
+
+// DefinirModerador concede ou retira o papel de moderador e retorna o valor que ele tinha antes
+func (repositorio Usuarios) DefinirModerador(usuarioID uint64, moderador bool) (bool, error) {
+	ctx, span := rastreamento.IniciarFilho(repositorio.ctx, "Usuarios.DefinirModerador")
+	defer span.End()
+
+	transacao, erro := repositorio.db.BeginTx(ctx, nil)
+	if erro != nil {
+		return false, erro
+	}
+	defer transacao.Rollback()
+
+	var anterior bool
+	if erro = transacao.QueryRowContext(ctx,
+		"select moderador from usuarios where id = ? and deletadoEm is null for update", usuarioID,
+	).Scan(&anterior); erro != nil {
+		if erro == sql.ErrNoRows {
+			return false, ErrUsuarioNaoEncontrado
+		}
+		return false, erro
+	}
+
+	if _, erro = transacao.ExecContext(ctx, "update usuarios set moderador = ? where id = ?", moderador, usuarioID); erro != nil {
+		return false, erro
+	}
+
+	return anterior, transacao.Commit()
+}
@@ -0,0 +1,27 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasAuditoria = []Rota{
+	{
+		URI:                "/auditoria",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarAuditoria,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/auditoria",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarAuditoriaDoUsuario,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/moderador",
+		Metodo:             http.MethodPut,
+		Funcao:             controllers.DefinirModerador,
+		RequerAutenticacao: true,
+	},
+}
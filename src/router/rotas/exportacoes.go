@@ -0,0 +1,28 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasExportacoes = []Rota{
+	{
+		URI:                "/usuarios/{usuarioId}/exportacao",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.SolicitarExportacao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/exportacao",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarExportacoes,
+		RequerAutenticacao: true,
+	},
+	{
+		// O link é assinado e tem validade, então o download não exige token
+		URI:                "/exportacoes/{exportacaoId}/download",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BaixarExportacao,
+		RequerAutenticacao: false,
+	},
+}
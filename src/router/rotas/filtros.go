@@ -0,0 +1,45 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasFiltros = []Rota{
+	{
+		URI:                "/moderacao/filtros",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarFiltros,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/moderacao/filtros",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.CriarFiltro,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/moderacao/filtros/{filtroId}",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.DeletarFiltro,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/palavras-silenciadas",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarPalavrasSilenciadas,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/palavras-silenciadas",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.SilenciarPalavra,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/palavras-silenciadas/{palavra}",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.RemoverPalavraSilenciada,
+		RequerAutenticacao: true,
+	},
+}
@@ -0,0 +1,13 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotaMidias = Rota{
+	URI:                "/midias/{chave:.+}",
+	Metodo:             http.MethodGet,
+	Funcao:             controllers.BuscarMidia,
+	RequerAutenticacao: false,
+}
@@ -0,0 +1,51 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasModeracao = []Rota{
+	{
+		URI:                "/publicacoes/{publicacaoId}/denunciar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.DenunciarPublicacao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/denunciar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.DenunciarUsuario,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/{usuarioId}/advertencias",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarAdvertencias,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/moderacao/denuncias",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarDenuncias,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/moderacao/denuncias/{denunciaId}/atribuir",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.AtribuirDenuncia,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/moderacao/denuncias/{denunciaId}/resolver",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.ResolverDenuncia,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/moderacao/denuncias/{denunciaId}/historico",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarDecisoes,
+		RequerAutenticacao: true,
+	},
+}
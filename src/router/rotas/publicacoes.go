@@ -18,6 +18,30 @@ var rotasPublicacoes = []Rota{
 		Funcao:             controllers.BuscarPublicacoes,
 		RequerAutenticacao: true,
 	},
+	{
+		URI:                "/publicacoes/lixeira",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarLixeira,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/rascunhos",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarRascunhos,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/rascunhos/{publicacaoId}",
+		Metodo:             http.MethodPut,
+		Funcao:             controllers.AtualizarRascunho,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/rascunhos/{publicacaoId}",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.DeletarRascunho,
+		RequerAutenticacao: true,
+	},
 	{
 		URI:                "/publicacoes/{publicacaoId}",
 		Metodo:             http.MethodGet,
@@ -54,4 +78,40 @@ var rotasPublicacoes = []Rota{
 		Funcao:             controllers.DescurtirPublicacao,
 		RequerAutenticacao: true,
 	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/restaurar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.RestaurarPublicacao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/trechos",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarTrechosDestacados,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/votar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.VotarEnquete,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/desvotar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.RemoverVotoEnquete,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/fixar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.FixarPublicacao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/desafixar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.DesafixarPublicacao,
+		RequerAutenticacao: true,
+	},
 }
@@ -0,0 +1,27 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasReacoes = []Rota{
+	{
+		URI:                "/publicacoes/{publicacaoId}/reacoes",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.ReagirPublicacao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/reacoes",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.RemoverReacao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/reacoes",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarReacoes,
+		RequerAutenticacao: true,
+	},
+}
@@ -1,7 +1,11 @@
 package rotas
 
 import (
+	"api/src/config"
+	"api/src/controllers"
+	"api/src/metricas"
 	"api/src/middlewares"
+	"database/sql"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -16,28 +20,51 @@ type Rota struct {
 }
 
 // Configurar coloca todas as rotas dentro do router
-func Configurar(r *mux.Router) *mux.Router {
+func Configurar(r *mux.Router, db *sql.DB) *mux.Router {
 	rotas := rotasUsuarios
 	rotas = append(rotas, rotaLogin)
 	rotas = append(rotas, rotasPublicacoes...)
+	rotas = append(rotas, rotasSalvos...)
+	rotas = append(rotas, rotasReacoes...)
+	rotas = append(rotas, rotaTendencias)
+	rotas = append(rotas, rotasExportacoes...)
+	rotas = append(rotas, rotasModeracao...)
+	rotas = append(rotas, rotasFiltros...)
+	rotas = append(rotas, rotasAuditoria...)
+	rotas = append(rotas, rotaMidias)
 
 	for _, rota := range rotas {
 		if rota.RequerAutenticacao {
 			r.HandleFunc(rota.URI,
-				middlewares.Logger(
-					middlewares.InjetarDependencias(
-						middlewares.Autenticar(rota.Funcao),
+				middlewares.Rastrear(rota.URI,
+					middlewares.Medir(rota.URI,
+						middlewares.Logger(
+							middlewares.Autenticar(db,
+								middlewares.InjetarDependencias(db, rota.Funcao),
+							),
+						),
 					),
 				),
 			).Methods(rota.Metodo)
 		} else {
 			r.HandleFunc(rota.URI, 
-				middlewares.Logger(
-					middlewares.InjetarDependencias(rota.Funcao),
+				middlewares.Rastrear(rota.URI,
+					middlewares.Medir(rota.URI,
+						middlewares.Logger(
+							middlewares.InjetarDependencias(db, rota.Funcao),
+						),
+					),
 				),
 			).Methods(rota.Metodo)
 		}
 	}
 
+	// As métricas ficam fora dos middlewares para que cada coleta do Prometheus não apareça no log nem nas próprias métricas
+	r.Handle("/metrics", metricas.Handler(config.TokenMetricas)).Methods(http.MethodGet)
+
+	// As verificações de saúde também ficam de fora, já que o orquestrador as chama a cada poucos segundos
+	r.HandleFunc("/healthz", controllers.VerificarVida).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", middlewares.InjetarDependencias(db, controllers.VerificarProntidao)).Methods(http.MethodGet)
+
 	return r
 }
@@ -0,0 +1,51 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotasSalvos = []Rota{
+	{
+		URI:                "/publicacoes/{publicacaoId}/salvar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.SalvarPublicacao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/publicacoes/{publicacaoId}/salvar",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.RemoverPublicacaoSalva,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/salvos",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarSalvos,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/colecoes",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.CriarColecao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/colecoes",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarColecoes,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/colecoes/{colecaoId}",
+		Metodo:             http.MethodPut,
+		Funcao:             controllers.AtualizarColecao,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/colecoes/{colecaoId}",
+		Metodo:             http.MethodDelete,
+		Funcao:             controllers.DeletarColecao,
+		RequerAutenticacao: true,
+	},
+}
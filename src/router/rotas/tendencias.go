@@ -0,0 +1,13 @@
+package rotas
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var rotaTendencias = Rota{
+	URI:                "/tendencias",
+	Metodo:             http.MethodGet,
+	Funcao:             controllers.BuscarTendencias,
+	RequerAutenticacao: true,
+}
@@ -18,6 +18,18 @@ var rotasUsuarios = []Rota{
 		Funcao:             controllers.BuscarUsuarios,
 		RequerAutenticacao: true,
 	},
+	{
+		URI:                "/usuarios/nick/{nick}",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarUsuarioPorNick,
+		RequerAutenticacao: true,
+	},
+	{
+		URI:                "/usuarios/sugestoes",
+		Metodo:             http.MethodGet,
+		Funcao:             controllers.BuscarSugestoes,
+		RequerAutenticacao: true,
+	},
 	{
 		URI:                "/usuarios/{usuarioId}",
 		Metodo:             http.MethodGet,
@@ -36,6 +48,12 @@ var rotasUsuarios = []Rota{
 		Funcao:             controllers.DeletarUsuario,
 		RequerAutenticacao: true,
 	},
+	{
+		URI:                "/usuarios/{usuarioId}/desativar",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.DesativarUsuario,
+		RequerAutenticacao: true,
+	},
 	{
 		URI:                "/usuarios/{usuarioId}/seguir",
 		Metodo:             http.MethodPost,
@@ -66,4 +84,10 @@ var rotasUsuarios = []Rota{
 		Funcao:             controllers.AtualizarSenha,
 		RequerAutenticacao: true,
 	},
+	{
+		URI:                "/usuarios/cancelar-exclusao",
+		Metodo:             http.MethodPost,
+		Funcao:             controllers.CancelarExclusaoUsuario,
+		RequerAutenticacao: false,
+	},
 }
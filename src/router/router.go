@@ -2,12 +2,13 @@ package router
 
 import (
 	"api/src/router/rotas"
+	"database/sql"
 
 	"github.com/gorilla/mux"
 )
 
-// Gerar vai retornar um router com as rotas configuradas
-func Gerar() *mux.Router {
+// Gerar vai retornar um router com as rotas configuradas, usando o pool de conexões informado
+func Gerar(db *sql.DB) *mux.Router {
 	r := mux.NewRouter()
-	return rotas.Configurar(r)
+	return rotas.Configurar(r, db)
 }
@@ -0,0 +1,35 @@
+package tarefas
+
+import (
+	"api/src/config"
+	"api/src/logs"
+	"api/src/repositorios"
+	"context"
+	"time"
+)
+
+var tarefaPublicarAgendadas = Tarefa{
+	Nome:      "publicar-agendadas",
+	Intervalo: func() time.Duration { return config.IntervaloAgendador },
+	Funcao:    publicarAgendadas,
+}
+
+// publicarAgendadas publica as publicações cuja data agendada já passou
+func publicarAgendadas(ctx context.Context, repos *repositorios.Repositories) error {
+	publicadas, erro := repos.Publicacao.PublicarAgendadas(time.Now())
+	if erro != nil {
+		return erro
+	}
+
+	for _, publicacaoID := range publicadas {
+		if erro = repos.LinhaDoTempo.Distribuir(publicacaoID, config.LimiteSeguidoresLinhaDoTempo); erro != nil {
+			logs.DoContexto(ctx).Error("agendador: não foi possível distribuir a publicação", "publicacaoId", publicacaoID, "erro", erro)
+		}
+	}
+
+	if len(publicadas) > 0 {
+		logs.DoContexto(ctx).Info("agendador: publicações publicadas", "quantidade", len(publicadas))
+	}
+
+	return nil
+}
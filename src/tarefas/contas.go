@@ -0,0 +1,81 @@
+package tarefas
+
+import (
+	"api/src/armazenamento"
+	"api/src/config"
+	"api/src/logs"
+	"api/src/modelos"
+	"api/src/repositorios"
+	"context"
+	"fmt"
+	"time"
+)
+
+var tarefaExcluirContas = Tarefa{
+	Nome:      "excluir-contas",
+	Intervalo: func() time.Duration { return config.IntervaloExclusaoContas },
+	Funcao:    excluirContas,
+}
+
+// excluirContas remove definitivamente as contas cujo prazo de exclusão venceu. O banco apaga em cascata
+// as publicações, relações, reações, salvos e votos da conta; os arquivos das imagens e das exportações
+// de dados são apagados do armazenamento em seguida
+func excluirContas(ctx context.Context, repos *repositorios.Repositories) error {
+	IDs, erro := repos.Usuario.BuscarExclusoesVencidas(time.Now())
+	if erro != nil {
+		return erro
+	}
+
+	var excluidas int
+	for _, usuarioID := range IDs {
+		if erro = ctx.Err(); erro != nil {
+			return erro
+		}
+
+		chaves, erro := repos.Anexo.BuscarChavesPorAutor(usuarioID)
+		if erro != nil {
+			return erro
+		}
+
+		exportacoes, erro := repos.Exportacao.BuscarPorUsuario(usuarioID)
+		if erro != nil {
+			return erro
+		}
+
+		for _, exportacao := range exportacoes {
+			if exportacao.Chave != "" {
+				chaves = append(chaves, exportacao.Chave)
+			}
+		}
+
+		excluida, erro := repos.Usuario.Excluir(usuarioID)
+		if erro != nil {
+			return erro
+		}
+
+		if !excluida {
+			continue
+		}
+		excluidas++
+
+		// A conta já não existe, então o registro guarda o ID só no estado final
+		if erro = repos.Auditoria.Registrar(modelos.RegistroAuditoria{
+			Acao:   modelos.AuditoriaContaExcluida,
+			Depois: []byte(fmt.Sprintf(`{"usuarioId":%d}`, usuarioID)),
+		}); erro != nil {
+			logs.DoContexto(ctx).Error("contas: não foi possível registrar a exclusão na auditoria", "usuarioId", usuarioID, "erro", erro)
+		}
+
+		for _, chave := range chaves {
+			if erro = armazenamento.Padrao.Remover(ctx, chave); erro != nil {
+				logs.DoContexto(ctx).Warn("contas: não foi possível remover o arquivo", "chave", chave, "erro", erro)
+			}
+		}
+	}
+
+	if excluidas > 0 {
+		logs.DoContexto(ctx).Info("contas: contas excluídas definitivamente", "quantidade", excluidas)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package tarefas
+
+import (
+	"api/src/armazenamento"
+	"api/src/config"
+	"api/src/exportacao"
+	"api/src/logs"
+	"api/src/repositorios"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"time"
+)
+
+// tempoMaximoExportacao é quanto tempo uma exportação pode ficar em processamento antes de ser considerada
+// abandonada, como quando a instância que a gerava é encerrada no meio do trabalho
+const tempoMaximoExportacao = 30 * time.Minute
+
+var tarefaGerarExportacoes = Tarefa{
+	Nome:      "gerar-exportacoes",
+	Intervalo: func() time.Duration { return config.IntervaloExportacoes },
+	Funcao:    gerarExportacoes,
+}
+
+// gerarExportacoes gera os arquivos das exportações de dados pendentes e remove as que já expiraram
+func gerarExportacoes(ctx context.Context, repos *repositorios.Repositories) error {
+	for {
+		if erro := ctx.Err(); erro != nil {
+			return erro
+		}
+
+		pedido, erro := repos.Exportacao.Reservar(time.Now().Add(-tempoMaximoExportacao))
+		if erro != nil {
+			return erro
+		}
+
+		if pedido.ID == 0 {
+			break
+		}
+
+		chave, erro := gerarExportacao(ctx, repos, pedido.UsuarioID)
+		if erro != nil && ctx.Err() != nil {
+			// A interrupção não é culpa do pedido, que volta para a fila para ser gerado de novo
+			if erro = repos.Exportacao.Devolver(pedido.ID); erro != nil {
+				return erro
+			}
+			return ctx.Err()
+		}
+
+		if erro != nil {
+			logs.DoContexto(ctx).Error("exportações: não foi possível gerar a exportação", "exportacaoId", pedido.ID, "erro", erro)
+			if erro = repos.Exportacao.Falhar(pedido.ID); erro != nil {
+				return erro
+			}
+			continue
+		}
+
+		if erro = repos.Exportacao.Concluir(pedido.ID, chave, time.Now().Add(config.ValidadeExportacao)); erro != nil {
+			return erro
+		}
+	}
+
+	return removerExportacoesExpiradas(ctx, repos)
+}
+
+// gerarExportacao monta o arquivo com os dados do usuário, guarda no armazenamento e retorna a chave dele.
+// O arquivo é montado num temporário em disco, para que uma conta com muitas imagens não esgote a memória
+func gerarExportacao(ctx context.Context, repos *repositorios.Repositories, usuarioID uint64) (string, error) {
+	temporario, erro := os.CreateTemp("", "exportacao-*.zip")
+	if erro != nil {
+		return "", erro
+	}
+	defer os.Remove(temporario.Name())
+	defer temporario.Close()
+
+	if erro = exportacao.Gerar(ctx, repos, usuarioID, temporario); erro != nil {
+		return "", erro
+	}
+
+	tamanho, erro := temporario.Seek(0, io.SeekCurrent)
+	if erro != nil {
+		return "", erro
+	}
+
+	if _, erro = temporario.Seek(0, io.SeekStart); erro != nil {
+		return "", erro
+	}
+
+	bytes := make([]byte, 16)
+	if _, erro = rand.Read(bytes); erro != nil {
+		return "", erro
+	}
+
+	chave := "exportacoes/" + hex.EncodeToString(bytes) + ".zip"
+	if erro = armazenamento.Padrao.Copiar(ctx, chave, "application/zip", temporario, tamanho); erro != nil {
+		return "", erro
+	}
+
+	return chave, nil
+}
+
+// removerExportacoesExpiradas apaga os arquivos e registros das exportações que passaram do prazo para download
+func removerExportacoesExpiradas(ctx context.Context, repos *repositorios.Repositories) error {
+	expiradas, erro := repos.Exportacao.BuscarExpiradas(time.Now())
+	if erro != nil {
+		return erro
+	}
+
+	for _, expirada := range expiradas {
+		if expirada.Chave != "" {
+			if erro = armazenamento.Padrao.Remover(ctx, expirada.Chave); erro != nil {
+				logs.DoContexto(ctx).Warn("exportações: não foi possível remover o arquivo", "chave", expirada.Chave, "erro", erro)
+				continue
+			}
+		}
+
+		if erro = repos.Exportacao.Deletar(expirada.ID); erro != nil {
+			return erro
+		}
+	}
+
+	return nil
+}
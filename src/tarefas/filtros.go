@@ -0,0 +1,26 @@
+package tarefas
+
+import (
+	"api/src/config"
+	"api/src/repositorios"
+	"api/src/triagem"
+	"context"
+	"time"
+)
+
+var tarefaAtualizarFiltros = Tarefa{
+	Nome:      "atualizar-filtros",
+	Intervalo: func() time.Duration { return config.IntervaloFiltros },
+	Funcao:    atualizarFiltros,
+}
+
+// atualizarFiltros relê as regras de filtro de conteúdo, para que as mudanças feitas por moderadores
+// em outra instância da API também passem a valer nesta
+func atualizarFiltros(ctx context.Context, repos *repositorios.Repositories) error {
+	regras, erro := repos.Filtro.BuscarRegras()
+	if erro != nil {
+		return erro
+	}
+
+	return triagem.Carregar(regras)
+}
@@ -0,0 +1,39 @@
+package tarefas
+
+import (
+	"api/src/config"
+	"api/src/logs"
+	"api/src/repositorios"
+	"context"
+)
+
+// ReconstruirLinhasDoTempo monta de novo a linha do tempo de todos os usuários ativos. Não roda
+// periodicamente: serve para corrigir linhas do tempo que ficaram para trás, por exemplo depois de
+// mudar o limite de seguidores da distribuição
+func ReconstruirLinhasDoTempo(ctx context.Context, repos *repositorios.Repositories) error {
+	usuarios, erro := repos.LinhaDoTempo.BuscarUsuarios()
+	if erro != nil {
+		return erro
+	}
+
+	for i, usuarioID := range usuarios {
+		if erro = ctx.Err(); erro != nil {
+			return erro
+		}
+
+		if erro = repos.LinhaDoTempo.Reconstruir(
+			usuarioID,
+			config.LimiteSeguidoresLinhaDoTempo,
+			config.TamanhoLinhaDoTempo,
+		); erro != nil {
+			return erro
+		}
+
+		if (i+1)%1000 == 0 {
+			logs.DoContexto(ctx).Info("linha do tempo: reconstrução em andamento", "reconstruidos", i+1, "total", len(usuarios))
+		}
+	}
+
+	logs.DoContexto(ctx).Info("linha do tempo: reconstrução concluída", "total", len(usuarios))
+	return nil
+}
@@ -0,0 +1,44 @@
+package tarefas
+
+import (
+	"api/src/armazenamento"
+	"api/src/config"
+	"api/src/logs"
+	"api/src/repositorios"
+	"context"
+	"time"
+)
+
+var tarefaLimparLixeira = Tarefa{
+	Nome:      "limpar-lixeira",
+	Intervalo: func() time.Duration { return config.IntervaloLimpezaLixeira },
+	Funcao:    limparLixeira,
+}
+
+// limparLixeira remove definitivamente as publicações que passaram do período de retenção na lixeira,
+// junto com as imagens anexadas a elas
+func limparLixeira(ctx context.Context, repos *repositorios.Repositories) error {
+	limite := time.Now().Add(-config.RetencaoLixeira)
+
+	chaves, erro := repos.Anexo.BuscarChavesPurgaveis(limite)
+	if erro != nil {
+		return erro
+	}
+
+	publicacoes, erro := repos.Publicacao.Purgar(limite)
+	if erro != nil {
+		return erro
+	}
+
+	for _, chave := range chaves {
+		if erro = armazenamento.Padrao.Remover(ctx, chave); erro != nil {
+			logs.DoContexto(ctx).Warn("lixeira: não foi possível remover o arquivo", "chave", chave, "erro", erro)
+		}
+	}
+
+	if publicacoes > 0 {
+		logs.DoContexto(ctx).Info("lixeira: publicações removidas definitivamente", "quantidade", publicacoes)
+	}
+
+	return nil
+}
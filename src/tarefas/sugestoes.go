@@ -0,0 +1,40 @@
+package tarefas
+
+import (
+	"api/src/config"
+	"api/src/logs"
+	"api/src/repositorios"
+	"context"
+	"time"
+)
+
+var tarefaAtualizarSugestoes = Tarefa{
+	Nome:      "atualizar-sugestoes",
+	Intervalo: func() time.Duration { return config.IntervaloSugestoes },
+	Funcao:    atualizarSugestoes,
+}
+
+// atualizarSugestoes recalcula as sugestões de quem seguir que passaram da validade, para que os usuários
+// não precisem esperar o cálculo na próxima vez que pedirem as sugestões
+func atualizarSugestoes(ctx context.Context, repos *repositorios.Repositories) error {
+	usuarios, erro := repos.Sugestao.BuscarDesatualizados(time.Now().Add(-config.ValidadeSugestoes), config.LoteSugestoes)
+	if erro != nil {
+		return erro
+	}
+
+	for _, usuarioID := range usuarios {
+		if erro = ctx.Err(); erro != nil {
+			return erro
+		}
+
+		if _, erro = repos.Sugestao.Recalcular(usuarioID, config.QuantidadeSugestoes); erro != nil {
+			return erro
+		}
+	}
+
+	if len(usuarios) > 0 {
+		logs.DoContexto(ctx).Info("sugestoes: sugestões recalculadas", "usuarios", len(usuarios))
+	}
+
+	return nil
+}
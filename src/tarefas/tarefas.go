@@ -0,0 +1,62 @@
+package tarefas
+
+import (
+	"api/src/logs"
+	"api/src/repositorios"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Tarefa representa um trabalho executado periodicamente em segundo plano
+type Tarefa struct {
+	Nome      string
+	Intervalo func() time.Duration
+	Funcao    func(ctx context.Context, repos *repositorios.Repositories) error
+}
+
+// Iniciar coloca todas as tarefas para rodar até que o contexto seja cancelado.
+// O WaitGroup retornado é liberado quando todas elas terminarem
+func Iniciar(ctx context.Context, repos *repositorios.Repositories) *sync.WaitGroup {
+	tarefas := []Tarefa{
+		tarefaAtualizarFiltros,
+		tarefaLimparLixeira,
+		tarefaExcluirContas,
+		tarefaPublicarAgendadas,
+		tarefaAtualizarSugestoes,
+		tarefaCalcularTendencias,
+		tarefaGerarExportacoes,
+	}
+
+	var grupo sync.WaitGroup
+	for _, tarefa := range tarefas {
+		grupo.Add(1)
+		go func(tarefa Tarefa) {
+			defer grupo.Done()
+			executar(ctx, tarefa, repos)
+		}(tarefa)
+	}
+
+	return &grupo
+}
+
+// executar roda a tarefa uma vez e depois a cada intervalo, até que o contexto seja cancelado
+func executar(ctx context.Context, tarefa Tarefa, repos *repositorios.Repositories) {
+	ctx = logs.NoContexto(ctx, slog.Default().With("tarefa", tarefa.Nome))
+
+	ticker := time.NewTicker(tarefa.Intervalo())
+	defer ticker.Stop()
+
+	for {
+		if erro := tarefa.Funcao(ctx, repos); erro != nil {
+			logs.DoContexto(ctx).Error("tarefa falhou", "erro", erro)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package tarefas
+
+import (
+	"api/src/config"
+	"api/src/modelos"
+	"api/src/relevancia"
+	"api/src/repositorios"
+	"context"
+	"sort"
+	"time"
+)
+
+var tarefaCalcularTendencias = Tarefa{
+	Nome:      "calcular-tendencias",
+	Intervalo: func() time.Duration { return config.IntervaloTendencias },
+	Funcao:    calcularTendencias,
+}
+
+// calcularTendencias calcula as publicações e hashtags em alta de cada janela de tempo. A pontuação é a
+// velocidade do engajamento: as interações recebidas dentro da janela divididas pela sua duração em horas
+func calcularTendencias(ctx context.Context, repos *repositorios.Repositories) error {
+	pesos := relevancia.PesosDaConfiguracao()
+	agora := time.Now()
+
+	for janela, duracao := range modelos.JanelasTendencias {
+		if erro := ctx.Err(); erro != nil {
+			return erro
+		}
+
+		desde := agora.Add(-duracao)
+		engajamentos, erro := repos.Tendencia.BuscarEngajamento(desde, pesos, config.MaximoCandidatosTendencias)
+		if erro != nil {
+			return erro
+		}
+
+		horas := duracao.Hours()
+		var publicacoes []modelos.Engajamento
+		porHashtag := make(map[string]*modelos.Hashtag)
+
+		for _, engajamento := range engajamentos {
+			engajamento.Pontuacao /= horas
+			if engajamento.Pontuacao > 0 && len(publicacoes) < config.QuantidadeTendencias {
+				publicacoes = append(publicacoes, engajamento)
+			}
+
+			if engajamento.CriadaEm.Before(desde) {
+				continue
+			}
+
+			for _, nome := range modelos.ExtrairHashtags(engajamento.Conteudo) {
+				hashtag, ok := porHashtag[nome]
+				if !ok {
+					hashtag = &modelos.Hashtag{Nome: nome}
+					porHashtag[nome] = hashtag
+				}
+
+				hashtag.Publicacoes++
+				hashtag.Pontuacao += 1/horas + engajamento.Pontuacao
+			}
+		}
+
+		if erro = repos.Tendencia.Salvar(janela, publicacoes, ordenarHashtags(porHashtag)); erro != nil {
+			return erro
+		}
+	}
+
+	return nil
+}
+
+// ordenarHashtags ordena as hashtags da maior para a menor pontuação e mantém só as primeiras
+func ordenarHashtags(porHashtag map[string]*modelos.Hashtag) []modelos.Hashtag {
+	hashtags := make([]modelos.Hashtag, 0, len(porHashtag))
+	for _, hashtag := range porHashtag {
+		hashtags = append(hashtags, *hashtag)
+	}
+
+	sort.Slice(hashtags, func(i, j int) bool {
+		if hashtags[i].Pontuacao != hashtags[j].Pontuacao {
+			return hashtags[i].Pontuacao > hashtags[j].Pontuacao
+		}
+		return hashtags[i].Nome < hashtags[j].Nome
+	})
+
+	if len(hashtags) > config.QuantidadeTendencias {
+		hashtags = hashtags[:config.QuantidadeTendencias]
+	}
+
+	return hashtags
+}
@@ -0,0 +1,232 @@
+// Package triagem confere o texto enviado pelos usuários contra as regras de filtro cadastradas pela
+// moderação: termos proibidos, expressões regulares e domínios de links bloqueados. Cada regra diz se o
+// texto deve ser recusado, retido para revisão ou publicado com o trecho encontrado mascarado
+package triagem
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// TipoTermo é uma palavra ou expressão que só é encontrada inteira, sem diferenciar maiúsculas
+	TipoTermo = "termo"
+
+	// TipoRegex é uma expressão regular na sintaxe do pacote regexp
+	TipoRegex = "regex"
+
+	// TipoDominio é um domínio cujos links não podem ser publicados, incluindo os subdomínios
+	TipoDominio = "dominio"
+)
+
+const (
+	// AcaoMascarar troca o trecho encontrado por asteriscos e deixa o texto seguir
+	AcaoMascarar = "mascarar"
+
+	// AcaoRevisar aceita o texto, mas esconde a publicação até um moderador liberar
+	AcaoRevisar = "revisar"
+
+	// AcaoRejeitar recusa o texto
+	AcaoRejeitar = "rejeitar"
+)
+
+// gravidade ordena as ações para que prevaleça a mais severa quando várias regras casam com o texto
+var gravidade = map[string]int{
+	"":           0,
+	AcaoMascarar: 1,
+	AcaoRevisar:  2,
+	AcaoRejeitar: 3,
+}
+
+// passadasMascara é quantas vezes, no máximo, uma regra de mascarar é aplicada ao mesmo texto
+const passadasMascara = 3
+
+// tamanhoMaximoPadrao é a quantidade máxima de caracteres do padrão de uma regra
+const tamanhoMaximoPadrao = 255
+
+// Regra representa uma regra de filtro cadastrada pela moderação
+type Regra struct {
+	ID       uint64    `json:"id,omitempty"`
+	Tipo     string    `json:"tipo"`
+	Padrao   string    `json:"padrao"`
+	Acao     string    `json:"acao"`
+	CriadaEm time.Time `json:"criadaEm,omitempty"`
+}
+
+// Resultado é o que a triagem decidiu sobre um texto
+type Resultado struct {
+	// Acao é a ação mais severa entre as regras que casaram, ou vazia se nenhuma casou
+	Acao string
+	// Texto é o texto recebido, com os trechos das regras de mascarar trocados por asteriscos
+	Texto string
+	// Motivos descreve as regras que casaram, para quem for revisar
+	Motivos []string
+}
+
+// regraCompilada é uma regra pronta para ser aplicada
+type regraCompilada struct {
+	Regra
+	expressao *regexp.Regexp
+	// mascararGrupo indica que só o grupo 1 é mascarado, deixando as bordas consumidas pela expressão
+	// como estão. Nas expressões regulares cadastradas, os grupos são da moderação e o trecho todo é mascarado
+	mascararGrupo bool
+}
+
+var (
+	mutex  sync.RWMutex
+	regras []regraCompilada
+)
+
+// Preparar vai validar e formatar a regra recebida
+func (regra *Regra) Preparar() error {
+	regra.Tipo = strings.TrimSpace(regra.Tipo)
+	regra.Acao = strings.TrimSpace(regra.Acao)
+	regra.Padrao = strings.TrimSpace(regra.Padrao)
+
+	if regra.Padrao == "" {
+		return errors.New("O padrão da regra é obrigatório e não pode estar em branco")
+	}
+
+	if utf8.RuneCountInString(regra.Padrao) > tamanhoMaximoPadrao {
+		return fmt.Errorf("O padrão da regra pode ter no máximo %d caracteres", tamanhoMaximoPadrao)
+	}
+
+	if _, existe := gravidade[regra.Acao]; !existe || regra.Acao == "" {
+		return fmt.Errorf("Ação inválida. Use uma destas: %s, %s, %s", AcaoMascarar, AcaoRevisar, AcaoRejeitar)
+	}
+
+	if regra.Tipo == TipoDominio {
+		regra.Padrao = normalizarDominio(regra.Padrao)
+	}
+
+	compilada, erro := compilar(*regra)
+	if erro != nil {
+		return erro
+	}
+
+	// Uma expressão que aceita o texto vazio casaria com qualquer publicação
+	if compilada.expressao.MatchString("") {
+		return errors.New("A expressão regular não pode aceitar um texto vazio")
+	}
+
+	return nil
+}
+
+// Carregar troca as regras em uso pelas informadas
+func Carregar(novas []Regra) error {
+	compiladas := make([]regraCompilada, 0, len(novas))
+	for _, regra := range novas {
+		compilada, erro := compilar(regra)
+		if erro != nil {
+			return fmt.Errorf("regra %d: %w", regra.ID, erro)
+		}
+		compiladas = append(compiladas, compilada)
+	}
+
+	mutex.Lock()
+	regras = compiladas
+	mutex.Unlock()
+
+	return nil
+}
+
+// Avaliar aplica as regras em uso ao texto
+func Avaliar(texto string) Resultado {
+	mutex.RLock()
+	emUso := regras
+	mutex.RUnlock()
+
+	resultado := Resultado{Texto: texto}
+	for _, regra := range emUso {
+		if !regra.expressao.MatchString(resultado.Texto) {
+			continue
+		}
+
+		resultado.Motivos = append(resultado.Motivos, fmt.Sprintf("%s %q", regra.Tipo, regra.Padrao))
+		if gravidade[regra.Acao] > gravidade[resultado.Acao] {
+			resultado.Acao = regra.Acao
+		}
+
+		if regra.Acao == AcaoMascarar {
+			// As bordas de um termo fazem parte do que a expressão consome, então ocorrências separadas
+			// por um único caractere só são encontradas numa segunda passada
+			for i := 0; i < passadasMascara && regra.expressao.MatchString(resultado.Texto); i++ {
+				resultado.Texto = mascarar(regra, resultado.Texto)
+			}
+		}
+	}
+
+	return resultado
+}
+
+// compilar monta a expressão regular que encontra o trecho proibido pela regra. Nos termos e domínios,
+// o grupo 1 é o trecho que deve ser mascarado
+func compilar(regra Regra) (regraCompilada, error) {
+	var padrao string
+
+	switch regra.Tipo {
+	case TipoTermo:
+		padrao = PadraoPalavraInteira(regra.Padrao)
+	case TipoRegex:
+		padrao = regra.Padrao
+	case TipoDominio:
+		padrao = `(?i)https?://((?:[^/\s?#:)\]]+\.)?` + regexp.QuoteMeta(regra.Padrao) + `)(?:$|[/\s?#:)\]])`
+	default:
+		return regraCompilada{}, fmt.Errorf("Tipo inválido. Use um destes: %s, %s, %s", TipoTermo, TipoRegex, TipoDominio)
+	}
+
+	expressao, erro := regexp.Compile(padrao)
+	if erro != nil {
+		return regraCompilada{}, fmt.Errorf("Expressão regular inválida: %w", erro)
+	}
+
+	return regraCompilada{Regra: regra, expressao: expressao, mascararGrupo: regra.Tipo != TipoRegex}, nil
+}
+
+// PadraoPalavraInteira monta a expressão regular que encontra o termo só quando ele aparece como palavra
+// inteira, sem diferenciar maiúsculas. O grupo 1 é o termo. O \b do pacote regexp só entende letras ASCII,
+// então as bordas são montadas à mão para que palavras acentuadas também sejam encontradas só inteiras.
+// A mesma expressão funciona no regexp_like do MySQL
+func PadraoPalavraInteira(termo string) string {
+	return `(?i)(?:^|[^\p{L}\p{N}_])(` + regexp.QuoteMeta(termo) + `)(?:$|[^\p{L}\p{N}_])`
+}
+
+// mascarar troca por asteriscos os trechos do texto encontrados pela regra
+func mascarar(regra regraCompilada, texto string) string {
+	var construtor strings.Builder
+	anterior := 0
+
+	for _, indices := range regra.expressao.FindAllStringSubmatchIndex(texto, -1) {
+		inicio, fim := indices[0], indices[1]
+		if regra.mascararGrupo && len(indices) >= 4 && indices[2] >= 0 {
+			inicio, fim = indices[2], indices[3]
+		}
+
+		construtor.WriteString(texto[anterior:inicio])
+		construtor.WriteString(strings.Repeat("*", utf8.RuneCountInString(texto[inicio:fim])))
+		anterior = fim
+	}
+
+	construtor.WriteString(texto[anterior:])
+	return construtor.String()
+}
+
+// normalizarDominio aceita o domínio com ou sem esquema e caminho e deixa só o nome, em minúsculas
+func normalizarDominio(dominio string) string {
+	dominio = strings.ToLower(dominio)
+	if !strings.Contains(dominio, "://") {
+		dominio = "http://" + dominio
+	}
+
+	if endereco, erro := url.Parse(dominio); erro == nil && endereco.Hostname() != "" {
+		dominio = endereco.Hostname()
+	}
+
+	return strings.TrimPrefix(dominio, "www.")
+}
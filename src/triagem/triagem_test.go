@@ -0,0 +1,200 @@
+package triagem
+
+import (
+	"testing"
+)
+
+// carregar põe as regras em uso durante o teste e as tira no fim dele
+func carregar(t *testing.T, novas ...Regra) {
+	t.Helper()
+
+	for i := range novas {
+		if erro := novas[i].Preparar(); erro != nil {
+			t.Fatalf("regra %q: %v", novas[i].Padrao, erro)
+		}
+	}
+
+	if erro := Carregar(novas); erro != nil {
+		t.Fatal(erro)
+	}
+	t.Cleanup(func() { Carregar(nil) })
+}
+
+func TestTermoSoCasaComPalavraInteira(t *testing.T) {
+	carregar(t,
+		Regra{Tipo: TipoTermo, Padrao: "maçã", Acao: AcaoRejeitar},
+		Regra{Tipo: TipoTermo, Padrao: "go", Acao: AcaoRejeitar},
+	)
+
+	testes := []struct {
+		nome  string
+		texto string
+		casou bool
+	}{
+		{"palavra acentuada sozinha", "maçã", true},
+		{"palavra acentuada no meio da frase", "comi uma maçã hoje", true},
+		{"palavra acentuada com pontuação", "comi uma maçã.", true},
+		{"palavra acentuada em maiúsculas", "COMI UMA MAÇÃ", true},
+		{"plural não é a mesma palavra", "comi duas maçãs", false},
+		{"letra acentuada antes do termo", "ámaçã", false},
+		{"termo curto como palavra", "Eu uso Go no trabalho", true},
+		{"termo curto dentro de outra palavra", "pesquisei no google", false},
+		{"termo curto seguido de letra acentuada", "goé", false},
+		{"termo curto depois de dígito", "1go", false},
+	}
+
+	for _, teste := range testes {
+		t.Run(teste.nome, func(t *testing.T) {
+			resultado := Avaliar(teste.texto)
+			if casou := resultado.Acao != ""; casou != teste.casou {
+				t.Errorf("esperava casar = %v, recebeu %v (%v)", teste.casou, casou, resultado.Motivos)
+			}
+		})
+	}
+}
+
+func TestDominioCasaComSubdominios(t *testing.T) {
+	regra := Regra{Tipo: TipoDominio, Padrao: "https://www.Exemplo.com/qualquer", Acao: AcaoRejeitar}
+	carregar(t, regra)
+
+	testes := []struct {
+		nome  string
+		texto string
+		casou bool
+	}{
+		{"o próprio domínio", "veja http://exemplo.com", true},
+		{"com caminho", "veja https://exemplo.com/pagina", true},
+		{"com porta e consulta", "veja https://exemplo.com:8080?x=1", true},
+		{"subdomínio", "veja https://www.exemplo.com/pagina", true},
+		{"subdomínio de vários níveis", "veja https://a.b.exemplo.com", true},
+		{"em maiúsculas", "veja HTTPS://EXEMPLO.COM", true},
+		{"entre parênteses", "(https://exemplo.com)", true},
+		{"domínio que só termina igual", "veja https://meuexemplo.com", false},
+		{"domínio que começa igual", "veja https://exemplo.community", false},
+		{"domínio como subdomínio de outro", "veja https://exemplo.com.golpe.net", false},
+		{"domínio no caminho de outro", "veja https://golpe.net/exemplo.com", false},
+		{"nome sem link", "o site exemplo.com", false},
+	}
+
+	for _, teste := range testes {
+		t.Run(teste.nome, func(t *testing.T) {
+			resultado := Avaliar(teste.texto)
+			if casou := resultado.Acao != ""; casou != teste.casou {
+				t.Errorf("esperava casar = %v, recebeu %v", teste.casou, casou)
+			}
+		})
+	}
+}
+
+func TestAcaoMaisSeveraPrevalece(t *testing.T) {
+	carregar(t,
+		Regra{Tipo: TipoTermo, Padrao: "chato", Acao: AcaoMascarar},
+		Regra{Tipo: TipoRegex, Padrao: `compre\s+agora`, Acao: AcaoRevisar},
+		Regra{Tipo: TipoDominio, Padrao: "golpe.net", Acao: AcaoRejeitar},
+	)
+
+	testes := []struct {
+		nome    string
+		texto   string
+		acao    string
+		motivos int
+	}{
+		{"nenhuma regra", "um texto qualquer", "", 0},
+		{"só mascarar", "que texto chato", AcaoMascarar, 1},
+		{"mascarar e revisar", "texto chato, compre agora", AcaoRevisar, 2},
+		{"revisar e rejeitar", "compre agora em https://golpe.net", AcaoRejeitar, 2},
+		{"as três", "chato, compre agora em https://golpe.net", AcaoRejeitar, 3},
+	}
+
+	for _, teste := range testes {
+		t.Run(teste.nome, func(t *testing.T) {
+			resultado := Avaliar(teste.texto)
+			if resultado.Acao != teste.acao {
+				t.Errorf("esperava a ação %q, recebeu %q", teste.acao, resultado.Acao)
+			}
+			if len(resultado.Motivos) != teste.motivos {
+				t.Errorf("esperava %d motivos, recebeu %v", teste.motivos, resultado.Motivos)
+			}
+		})
+	}
+}
+
+func TestMascarar(t *testing.T) {
+	testes := []struct {
+		nome     string
+		regra    Regra
+		texto    string
+		esperado string
+	}{
+		{
+			nome:     "ocorrências separadas por espaço",
+			regra:    Regra{Tipo: TipoTermo, Padrao: "feio", Acao: AcaoMascarar},
+			texto:    "feio feio feio",
+			esperado: "**** **** ****",
+		},
+		{
+			nome:     "ocorrências separadas por pontuação",
+			regra:    Regra{Tipo: TipoTermo, Padrao: "feio", Acao: AcaoMascarar},
+			texto:    "feio,feio.feio",
+			esperado: "****,****.****",
+		},
+		{
+			nome:     "conta caracteres, não bytes",
+			regra:    Regra{Tipo: TipoTermo, Padrao: "maçã", Acao: AcaoMascarar},
+			texto:    "maçã maçã",
+			esperado: "**** ****",
+		},
+		{
+			nome:     "mantém o que não é o termo",
+			regra:    Regra{Tipo: TipoTermo, Padrao: "feio", Acao: AcaoMascarar},
+			texto:    "feioso e feio",
+			esperado: "feioso e ****",
+		},
+		{
+			nome:     "domínio mascara só o nome",
+			regra:    Regra{Tipo: TipoDominio, Padrao: "golpe.net", Acao: AcaoMascarar},
+			texto:    "https://golpe.net/x https://a.golpe.net",
+			esperado: "https://*********/x https://***********",
+		},
+		{
+			nome:     "regex mascara o trecho todo, mesmo com grupos",
+			regra:    Regra{Tipo: TipoRegex, Padrao: `(\d{3})\.\d{3}\.\d{3}-\d{2}`, Acao: AcaoMascarar},
+			texto:    "cpf 123.456.789-00 e 987.654.321-00",
+			esperado: "cpf ************** e **************",
+		},
+	}
+
+	for _, teste := range testes {
+		t.Run(teste.nome, func(t *testing.T) {
+			carregar(t, teste.regra)
+
+			resultado := Avaliar(teste.texto)
+			if resultado.Texto != teste.esperado {
+				t.Errorf("esperava %q, recebeu %q", teste.esperado, resultado.Texto)
+			}
+		})
+	}
+}
+
+func TestPrepararRecusaRegexQueAceitaTextoVazio(t *testing.T) {
+	testes := []struct {
+		padrao string
+		valida bool
+	}{
+		{`a*`, false},
+		{`(spam)?`, false},
+		{`^`, false},
+		{`x|`, false},
+		{`a+`, true},
+		{`spam`, true},
+	}
+
+	for _, teste := range testes {
+		t.Run(teste.padrao, func(t *testing.T) {
+			regra := Regra{Tipo: TipoRegex, Padrao: teste.padrao, Acao: AcaoRejeitar}
+			if erro := regra.Preparar(); (erro == nil) != teste.valida {
+				t.Errorf("esperava válida = %v, recebeu erro %v", teste.valida, erro)
+			}
+		})
+	}
+}
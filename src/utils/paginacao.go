@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"api/src/modelos"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// limitePadrao é a quantidade de itens por página quando o parâmetro limite não é enviado
+	limitePadrao = 20
+
+	// limiteMaximo é a maior quantidade de itens por página que pode ser pedida
+	limiteMaximo = 100
+)
+
+var (
+	// ErrPaginacaoInvalida é retornado quando os parâmetros pagina ou limite não são números positivos
+	ErrPaginacaoInvalida = errors.New("os parâmetros pagina e limite devem ser números positivos")
+)
+
+// ExtrairPaginacao lê os parâmetros pagina e limite da query string da requisição
+func ExtrairPaginacao(r *http.Request) (modelos.Paginacao, error) {
+	paginacao := modelos.Paginacao{Pagina: 1, Limite: limitePadrao}
+
+	if pagina := r.URL.Query().Get("pagina"); pagina != "" {
+		valor, erro := strconv.ParseUint(pagina, 10, 64)
+		if erro != nil || valor == 0 {
+			return modelos.Paginacao{}, ErrPaginacaoInvalida
+		}
+		paginacao.Pagina = valor
+	}
+
+	if limite := r.URL.Query().Get("limite"); limite != "" {
+		valor, erro := strconv.ParseUint(limite, 10, 64)
+		if erro != nil || valor == 0 {
+			return modelos.Paginacao{}, ErrPaginacaoInvalida
+		}
+		paginacao.Limite = valor
+	}
+
+	if paginacao.Limite > limiteMaximo {
+		paginacao.Limite = limiteMaximo
+	}
+
+	return paginacao, nil
+}
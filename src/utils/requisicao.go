@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"api/src/config"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ExtrairIP retorna o IP de quem fez a requisição. O X-Forwarded-For só é usado quando a API está atrás de um
+// proxy confiável, já que fora disso qualquer cliente pode enviar o cabeçalho com o endereço que quiser
+func ExtrairIP(r *http.Request) string {
+	if config.ProxyConfiavel {
+		if encaminhado := r.Header.Get("X-Forwarded-For"); encaminhado != "" {
+			return strings.TrimSpace(strings.Split(encaminhado, ",")[0])
+		}
+	}
+
+	host, _, erro := net.SplitHostPort(r.RemoteAddr)
+	if erro != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}